@@ -12,11 +12,18 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/tendant/simple-content-mcp/pkg/mcpserver"
 	"github.com/tendant/simple-content-mcp/pkg/mcpserver/auth"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/facets"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/jobs"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/search"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/semantic"
 	"github.com/tendant/simple-content/pkg/simplecontent"
 	memoryrepo "github.com/tendant/simple-content/pkg/simplecontent/repo/memory"
 	postgresrepo "github.com/tendant/simple-content/pkg/simplecontent/repo/postgres"
 	fsstorage "github.com/tendant/simple-content/pkg/simplecontent/storage/fs"
 	memorystorage "github.com/tendant/simple-content/pkg/simplecontent/storage/memory"
+	s3storage "github.com/tendant/simple-content/pkg/simplecontent/storage/s3"
+	"github.com/tendant/simple-content/pkg/simplecontent/urlstrategy"
 )
 
 // LoadConfigFromEnv creates a server configuration from environment variables
@@ -59,6 +66,14 @@ func LoadConfigFromEnv(service simplecontent.Service) mcpserver.Config {
 			config.MaxPageSize = size
 		}
 	}
+	if secret := os.Getenv("MCP_CURSOR_SECRET"); secret != "" {
+		config.CursorSecret = []byte(secret)
+	}
+	if timeoutStr := os.Getenv("MCP_MAX_WAIT_TIMEOUT"); timeoutStr != "" {
+		if timeout, err := time.ParseDuration(timeoutStr); err == nil {
+			config.MaxWaitTimeout = timeout
+		}
+	}
 
 	// Feature flags
 	if resourcesStr := os.Getenv("MCP_ENABLE_RESOURCES"); resourcesStr != "" {
@@ -79,6 +94,84 @@ func LoadConfigFromEnv(service simplecontent.Service) mcpserver.Config {
 		}
 	}
 
+	// Search settings
+	if rebuildStr := os.Getenv("MCP_SEARCH_REBUILD_ON_STARTUP"); rebuildStr != "" {
+		if rebuild, err := strconv.ParseBool(rebuildStr); err == nil {
+			config.RebuildIndexOnStartup = rebuild
+		}
+	}
+	if bodiesStr := os.Getenv("MCP_SEARCH_BODIES"); bodiesStr != "" {
+		if bodies, err := strconv.ParseBool(bodiesStr); err == nil {
+			config.IndexBodies = bodies
+		}
+	}
+
+	// Maintenance settings
+	if intervalStr := os.Getenv("MCP_ORPHAN_SWEEP_INTERVAL"); intervalStr != "" {
+		if interval, err := time.ParseDuration(intervalStr); err == nil {
+			config.OrphanSweepInterval = interval
+		}
+	}
+	if intervalStr := os.Getenv("MCP_EXPIRY_SWEEP_INTERVAL"); intervalStr != "" {
+		if interval, err := time.ParseDuration(intervalStr); err == nil {
+			config.ExpirySweepInterval = interval
+		}
+	}
+	if action := os.Getenv("MCP_EXPIRY_ACTION"); action != "" {
+		config.ExpiryAction = mcpserver.ExpiryAction(action)
+	}
+
+	// Processing settings
+	if sizesStr := os.Getenv("MCP_THUMBNAIL_SIZES"); sizesStr != "" {
+		var sizes []int
+		for _, part := range strings.Split(sizesStr, ",") {
+			if size, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+				sizes = append(sizes, size)
+			}
+		}
+		config.ThumbnailSizes = sizes
+	}
+	if pixelsStr := os.Getenv("MCP_THUMBNAIL_MAX_PIXELS"); pixelsStr != "" {
+		if pixels, err := strconv.Atoi(pixelsStr); err == nil {
+			config.ThumbnailMaxPixels = pixels
+		}
+	}
+	if sizeStr := os.Getenv("MCP_THUMBNAIL_MAX_SIZE"); sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil {
+			config.ThumbnailMaxSize = size
+		}
+	}
+	if extractStr := os.Getenv("MCP_EXTRACT_TEXT"); extractStr != "" {
+		if enabled, err := strconv.ParseBool(extractStr); err == nil {
+			config.ExtractText = enabled
+		}
+	}
+	if policyStr := os.Getenv("MCP_DERIVATION_POLICY"); policyStr != "" {
+		if policy, err := mcpserver.ParseDerivationPolicy(policyStr); err == nil {
+			config.DerivationPolicy = policy
+		}
+	}
+	if workersStr := os.Getenv("MCP_JOB_WORKERS"); workersStr != "" {
+		if workers, err := strconv.Atoi(workersStr); err == nil {
+			config.JobWorkers = workers
+		}
+	}
+	if attemptsStr := os.Getenv("MCP_JOB_MAX_ATTEMPTS"); attemptsStr != "" {
+		if attempts, err := strconv.Atoi(attemptsStr); err == nil {
+			config.JobMaxAttempts = attempts
+		}
+	}
+	if limitStr := os.Getenv("MCP_JOB_ATTEMPTS_LIMIT"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			config.JobAttemptsLimit = limit
+		}
+	}
+	if backoffStr := os.Getenv("MCP_JOB_RETRY_BACKOFF"); backoffStr != "" {
+		if backoff, err := time.ParseDuration(backoffStr); err == nil {
+			config.JobRetryBackoff = backoff
+		}
+	}
+
 	// Authentication
 	if authStr := os.Getenv("MCP_AUTH_ENABLED"); authStr != "" {
 		if enabled, err := strconv.ParseBool(authStr); err == nil {
@@ -107,6 +200,15 @@ func loadAuthenticator() auth.Authenticator {
 
 		keyInfo := parseAPIKeyEnv(keyEnv)
 		if keyInfo != nil {
+			// Optional scopes: MCP_API_KEY_1_SCOPES=content:read,content:admin
+			if scopes := os.Getenv(fmt.Sprintf("MCP_API_KEY_%d_SCOPES", i)); scopes != "" {
+				for _, scope := range strings.Split(scopes, ",") {
+					if scope = strings.TrimSpace(scope); scope != "" {
+						keyInfo.Scopes = append(keyInfo.Scopes, scope)
+					}
+				}
+			}
+
 			authenticator.AddKey(keyInfo)
 		}
 	}
@@ -150,80 +252,274 @@ func parseAPIKeyEnv(value string) *auth.KeyInfo {
 	return keyInfo
 }
 
+// OpenDatabaseFromEnv connects to the PostgreSQL database in DATABASE_URL.
+// It returns nil when DATABASE_URL is not set. The pool is shared by the
+// repository and the PostgreSQL-backed server components; the caller closes it.
+func OpenDatabaseFromEnv(ctx context.Context) (*pgxpool.Pool, error) {
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		return nil, nil
+	}
+
+	pool, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+
+	// Test connection
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to ping PostgreSQL: %w", err)
+	}
+	return pool, nil
+}
+
 // CreateServiceFromEnv creates a simple-content service from environment variables
 // Supports multiple backends:
-// - Repository: memory, postgres (via DATABASE_URL)
-// - Storage: memory, fs (filesystem via STORAGE_PATH), s3 (requires AWS SDK)
+// - Repository: memory, postgres (when pool is set, see OpenDatabaseFromEnv)
+// - Storage: memory, fs (filesystem via STORAGE_PATH), s3 (via STORAGE_S3_*)
+// - Additional named storage backends via STORAGE_BACKENDS
 // Returns both service and repository (repository is needed for admin operations)
-func CreateServiceFromEnv() (simplecontent.Service, simplecontent.Repository, error) {
-	ctx := context.Background()
-
+func CreateServiceFromEnv(pool *pgxpool.Pool) (simplecontent.Service, simplecontent.Repository, error) {
 	// Configuration
-	databaseURL := os.Getenv("DATABASE_URL")
 	storageBackend := getEnvOrDefault("STORAGE_BACKEND", "memory")
-	storagePath := os.Getenv("STORAGE_PATH")
 
 	// Create repository
 	var repo simplecontent.Repository
-	var err error
 
-	if databaseURL != "" {
+	if pool != nil {
 		// PostgreSQL repository
-		pool, err := pgxpool.New(ctx, databaseURL)
+		repo = postgresrepo.New(pool)
+	} else {
+		// In-memory repository (default)
+		repo = memoryrepo.New()
+	}
+
+	// Create the default storage backend
+	store, err := createBlobStore(storageBackend, "STORAGE_")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stores := map[string]simplecontent.BlobStore{"default": store}
+	presigned := storageBackend == "s3"
+
+	// Additional named backends, e.g. STORAGE_BACKENDS=archive with
+	// STORAGE_ARCHIVE_BACKEND=fs and STORAGE_ARCHIVE_PATH=/mnt/archive
+	for _, name := range strings.Split(os.Getenv("STORAGE_BACKENDS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "default" {
+			continue
+		}
+
+		prefix := "STORAGE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		kind := os.Getenv(prefix + "BACKEND")
+		if kind == "" {
+			return nil, nil, fmt.Errorf("storage backend %q: %sBACKEND is required", name, prefix)
+		}
+
+		named, err := createBlobStore(kind, prefix)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+			return nil, nil, fmt.Errorf("storage backend %q: %w", name, err)
 		}
+		stores[name] = named
+		presigned = presigned || kind == "s3"
+	}
+
+	options := []simplecontent.Option{simplecontent.WithRepository(repo)}
+	urlStores := make(map[string]urlstrategy.BlobStore, len(stores))
+	for name, store := range stores {
+		options = append(options, simplecontent.WithBlobStore(name, store))
+		urlStores[name] = store
+	}
 
-		// Test connection
-		if err := pool.Ping(ctx); err != nil {
-			return nil, nil, fmt.Errorf("failed to ping PostgreSQL: %w", err)
+	// S3 backends hand out presigned URLs, so let each backend generate its own
+	if presigned {
+		options = append(options, simplecontent.WithURLStrategy(urlstrategy.NewStorageDelegatedStrategy(urlStores)))
+	}
+
+	// Create service
+	service, err := simplecontent.New(options...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create service: %w", err)
+	}
+
+	return service, repo, nil
+}
+
+// CreateJobQueueFromEnv creates the derivation job queue selected by
+// MCP_JOB_QUEUE: memory (default, returns nil so the server uses its own
+// in-memory queue) or postgres (stored in the database of pool, shared between instances)
+func CreateJobQueueFromEnv(ctx context.Context, pool *pgxpool.Pool) (jobs.Queue, error) {
+	switch kind := getEnvOrDefault("MCP_JOB_QUEUE", "memory"); kind {
+	case "memory":
+		return nil, nil
+
+	case "postgres":
+		if pool == nil {
+			return nil, fmt.Errorf("MCP_JOB_QUEUE=postgres requires a PostgreSQL connection (DATABASE_URL)")
 		}
 
-		repo = postgresrepo.New(pool)
-	} else {
-		// In-memory repository (default)
-		repo = memoryrepo.New()
+		queue := jobs.NewPostgresQueue(pool)
+		if err := queue.EnsureSchema(ctx); err != nil {
+			return nil, err
+		}
+		return queue, nil
+
+	default:
+		return nil, fmt.Errorf("unknown job queue: %s", kind)
+	}
+}
+
+// CreateSearchIndexFromEnv creates the search index selected by
+// MCP_SEARCH_INDEX: memory (default, returns nil so the server uses its own
+// in-memory index) or postgres (full-text search in the database of pool)
+func CreateSearchIndexFromEnv(ctx context.Context, pool *pgxpool.Pool) (search.Index, error) {
+	switch kind := getEnvOrDefault("MCP_SEARCH_INDEX", "memory"); kind {
+	case "memory":
+		return nil, nil
+
+	case "postgres":
+		if pool == nil {
+			return nil, fmt.Errorf("MCP_SEARCH_INDEX=postgres requires a PostgreSQL connection (DATABASE_URL)")
+		}
+
+		index := search.NewPostgresIndex(pool)
+		if err := index.EnsureSchema(ctx); err != nil {
+			return nil, err
+		}
+		return index, nil
+
+	default:
+		return nil, fmt.Errorf("unknown search index: %s", kind)
+	}
+}
+
+// CreateSemanticSearchFromEnv creates the embedder selected by MCP_EMBEDDER
+// and the vector index selected by MCP_VECTOR_INDEX. It returns nils when no
+// embedder is configured, leaving semantic search disabled. The postgres
+// vector index is stored in the database of pool.
+func CreateSemanticSearchFromEnv(ctx context.Context, pool *pgxpool.Pool) (semantic.Embedder, semantic.Index, error) {
+	var embedder semantic.Embedder
+	switch kind := os.Getenv("MCP_EMBEDDER"); kind {
+	case "", "none":
+		return nil, nil, nil
+
+	case "hash":
+		dimensions := semantic.DefaultDimensions
+		if dimensionsStr := os.Getenv("MCP_EMBEDDING_DIMENSIONS"); dimensionsStr != "" {
+			parsed, err := strconv.Atoi(dimensionsStr)
+			if err != nil || parsed <= 0 {
+				return nil, nil, fmt.Errorf("invalid MCP_EMBEDDING_DIMENSIONS: %s", dimensionsStr)
+			}
+			dimensions = parsed
+		}
+		embedder = semantic.NewHashEmbedder(dimensions)
+
+	default:
+		return nil, nil, fmt.Errorf("unknown embedder: %s", kind)
 	}
 
-	// Create storage backend
-	var store simplecontent.BlobStore
+	switch kind := getEnvOrDefault("MCP_VECTOR_INDEX", "memory"); kind {
+	case "memory":
+		return embedder, nil, nil
+
+	case "postgres":
+		if pool == nil {
+			return nil, nil, fmt.Errorf("MCP_VECTOR_INDEX=postgres requires a PostgreSQL connection (DATABASE_URL)")
+		}
 
-	switch storageBackend {
+		index := semantic.NewPostgresIndex(pool, embedder.Dimensions())
+		if err := index.EnsureSchema(ctx); err != nil {
+			return nil, nil, err
+		}
+		return embedder, index, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown vector index: %s", kind)
+	}
+}
+
+// CreateMetadataQuerierFromEnv creates the querier evaluating metadata
+// filters in the database of pool when repo is the PostgreSQL repository. It
+// returns nil for other repositories, so the server matches metadata in memory.
+func CreateMetadataQuerierFromEnv(pool *pgxpool.Pool, repo simplecontent.Repository) metafilter.Querier {
+	if _, ok := repo.(*postgresrepo.Repository); !ok || pool == nil {
+		return nil
+	}
+	return metafilter.NewPostgresQuerier(pool)
+}
+
+// CreateFacetAggregatorFromEnv creates the aggregator computing
+// content_facets in the database of pool when repo is the PostgreSQL
+// repository. It returns nil for other repositories, so the server counts
+// content one by one.
+func CreateFacetAggregatorFromEnv(pool *pgxpool.Pool, repo simplecontent.Repository) facets.Aggregator {
+	if _, ok := repo.(*postgresrepo.Repository); !ok || pool == nil {
+		return nil
+	}
+	return facets.NewPostgresAggregator(pool)
+}
+
+// createBlobStore creates a blob store of the given kind (memory, fs, s3).
+// Backend settings are read from environment variables starting with
+// envPrefix, e.g. STORAGE_PATH or STORAGE_ARCHIVE_S3_BUCKET.
+func createBlobStore(kind, envPrefix string) (simplecontent.BlobStore, error) {
+	switch kind {
 	case "fs", "filesystem":
 		// Filesystem storage
-		if storagePath == "" {
-			storagePath = "./data/storage"
-		}
-		store, err = fsstorage.New(fsstorage.Config{
-			BaseDir:   storagePath,
-			URLPrefix: getEnvOrDefault("STORAGE_URL_PREFIX", ""),
+		store, err := fsstorage.New(fsstorage.Config{
+			BaseDir:   getEnvOrDefault(envPrefix+"PATH", "./data/storage"),
+			URLPrefix: os.Getenv(envPrefix + "URL_PREFIX"),
 		})
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to create filesystem storage: %w", err)
+			return nil, fmt.Errorf("failed to create filesystem storage: %w", err)
 		}
+		return store, nil
 
 	case "s3":
-		// S3 storage - requires AWS SDK dependencies
-		return nil, nil, fmt.Errorf("S3 storage requires AWS SDK dependencies - not yet implemented")
+		// S3 or S3-compatible storage (MinIO, R2, ...)
+		store, err := s3storage.New(loadS3Config(envPrefix + "S3_"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create S3 storage: %w", err)
+		}
+		return store, nil
 
 	case "memory":
 		// In-memory storage (default)
-		store = memorystorage.New()
+		return memorystorage.New(), nil
 
 	default:
-		return nil, nil, fmt.Errorf("unknown storage backend: %s", storageBackend)
+		return nil, fmt.Errorf("unknown storage backend: %s", kind)
 	}
+}
 
-	// Create service
-	service, err := simplecontent.New(
-		simplecontent.WithRepository(repo),
-		simplecontent.WithBlobStore("default", store),
-	)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create service: %w", err)
+// loadS3Config reads S3 backend settings from environment variables starting with envPrefix
+// Credentials fall back to the AWS default chain (AWS_ACCESS_KEY_ID, profiles, IAM roles) when unset
+func loadS3Config(envPrefix string) s3storage.Config {
+	config := s3storage.Config{
+		Bucket:          os.Getenv(envPrefix + "BUCKET"),
+		Region:          getEnvOrDefault(envPrefix+"REGION", os.Getenv("AWS_REGION")),
+		Endpoint:        os.Getenv(envPrefix + "ENDPOINT"),
+		AccessKeyID:     os.Getenv(envPrefix + "ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv(envPrefix + "SECRET_ACCESS_KEY"),
+		UseSSL:          true,
 	}
 
-	return service, repo, nil
+	if v, err := strconv.ParseBool(os.Getenv(envPrefix + "USE_PATH_STYLE")); err == nil {
+		config.UsePathStyle = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv(envPrefix + "USE_SSL")); err == nil {
+		config.UseSSL = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv(envPrefix + "CREATE_BUCKET")); err == nil {
+		config.CreateBucketIfNotExist = v
+	}
+	if v, err := time.ParseDuration(os.Getenv(envPrefix + "PRESIGN_DURATION")); err == nil {
+		config.PresignDuration = int(v.Seconds())
+	}
+
+	return config
 }
 
 // getEnvOrDefault returns environment variable value or default
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// fakeS3 is a minimal path-style S3 stand-in supporting object PUT/GET/HEAD/DELETE
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") == "" && r.URL.Query().Get("X-Amz-Signature") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		body, err := readS3Body(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = body
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("ETag", `"etag"`)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// readS3Body returns the object payload, decoding aws-chunked uploads
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, reader, size); err != nil {
+			return nil, err
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func TestCreateServiceFromEnvS3(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("STORAGE_BACKEND", "s3")
+	t.Setenv("STORAGE_S3_ENDPOINT", server.URL)
+	t.Setenv("STORAGE_S3_BUCKET", "content")
+	t.Setenv("STORAGE_S3_REGION", "us-east-1")
+	t.Setenv("STORAGE_S3_ACCESS_KEY_ID", "minio")
+	t.Setenv("STORAGE_S3_SECRET_ACCESS_KEY", "minio-secret")
+	t.Setenv("STORAGE_S3_USE_PATH_STYLE", "true")
+	t.Setenv("STORAGE_S3_PRESIGN_DURATION", "15m")
+
+	service, _, err := CreateServiceFromEnv(nil)
+	if err != nil {
+		t.Fatalf("CreateServiceFromEnv failed: %v", err)
+	}
+	ctx := context.Background()
+
+	content, err := service.UploadContent(ctx, simplecontent.UploadContentRequest{
+		OwnerID:      uuid.New(),
+		Name:         "report.txt",
+		DocumentType: "text/plain",
+		FileName:     "report.txt",
+		Reader:       strings.NewReader("hello s3"),
+	})
+	if err != nil {
+		t.Fatalf("UploadContent failed: %v", err)
+	}
+	if len(fake.objects) != 1 {
+		t.Fatalf("Expected 1 object in the bucket, got %d", len(fake.objects))
+	}
+	for key := range fake.objects {
+		if !strings.HasPrefix(key, "/content/") {
+			t.Errorf("Expected path-style key under /content/, got %s", key)
+		}
+	}
+
+	reader, err := service.DownloadContent(ctx, content.ID)
+	if err != nil {
+		t.Fatalf("DownloadContent failed: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(data) != "hello s3" {
+		t.Fatalf("Expected downloaded data %q, got %q (%v)", "hello s3", data, err)
+	}
+
+	details, err := service.GetContentDetails(ctx, content.ID)
+	if err != nil {
+		t.Fatalf("GetContentDetails failed: %v", err)
+	}
+	if !strings.HasPrefix(details.Download, server.URL+"/content/") {
+		t.Errorf("Expected download URL on the S3 endpoint, got %q", details.Download)
+	}
+	if !strings.Contains(details.Download, "X-Amz-Signature=") || !strings.Contains(details.Download, "X-Amz-Expires=900") {
+		t.Errorf("Expected presigned download URL, got %q", details.Download)
+	}
+
+	// Presigned URLs are usable without further credentials
+	resp, err := http.Get(details.Download)
+	if err != nil {
+		t.Fatalf("Failed to fetch presigned URL: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected presigned GET to succeed, got %d", resp.StatusCode)
+	}
+
+	details, err = service.GetContentDetails(ctx, content.ID, simplecontent.WithUploadAccess())
+	if err != nil {
+		t.Fatalf("GetContentDetails with upload access failed: %v", err)
+	}
+	if !strings.HasPrefix(details.Upload, server.URL+"/content/") || !strings.Contains(details.Upload, "X-Amz-Signature=") {
+		t.Errorf("Expected presigned upload URL, got %q", details.Upload)
+	}
+}
+
+func TestCreateServiceFromEnvS3RequiresBucket(t *testing.T) {
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("STORAGE_BACKEND", "s3")
+	t.Setenv("STORAGE_S3_BUCKET", "")
+
+	if _, _, err := CreateServiceFromEnv(nil); err == nil {
+		t.Error("Expected an error when STORAGE_S3_BUCKET is missing")
+	}
+}
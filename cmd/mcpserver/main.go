@@ -61,9 +61,20 @@ func main() {
 		cancel()
 	}()
 
+	// One PostgreSQL pool (DATABASE_URL) is shared by the repository, the
+	// job queue, the indexes and the queriers, and closed on shutdown
+	pool, err := OpenDatabaseFromEnv(ctx)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		log.Println("Falling back to in-memory repository...")
+	}
+	if pool != nil {
+		defer pool.Close()
+	}
+
 	// Initialize simple-content service
 	// Try to load from environment first, fallback to in-memory
-	service, repo, err := CreateServiceFromEnv()
+	service, repo, err := CreateServiceFromEnv(pool)
 	if err != nil {
 		log.Printf("Warning: Failed to load service from environment: %v", err)
 		log.Println("Falling back to in-memory service...")
@@ -76,6 +87,30 @@ func main() {
 	// Create MCP server configuration from environment
 	config := LoadConfigFromEnv(service)
 
+	// Derivation jobs may be queued in PostgreSQL
+	config.JobQueue, err = CreateJobQueueFromEnv(ctx, pool)
+	if err != nil {
+		log.Fatalf("Failed to create job queue: %v", err)
+	}
+
+	// Search may use PostgreSQL full-text search
+	config.SearchIndex, err = CreateSearchIndexFromEnv(ctx, pool)
+	if err != nil {
+		log.Fatalf("Failed to create search index: %v", err)
+	}
+
+	// Semantic search needs an embedder and may store vectors with pgvector
+	config.Embedder, config.VectorIndex, err = CreateSemanticSearchFromEnv(ctx, pool)
+	if err != nil {
+		log.Fatalf("Failed to create semantic search: %v", err)
+	}
+
+	// Metadata filters run as JSONB queries with the PostgreSQL repository
+	config.MetadataQuerier = CreateMetadataQuerierFromEnv(pool, repo)
+
+	// Facets are computed with GROUP BY queries with the PostgreSQL repository
+	config.FacetAggregator = CreateFacetAggregatorFromEnv(pool, repo)
+
 	// Create admin service if repository is available
 	if repo != nil {
 		config.AdminService = admin.New(repo)
@@ -16,6 +16,19 @@ type KeyInfo struct {
 	Scopes    []string // Optional: content:read, content:write, etc.
 }
 
+// ScopeAdmin grants access to content of any owner or tenant
+const ScopeAdmin = "content:admin"
+
+// HasScope reports whether the key carries the given scope
+func (k *KeyInfo) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 // Authenticator validates API keys and manages authentication
 type Authenticator interface {
 	// Validate checks if an API key is valid and returns associated key info
@@ -68,3 +81,21 @@ func EnforceTenant(ctx context.Context, tenantID uuid.UUID) error {
 
 	return nil
 }
+
+// EnforceAccess checks that the authenticated key may act on content of the
+// given owner and tenant. Keys with ScopeAdmin may act on any owner.
+func EnforceAccess(ctx context.Context, ownerID, tenantID uuid.UUID) error {
+	keyInfo, ok := GetKeyInfo(ctx)
+	if !ok {
+		return ErrUnauthorized
+	}
+
+	if keyInfo.HasScope(ScopeAdmin) {
+		return nil
+	}
+
+	if err := EnforceOwnership(ctx, ownerID); err != nil {
+		return err
+	}
+	return EnforceTenant(ctx, tenantID)
+}
@@ -0,0 +1,199 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/admin"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+)
+
+// sweepPageSize is the number of contents fetched per page when scanning for orphans
+const sweepPageSize = 500
+
+// derivedNode describes a derived content found while walking a content tree
+type derivedNode struct {
+	ContentID      uuid.UUID `json:"content_id"`
+	ParentID       uuid.UUID `json:"parent_id"`
+	DerivationType string    `json:"derivation_type"`
+	Variant        string    `json:"variant"`
+	Depth          int       `json:"depth"`
+}
+
+// collectDerivedTree walks derived content below rootID and returns every
+// live descendant, deepest first, so that children are removed before parents
+func (s *Server) collectDerivedTree(ctx context.Context, rootID uuid.UUID) ([]derivedNode, error) {
+	visited := map[uuid.UUID]bool{rootID: true}
+	var nodes []derivedNode
+
+	var walk func(parentID uuid.UUID, depth int) error
+	walk = func(parentID uuid.UUID, depth int) error {
+		children, err := s.service.ListDerivedContent(ctx, simplecontent.WithParentID(parentID))
+		if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			if visited[child.ContentID] {
+				continue
+			}
+			visited[child.ContentID] = true
+
+			// Relationships may outlive soft-deleted children
+			if _, err := s.service.GetContent(ctx, child.ContentID); err != nil {
+				if mcperrors.IsNotFound(err) {
+					continue
+				}
+				return err
+			}
+
+			if err := walk(child.ContentID, depth+1); err != nil {
+				return err
+			}
+
+			nodes = append(nodes, derivedNode{
+				ContentID:      child.ContentID,
+				ParentID:       parentID,
+				DerivationType: child.DerivationType,
+				Variant:        child.Variant,
+				Depth:          depth,
+			})
+		}
+		return nil
+	}
+
+	if err := walk(rootID, 1); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// deleteContentTree deletes a content and, when cascade is set, all of its
+// derived descendants. It returns the IDs that were deleted in order, which
+// are removed from the search index even if a later deletion fails.
+func (s *Server) deleteContentTree(ctx context.Context, contentID uuid.UUID, cascade bool) ([]uuid.UUID, error) {
+	var deleted []uuid.UUID
+	defer func() { s.unindexContent(ctx, deleted...) }()
+
+	if cascade {
+		nodes, err := s.collectDerivedTree(ctx, contentID)
+		if err != nil {
+			return deleted, err
+		}
+		for _, node := range nodes {
+			if err := s.service.DeleteContent(ctx, node.ContentID); err != nil {
+				return deleted, fmt.Errorf("failed to delete derived content %s: %w", node.ContentID, err)
+			}
+			deleted = append(deleted, node.ContentID)
+		}
+	}
+
+	if err := s.service.DeleteContent(ctx, contentID); err != nil {
+		return deleted, err
+	}
+	deleted = append(deleted, contentID)
+
+	return deleted, nil
+}
+
+// SweepOrphans deletes derived content whose parent no longer exists.
+// It requires an AdminService to enumerate content and returns the number
+// of contents removed. Content whose relationship or parent cannot be looked
+// up is left in place and reported in the error, along with that number.
+func (s *Server) SweepOrphans(ctx context.Context) (int, error) {
+	if s.adminService == nil {
+		return 0, fmt.Errorf("orphan sweep requires an admin service")
+	}
+
+	// Collect orphans first so deletions don't shift the pages being scanned.
+	// Content whose lookups fail is skipped and reported after the sweep.
+	var orphans []uuid.UUID
+	var failed int
+	var firstErr error
+	lookupFailed := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+		failed++
+	}
+	limit := sweepPageSize
+	for offset := 0; ; offset += limit {
+		pageOffset := offset
+		resp, err := s.adminService.ListAllContents(ctx, admin.ListContentsRequest{
+			Filters: admin.ContentFilters{
+				Limit:  &limit,
+				Offset: &pageOffset,
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, content := range resp.Contents {
+			if content.DerivationType == "" || content.DeletedAt != nil {
+				continue
+			}
+
+			rel, err := s.service.GetDerivedRelationship(ctx, content.ID)
+			if err != nil {
+				// Derived content may have no relationship left
+				if !mcperrors.IsNotFound(err) {
+					lookupFailed(err)
+				}
+				continue
+			}
+
+			if _, err := s.service.GetContent(ctx, rel.ParentID); err != nil {
+				if mcperrors.IsNotFound(err) {
+					orphans = append(orphans, content.ID)
+				} else {
+					lookupFailed(err)
+				}
+			}
+		}
+
+		if len(resp.Contents) < limit {
+			break
+		}
+	}
+
+	removed := 0
+	for _, id := range orphans {
+		deleted, err := s.deleteContentTree(ctx, id, true)
+		removed += len(deleted)
+		if err != nil && !mcperrors.IsNotFound(err) {
+			return removed, err
+		}
+	}
+
+	if failed > 0 {
+		return removed, fmt.Errorf("failed to check %d derived content item(s): %w", failed, firstErr)
+	}
+	return removed, nil
+}
+
+// runOrphanSweeper periodically removes orphaned derived content until ctx is cancelled
+func (s *Server) runOrphanSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := s.SweepOrphans(ctx)
+			if err != nil {
+				log.Printf("Orphan sweep failed: %v", err)
+			}
+			if removed > 0 {
+				log.Printf("Orphan sweep removed %d derived content item(s)", removed)
+			}
+		}
+	}
+}
@@ -0,0 +1,667 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+)
+
+// Collections are stored as content records of their own document type, so
+// they persist in whatever repository backs the service. Membership and
+// nesting live in reserved metadata on the collection records.
+const (
+	// collectionDocumentType marks a content record as a collection
+	collectionDocumentType = "application/vnd.simple-content.collection"
+
+	// collectionMembersKey holds the ordered member IDs of a collection,
+	// including nested collections
+	collectionMembersKey = reservedMetadataPrefix + "collection_members"
+
+	// collectionParentKey holds the ID of the collection a collection is nested in
+	collectionParentKey = reservedMetadataPrefix + "parent_collection"
+)
+
+// isCollection reports whether content is a collection record
+func isCollection(content *simplecontent.Content) bool {
+	return content.DocumentType == collectionDocumentType
+}
+
+// collectionMembers decodes the member IDs stored in collection metadata
+func collectionMembers(metadata map[string]interface{}) []uuid.UUID {
+	raw, _ := metadata[collectionMembersKey].([]interface{})
+	members := make([]uuid.UUID, 0, len(raw))
+	for _, v := range raw {
+		if id, err := parseUUID(v); err == nil {
+			members = append(members, id)
+		}
+	}
+	return members
+}
+
+// collectionParent returns the collection a collection is nested in, or uuid.Nil
+func collectionParent(metadata map[string]interface{}) uuid.UUID {
+	return parseTenantID(metadata[collectionParentKey])
+}
+
+// setCollectionMembers stores member IDs in collection metadata
+func setCollectionMembers(metadata map[string]interface{}, members []uuid.UUID) {
+	ids := make([]interface{}, len(members))
+	for i, id := range members {
+		ids[i] = id.String()
+	}
+	metadata[collectionMembersKey] = ids
+}
+
+// getCollection loads a collection and checks the caller may access it
+func (s *Server) getCollection(ctx context.Context, collectionID uuid.UUID) (*simplecontent.Content, *simplecontent.ContentMetadata, error) {
+	content, err := s.service.GetContent(ctx, collectionID)
+	if err != nil {
+		return nil, nil, s.mapError(err)
+	}
+	if !isCollection(content) {
+		return nil, nil, mcperrors.NewNotFoundError("collection", collectionID.String())
+	}
+	if err := s.authorizeAccess(ctx, content.OwnerID, content.TenantID); err != nil {
+		return nil, nil, err
+	}
+
+	metadata, err := s.loadContentMetadata(ctx, collectionID)
+	if err != nil {
+		return nil, nil, s.mapError(err)
+	}
+	return content, metadata, nil
+}
+
+// updateCollection applies fn to the metadata of a collection under its lock
+func (s *Server) updateCollection(ctx context.Context, collectionID uuid.UUID, fn func(custom map[string]interface{}) error) error {
+	unlock := s.locks.lock(collectionID)
+	defer unlock()
+
+	_, metadata, err := s.getCollection(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+
+	custom := metadata.Metadata
+	if err := fn(custom); err != nil {
+		return err
+	}
+	if err := s.saveContentMetadata(ctx, metadata, metadata.Tags, custom); err != nil {
+		return s.mapError(err)
+	}
+	return nil
+}
+
+// collectionContents returns the live, non-collection members of a
+// collection that the caller may access. Access to the collection itself is
+// checked by getCollection.
+func (s *Server) collectionContents(ctx context.Context, collectionID uuid.UUID) ([]*simplecontent.Content, error) {
+	_, metadata, err := s.getCollection(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := []*simplecontent.Content{}
+	for _, id := range collectionMembers(metadata.Metadata) {
+		content, err := s.service.GetContent(ctx, id)
+		if err != nil {
+			// Deleted members are skipped rather than pruned on read
+			if mcperrors.IsNotFound(err) {
+				continue
+			}
+			return nil, s.mapError(err)
+		}
+		// Members of other owners the caller has no access to are hidden
+		if isCollection(content) || s.authorizeAccess(ctx, content.OwnerID, content.TenantID) != nil {
+			continue
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
+
+// withoutCollections drops collection records from a content list
+func withoutCollections(contents []*simplecontent.Content) []*simplecontent.Content {
+	result := make([]*simplecontent.Content, 0, len(contents))
+	for _, content := range contents {
+		if !isCollection(content) {
+			result = append(result, content)
+		}
+	}
+	return result
+}
+
+// collectionSummary formats a collection for tool and resource results
+func collectionSummary(content *simplecontent.Content, metadata map[string]interface{}) map[string]interface{} {
+	summary := map[string]interface{}{
+		"id":          content.ID.String(),
+		"owner_id":    content.OwnerID.String(),
+		"tenant_id":   content.TenantID.String(),
+		"name":        content.Name,
+		"description": content.Description,
+		"created_at":  content.CreatedAt,
+		"updated_at":  content.UpdatedAt,
+	}
+	if parentID := collectionParent(metadata); parentID != uuid.Nil {
+		summary["parent_id"] = parentID.String()
+	}
+	return summary
+}
+
+// handleCreateCollection creates a collection, optionally nested in another
+func (s *Server) handleCreateCollection(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	ownerID, err := parseUUID(params["owner_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("owner_id", err)
+	}
+	tenantID := parseTenantID(params["tenant_id"])
+
+	name := getStringOr(params, "name", "")
+	if name == "" {
+		return nil, mcperrors.NewValidationError("name", fmt.Errorf("required"))
+	}
+
+	if err := s.authorizeAccess(ctx, ownerID, tenantID); err != nil {
+		return nil, err
+	}
+
+	var parentID uuid.UUID
+	if raw, ok := params["parent_id"]; ok && raw != nil {
+		if parentID, err = parseUUID(raw); err != nil {
+			return nil, mcperrors.NewValidationError("parent_id", err)
+		}
+		parent, _, err := s.getCollection(ctx, parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.OwnerID != ownerID || parent.TenantID != tenantID {
+			return nil, mcperrors.NewValidationError("parent_id", fmt.Errorf("parent collection belongs to another owner or tenant"))
+		}
+	}
+
+	collection, err := s.service.CreateContent(ctx, simplecontent.CreateContentRequest{
+		OwnerID:      ownerID,
+		TenantID:     tenantID,
+		Name:         name,
+		Description:  getStringOr(params, "description", ""),
+		DocumentType: collectionDocumentType,
+	})
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+
+	custom := map[string]interface{}{}
+	setCollectionMembers(custom, nil)
+	if parentID != uuid.Nil {
+		custom[collectionParentKey] = parentID.String()
+	}
+	metadata := &simplecontent.ContentMetadata{ContentID: collection.ID}
+	if err := s.saveContentMetadata(ctx, metadata, nil, custom); err != nil {
+		return nil, s.mapError(err)
+	}
+
+	if parentID != uuid.Nil {
+		err := s.updateCollection(ctx, parentID, func(parent map[string]interface{}) error {
+			setCollectionMembers(parent, append(collectionMembers(parent), collection.ID))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newTextResult(formatJSON(collectionSummary(collection, custom))), nil
+}
+
+// handleAddToCollection adds content or collections to a collection
+func (s *Server) handleAddToCollection(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	collectionID, err := parseUUID(params["collection_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("collection_id", err)
+	}
+	ids, err := s.parseMemberIDs(params)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, _, err := s.getCollection(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate every member before changing anything
+	var nested []uuid.UUID
+	for _, id := range ids {
+		content, err := s.service.GetContent(ctx, id)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		if content.OwnerID != collection.OwnerID || content.TenantID != collection.TenantID {
+			return nil, mcperrors.NewValidationError("content_ids", fmt.Errorf("content %s belongs to another owner or tenant", id))
+		}
+		if isCollection(content) {
+			if err := s.checkNesting(ctx, id, collectionID); err != nil {
+				return nil, err
+			}
+			nested = append(nested, id)
+		}
+	}
+
+	// A collection has a single parent; record it on the nested collections
+	for _, id := range nested {
+		err := s.updateCollection(ctx, id, func(custom map[string]interface{}) error {
+			if parentID := collectionParent(custom); parentID != uuid.Nil && parentID != collectionID {
+				return mcperrors.NewConflictError(fmt.Sprintf("collection %s is already nested in %s; remove it there first", id, parentID))
+			}
+			custom[collectionParentKey] = collectionID.String()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	added := 0
+	err = s.updateCollection(ctx, collectionID, func(custom map[string]interface{}) error {
+		members := collectionMembers(custom)
+		present := make(map[uuid.UUID]bool, len(members))
+		for _, id := range members {
+			present[id] = true
+		}
+		for _, id := range ids {
+			if !present[id] {
+				present[id] = true
+				members = append(members, id)
+				added++
+			}
+		}
+		setCollectionMembers(custom, members)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newTextResult(formatJSON(map[string]interface{}{
+		"success":         true,
+		"collection_id":   collectionID.String(),
+		"added":           added,
+		"already_present": len(ids) - added,
+	})), nil
+}
+
+// handleRemoveFromCollection removes content or collections from a collection.
+// The removed content itself is not deleted.
+func (s *Server) handleRemoveFromCollection(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	collectionID, err := parseUUID(params["collection_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("collection_id", err)
+	}
+	ids, err := s.parseMemberIDs(params)
+	if err != nil {
+		return nil, err
+	}
+
+	drop := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		drop[id] = true
+	}
+
+	var removed []uuid.UUID
+	err = s.updateCollection(ctx, collectionID, func(custom map[string]interface{}) error {
+		var kept []uuid.UUID
+		for _, id := range collectionMembers(custom) {
+			if drop[id] {
+				removed = append(removed, id)
+			} else {
+				kept = append(kept, id)
+			}
+		}
+		setCollectionMembers(custom, kept)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Nested collections that were removed become top-level collections
+	for _, id := range removed {
+		content, err := s.service.GetContent(ctx, id)
+		if err != nil || !isCollection(content) {
+			continue
+		}
+		err = s.updateCollection(ctx, id, func(custom map[string]interface{}) error {
+			if collectionParent(custom) == collectionID {
+				delete(custom, collectionParentKey)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newTextResult(formatJSON(map[string]interface{}{
+		"success":       true,
+		"collection_id": collectionID.String(),
+		"removed":       len(removed),
+	})), nil
+}
+
+// handleListCollection lists the contents of a collection, or the top-level
+// collections of an owner when no collection_id is given
+func (s *Server) handleListCollection(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	limit := getIntOr(params, "limit", s.config.DefaultPageSize)
+	if limit > s.config.MaxPageSize {
+		limit = s.config.MaxPageSize
+	}
+	offset := getIntOr(params, "offset", 0)
+
+	if _, ok := params["collection_id"]; !ok {
+		return s.listRootCollections(ctx, params, limit, offset)
+	}
+
+	collectionID, err := parseUUID(params["collection_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("collection_id", err)
+	}
+
+	collection, metadata, err := s.getCollection(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	listing, err := s.listCollectionMembers(ctx, collectionID, metadata.Metadata, getBoolOr(params, "recursive", false), map[uuid.UUID]bool{collectionID: true})
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(listing.items)
+	start := offset
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	return newTextResult(formatJSON(map[string]interface{}{
+		"collection":  collectionSummary(collection, metadata.Metadata),
+		"collections": listing.collections,
+		"items":       listing.items[start:end],
+		"total":       total,
+		"limit":       limit,
+		"offset":      offset,
+	})), nil
+}
+
+// collectionListing holds the members of a collection split by kind
+type collectionListing struct {
+	collections []map[string]interface{}
+	items       []map[string]interface{}
+}
+
+// listCollectionMembers resolves the members of a collection. With recursive
+// set, items of nested collections are included, tagged with the collection
+// they were found in.
+func (s *Server) listCollectionMembers(ctx context.Context, collectionID uuid.UUID, metadata map[string]interface{}, recursive bool, visited map[uuid.UUID]bool) (*collectionListing, error) {
+	listing := &collectionListing{
+		collections: []map[string]interface{}{},
+		items:       []map[string]interface{}{},
+	}
+
+	for _, id := range collectionMembers(metadata) {
+		content, err := s.service.GetContent(ctx, id)
+		if err != nil {
+			if mcperrors.IsNotFound(err) {
+				continue
+			}
+			return nil, s.mapError(err)
+		}
+
+		if !isCollection(content) {
+			listing.items = append(listing.items, map[string]interface{}{
+				"id":              content.ID.String(),
+				"name":            content.Name,
+				"description":     content.Description,
+				"status":          string(content.Status),
+				"document_type":   content.DocumentType,
+				"derivation_type": content.DerivationType,
+				"collection_id":   collectionID.String(),
+				"created_at":      content.CreatedAt,
+				"updated_at":      content.UpdatedAt,
+			})
+			continue
+		}
+
+		child, err := s.loadContentMetadata(ctx, id)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		summary := collectionSummary(content, child.Metadata)
+		summary["member_count"] = len(collectionMembers(child.Metadata))
+		listing.collections = append(listing.collections, summary)
+
+		if recursive && !visited[id] {
+			visited[id] = true
+			nested, err := s.listCollectionMembers(ctx, id, child.Metadata, true, visited)
+			if err != nil {
+				return nil, err
+			}
+			listing.collections = append(listing.collections, nested.collections...)
+			listing.items = append(listing.items, nested.items...)
+		}
+	}
+	return listing, nil
+}
+
+// listRootCollections lists the collections of an owner that are not nested in another
+func (s *Server) listRootCollections(ctx context.Context, params map[string]interface{}, limit, offset int) (*mcp.CallToolResult, error) {
+	ownerID, err := parseUUID(params["owner_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("owner_id", fmt.Errorf("collection_id or owner_id is required"))
+	}
+	tenantID := parseTenantID(params["tenant_id"])
+
+	if err := s.authorizeAccess(ctx, ownerID, tenantID); err != nil {
+		return nil, err
+	}
+
+	contents, err := s.service.ListContent(ctx, simplecontent.ListContentRequest{OwnerID: ownerID, TenantID: tenantID})
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+
+	collections := []map[string]interface{}{}
+	for _, content := range contents {
+		if !isCollection(content) {
+			continue
+		}
+		metadata, err := s.loadContentMetadata(ctx, content.ID)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		if collectionParent(metadata.Metadata) != uuid.Nil {
+			continue
+		}
+		summary := collectionSummary(content, metadata.Metadata)
+		summary["member_count"] = len(collectionMembers(metadata.Metadata))
+		collections = append(collections, summary)
+	}
+
+	total := len(collections)
+	start := offset
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	return newTextResult(formatJSON(map[string]interface{}{
+		"collections": collections[start:end],
+		"total":       total,
+		"limit":       limit,
+		"offset":      offset,
+	})), nil
+}
+
+// handleDeleteCollection deletes a collection. Its member content is kept;
+// nested collections are deleted only when recursive is set.
+func (s *Server) handleDeleteCollection(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	collectionID, err := parseUUID(params["collection_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("collection_id", err)
+	}
+	recursive := getBoolOr(params, "recursive", false)
+
+	_, metadata, err := s.getCollection(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect nested collections, deepest first
+	var nested []uuid.UUID
+	var walk func(metadata map[string]interface{}) error
+	visited := map[uuid.UUID]bool{collectionID: true}
+	walk = func(metadata map[string]interface{}) error {
+		for _, id := range collectionMembers(metadata) {
+			content, err := s.service.GetContent(ctx, id)
+			if err != nil || !isCollection(content) || visited[id] {
+				continue
+			}
+			visited[id] = true
+			child, err := s.loadContentMetadata(ctx, id)
+			if err != nil {
+				return s.mapError(err)
+			}
+			if err := walk(child.Metadata); err != nil {
+				return err
+			}
+			nested = append(nested, id)
+		}
+		return nil
+	}
+	if err := walk(metadata.Metadata); err != nil {
+		return nil, err
+	}
+
+	if len(nested) > 0 && !recursive {
+		return nil, mcperrors.NewValidationError("recursive", fmt.Errorf("collection has %d nested collection(s); set recursive to delete them", len(nested)))
+	}
+
+	deleted := make([]string, 0, len(nested)+1)
+	for _, id := range append(nested, collectionID) {
+		if err := s.service.DeleteContent(ctx, id); err != nil {
+			return nil, s.mapError(err)
+		}
+		deleted = append(deleted, id.String())
+	}
+
+	// Detach from the parent collection
+	if parentID := collectionParent(metadata.Metadata); parentID != uuid.Nil {
+		err := s.updateCollection(ctx, parentID, func(custom map[string]interface{}) error {
+			var kept []uuid.UUID
+			for _, id := range collectionMembers(custom) {
+				if id != collectionID {
+					kept = append(kept, id)
+				}
+			}
+			setCollectionMembers(custom, kept)
+			return nil
+		})
+		if err != nil && !mcperrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	return newTextResult(formatJSON(map[string]interface{}{
+		"success": true,
+		"deleted": deleted,
+	})), nil
+}
+
+// parseCollectionFilter reads the optional collection_id filter of the list tools
+func parseCollectionFilter(params map[string]interface{}) (uuid.UUID, bool, error) {
+	raw, ok := params["collection_id"]
+	if !ok || raw == nil || raw == "" {
+		return uuid.Nil, false, nil
+	}
+	collectionID, err := parseUUID(raw)
+	if err != nil {
+		return uuid.Nil, false, mcperrors.NewValidationError("collection_id", err)
+	}
+	return collectionID, true, nil
+}
+
+// parseMemberIDs parses the content_ids argument of the membership tools
+func (s *Server) parseMemberIDs(params map[string]interface{}) ([]uuid.UUID, error) {
+	raw := getStringSlice(params, "content_ids")
+	if len(raw) == 0 {
+		return nil, mcperrors.NewValidationError("content_ids", fmt.Errorf("at least one content ID is required"))
+	}
+	if len(raw) > s.config.MaxBatchSize {
+		return nil, mcperrors.NewValidationError("content_ids", fmt.Errorf("%d IDs exceeds maximum %d", len(raw), s.config.MaxBatchSize))
+	}
+
+	ids := make([]uuid.UUID, len(raw))
+	for i, v := range raw {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, mcperrors.NewValidationError(fmt.Sprintf("content_ids[%d]", i), err)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// checkNesting fails if nesting collection childID inside parentID would create a cycle
+func (s *Server) checkNesting(ctx context.Context, childID, parentID uuid.UUID) error {
+	seen := map[uuid.UUID]bool{}
+	for id := parentID; id != uuid.Nil && !seen[id]; {
+		if id == childID {
+			return mcperrors.NewValidationError("content_ids", fmt.Errorf("collection %s cannot be nested inside itself", childID))
+		}
+		seen[id] = true
+
+		metadata, err := s.loadContentMetadata(ctx, id)
+		if err != nil {
+			return s.mapError(err)
+		}
+		id = collectionParent(metadata.Metadata)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package mcpserver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+)
+
+// contentETag derives a version token from the content's last modification time.
+// Microsecond precision matches what the PostgreSQL repository stores.
+func contentETag(content *simplecontent.Content) string {
+	return strconv.FormatInt(content.UpdatedAt.UnixMicro(), 36)
+}
+
+// checkIfMatch fails with a conflict error when the if_match argument is set
+// and no longer matches the current version of content
+func checkIfMatch(params map[string]interface{}, content *simplecontent.Content) error {
+	ifMatch := strings.Trim(getStringOr(params, "if_match", ""), `"`)
+	if ifMatch == "" || ifMatch == "*" {
+		return nil
+	}
+
+	if etag := contentETag(content); ifMatch != etag {
+		return mcperrors.NewConflictError(fmt.Sprintf("content %s has changed (current etag %s, if_match %s)", content.ID, etag, ifMatch))
+	}
+	return nil
+}
+
+// contentLocks hands out one mutex per content ID so that the version check
+// and the write that follows it are not interleaved with another tool call
+// in the same process. The zero value is ready to use.
+type contentLocks struct {
+	mu    sync.Mutex
+	locks map[uuid.UUID]*contentLock
+}
+
+type contentLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock acquires the lock for id and returns the function that releases it
+func (l *contentLocks) lock(id uuid.UUID) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[uuid.UUID]*contentLock)
+	}
+	entry, ok := l.locks[id]
+	if !ok {
+		entry = &contentLock{}
+		l.locks[id] = entry
+	}
+	entry.refs++
+	l.mu.Unlock()
+
+	entry.mu.Lock()
+	return func() {
+		entry.mu.Unlock()
+
+		l.mu.Lock()
+		entry.refs--
+		if entry.refs == 0 {
+			delete(l.locks, id)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -1,9 +1,16 @@
 package mcpserver
 
 import (
+	"time"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/auth"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/facets"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/jobs"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/search"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/semantic"
 	"github.com/tendant/simple-content/pkg/simplecontent"
 	"github.com/tendant/simple-content/pkg/simplecontent/admin"
-	"github.com/tendant/simple-content-mcp/pkg/mcpserver/auth"
 )
 
 // TransportMode defines the MCP transport protocol
@@ -18,6 +25,16 @@ const (
 	TransportHTTP TransportMode = "http"
 )
 
+// ExpiryAction defines what the expiry sweeper does with expired content
+type ExpiryAction string
+
+const (
+	// ExpirySoftDelete marks expired content as deleted, keeping its blobs
+	ExpirySoftDelete ExpiryAction = "soft_delete"
+	// ExpiryPurge also removes the stored objects and their blobs
+	ExpiryPurge ExpiryAction = "purge"
+)
+
 // Config holds server configuration
 type Config struct {
 	// Core dependencies
@@ -35,16 +52,48 @@ type Config struct {
 	BaseURL string        // For SSE mode
 
 	// Behavior settings
-	MaxBatchSize    int // Maximum number of items in batch operations
-	DefaultPageSize int // Default page size for list operations
-	MaxPageSize     int // Maximum page size for list operations
+	MaxBatchSize     int           // Maximum number of items in batch operations
+	DefaultPageSize  int           // Default page size for list operations
+	MaxPageSize      int           // Maximum page size for list operations
+	CursorSecret     []byte        // Key signing pagination cursors (empty means a random key, so cursors don't survive restarts)
+	MaxWaitTimeout   time.Duration // Longest timeout wait_for_content accepts (0 means 5m)
+	WaitPollInterval time.Duration // How often wait_for_content checks the content (0 means 500ms)
 
 	// Feature flags
 	EnableResources bool // Enable MCP resources (Phase 3)
 	EnablePrompts   bool // Enable MCP prompts (Phase 3)
 
 	// List content settings
-	RequireOwnerID bool // Require owner_id for list_content tool
+	RequireOwnerID  bool               // Require owner_id for list_content tool
+	MetadataQuerier metafilter.Querier // Evaluates list_content metadata_filters in the database, and sorts and pages list_content there when it is a metafilter.Lister (default: in memory)
+	FacetAggregator facets.Aggregator  // Computes content_facets in the database (default: content by content through the service)
+
+	// Search settings
+	SearchIndex           search.Index // Index used by search_content (default: in-memory)
+	RebuildIndexOnStartup bool         // Index all existing content when Serve starts (requires AdminService)
+	IndexBodies           bool         // Index the text of text-like content and extracted text for body search (requires a search.BodyIndex)
+
+	// Semantic search settings
+	Embedder    semantic.Embedder // Embeds names, descriptions and text for semantic_search (nil disables it)
+	VectorIndex semantic.Index    // Index used by semantic_search (default: in-memory)
+
+	// Maintenance settings
+	OrphanSweepInterval time.Duration // Interval for removing derived content whose parent is gone (0 disables, requires AdminService)
+	ExpirySweepInterval time.Duration // Interval for removing content past its expires_at (0 disables, requires AdminService)
+	ExpiryAction        ExpiryAction  // soft_delete (default) or purge
+
+	// Processing settings
+	ThumbnailSizes     []int            // Longest-side sizes of thumbnails generated for image uploads (empty disables)
+	ThumbnailMaxPixels int              // Largest image, in pixels, decoded for thumbnails (0 means 40 MP)
+	ThumbnailMaxSize   int              // Largest thumbnail size a derivation job may request (0 means 4096)
+	ExtractText        bool             // Derive a plain text variant from document uploads
+	DerivationPolicy   DerivationPolicy // Derivations get_content_status expects per MIME type
+	Processors         []jobs.Processor // Additional derivation processors (thumbnail and text processors are always registered)
+	JobQueue           jobs.Queue       // Queue for derivation jobs (default: in-memory)
+	JobWorkers         int              // Number of workers running derivation jobs in Serve (0 disables)
+	JobMaxAttempts     int              // Attempts per job before it fails
+	JobAttemptsLimit   int              // Largest max_attempts request_derivation accepts (never less than JobMaxAttempts)
+	JobRetryBackoff    time.Duration    // Delay before the first retry, doubled for each further attempt
 
 	// Authentication settings (Phase 5)
 	AuthEnabled   bool               // Enable authentication
@@ -54,20 +103,28 @@ type Config struct {
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig(service simplecontent.Service) Config {
 	return Config{
-		Service:         service,
-		Name:            "simple-content-mcp",
-		Version:         "0.1.0",
-		Mode:            TransportStdio,
-		Host:            "localhost",
-		Port:            8080,
-		MaxBatchSize:    100,
-		DefaultPageSize: 50,
-		MaxPageSize:     1000,
-		EnableResources: true,  // Phase 3
-		EnablePrompts:   true,  // Phase 3
-		RequireOwnerID:  true,  // Require owner_id for list_content by default
-		AuthEnabled:     false, // Phase 5 - disabled by default
-		Authenticator:   nil,   // Phase 5 - must be set if AuthEnabled
+		Service:               service,
+		Name:                  "simple-content-mcp",
+		Version:               "0.1.0",
+		Mode:                  TransportStdio,
+		Host:                  "localhost",
+		Port:                  8080,
+		MaxBatchSize:          100,
+		DefaultPageSize:       50,
+		MaxPageSize:           1000,
+		MaxWaitTimeout:        defaultMaxWaitTimeout,
+		WaitPollInterval:      defaultWaitPollInterval,
+		EnableResources:       true, // Phase 3
+		EnablePrompts:         true, // Phase 3
+		RequireOwnerID:        true, // Require owner_id for list_content by default
+		RebuildIndexOnStartup: true,
+		AuthEnabled:           false, // Phase 5 - disabled by default
+		Authenticator:         nil,   // Phase 5 - must be set if AuthEnabled
+		ExpiryAction:          ExpirySoftDelete,
+		JobWorkers:            2,
+		JobMaxAttempts:        3,
+		JobAttemptsLimit:      10,
+		JobRetryBackoff:       10 * time.Second,
 	}
 }
 
@@ -101,6 +158,72 @@ func (c *Config) Validate() error {
 		return &ConfigError{Field: "DefaultPageSize", Message: "cannot be greater than MaxPageSize"}
 	}
 
+	if c.MaxWaitTimeout < 0 {
+		return &ConfigError{Field: "MaxWaitTimeout", Message: "cannot be negative"}
+	}
+
+	if c.WaitPollInterval < 0 {
+		return &ConfigError{Field: "WaitPollInterval", Message: "cannot be negative"}
+	}
+
+	if c.IndexBodies && c.SearchIndex != nil {
+		if _, ok := c.SearchIndex.(search.BodyIndex); !ok {
+			return &ConfigError{Field: "IndexBodies", Message: "search index does not support body search"}
+		}
+	}
+
+	if c.VectorIndex != nil && c.Embedder == nil {
+		return &ConfigError{Field: "VectorIndex", Message: "requires an Embedder"}
+	}
+
+	if c.OrphanSweepInterval < 0 {
+		return &ConfigError{Field: "OrphanSweepInterval", Message: "cannot be negative"}
+	}
+
+	if c.ExpirySweepInterval < 0 {
+		return &ConfigError{Field: "ExpirySweepInterval", Message: "cannot be negative"}
+	}
+
+	switch c.ExpiryAction {
+	case "", ExpirySoftDelete, ExpiryPurge:
+	default:
+		return &ConfigError{Field: "ExpiryAction", Message: "must be soft_delete or purge"}
+	}
+
+	if err := c.DerivationPolicy.validate(); err != nil {
+		return &ConfigError{Field: "DerivationPolicy", Message: err.Error()}
+	}
+
+	for _, size := range c.ThumbnailSizes {
+		if size <= 0 {
+			return &ConfigError{Field: "ThumbnailSizes", Message: "sizes must be greater than 0"}
+		}
+	}
+
+	if c.ThumbnailMaxPixels < 0 {
+		return &ConfigError{Field: "ThumbnailMaxPixels", Message: "cannot be negative"}
+	}
+
+	if c.ThumbnailMaxSize < 0 {
+		return &ConfigError{Field: "ThumbnailMaxSize", Message: "cannot be negative"}
+	}
+
+	if c.JobWorkers < 0 {
+		return &ConfigError{Field: "JobWorkers", Message: "cannot be negative"}
+	}
+
+	if c.JobMaxAttempts < 0 {
+		return &ConfigError{Field: "JobMaxAttempts", Message: "cannot be negative"}
+	}
+
+	if c.JobAttemptsLimit < 0 {
+		return &ConfigError{Field: "JobAttemptsLimit", Message: "cannot be negative"}
+	}
+
+	if c.JobRetryBackoff < 0 {
+		return &ConfigError{Field: "JobRetryBackoff", Message: "cannot be negative"}
+	}
+
 	// Validate authentication configuration
 	if c.AuthEnabled && c.Authenticator == nil {
 		return &ConfigError{Field: "Authenticator", Message: "authenticator is required when AuthEnabled is true"}
@@ -0,0 +1,205 @@
+// Package cursor implements opaque, signed pagination cursors.
+//
+// Listings are sorted in an Order: by a key (created_at unless specified),
+// then by created_at in the same direction, then by ID. A cursor holds the
+// Position of the last item of a page and a fingerprint of the listing's
+// filters, signed with HMAC-SHA256 so clients can neither forge positions
+// nor reuse a cursor with other filters.
+package cursor
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalid is returned for cursors that are malformed, forged or were
+// issued for another listing
+var ErrInvalid = errors.New("invalid cursor")
+
+// Sort keys of an Order
+const (
+	ByCreatedAt = "created_at"
+	ByUpdatedAt = "updated_at"
+	ByName      = "name"
+	BySize      = "size"
+	ByRelevance = "relevance"
+)
+
+// Order is the order of a listing. The zero value lists newest first.
+type Order struct {
+	By        string // Sort key (ByCreatedAt when empty)
+	Ascending bool
+}
+
+// Position is the place of an item in a listing. Besides CreatedAt and ID,
+// only the field of the listing's sort key is needed.
+type Position struct {
+	Score     *float64   `json:"s,omitempty"` // Relevance, for ranked listings
+	UpdatedAt *time.Time `json:"u,omitempty"`
+	Name      *string    `json:"n,omitempty"`
+	Size      *int64     `json:"z,omitempty"`
+	CreatedAt time.Time  `json:"c"`
+	ID        uuid.UUID  `json:"i"`
+}
+
+// Before reports whether p comes before q in the order. Ties on the key are
+// broken by created_at in the same direction, then by lower IDs first.
+func (o Order) Before(p, q Position) bool {
+	c := 0
+	switch o.By {
+	case ByRelevance:
+		c = comparePtr(p.Score, q.Score, cmp.Compare[float64])
+	case ByUpdatedAt:
+		c = comparePtr(p.UpdatedAt, q.UpdatedAt, time.Time.Compare)
+	case ByName:
+		c = comparePtr(p.Name, q.Name, strings.Compare)
+	case BySize:
+		c = comparePtr(p.Size, q.Size, cmp.Compare[int64])
+	}
+	if c == 0 {
+		c = p.CreatedAt.Compare(q.CreatedAt)
+	}
+	if !o.Ascending {
+		c = -c
+	}
+	if c == 0 {
+		return p.ID.String() < q.ID.String()
+	}
+	return c < 0
+}
+
+// Key returns p with only the fields the order compares
+func (o Order) Key(p Position) Position {
+	key := Position{CreatedAt: p.CreatedAt, ID: p.ID}
+	switch o.By {
+	case ByRelevance:
+		key.Score = p.Score
+	case ByUpdatedAt:
+		key.UpdatedAt = p.UpdatedAt
+	case ByName:
+		key.Name = p.Name
+	case BySize:
+		key.Size = p.Size
+	}
+	return key
+}
+
+// comparePtr compares two optional values; missing values come first
+func comparePtr[T any](a, b *T, compare func(T, T) int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return compare(*a, *b)
+}
+
+// payload is the signed content of a cursor
+type payload struct {
+	Position
+	Listing string `json:"l"` // Fingerprint of the listing filters
+}
+
+// Signer encodes and verifies cursors
+type Signer struct {
+	key []byte
+}
+
+// NewSigner creates a signer. With an empty key a random one is used, so
+// cursors stay valid only as long as the process runs.
+func NewSigner(key []byte) *Signer {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic("cursor: failed to generate key: " + err.Error())
+		}
+	}
+	return &Signer{key: key}
+}
+
+// Encode returns the cursor of pos in the listing identified by listing
+func (s *Signer) Encode(listing string, pos Position) string {
+	data, _ := json.Marshal(payload{Position: pos, Listing: listing})
+	return base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(s.sign(data))
+}
+
+// Decode verifies a cursor issued for listing and returns its position
+func (s *Signer) Decode(listing, cursor string) (Position, error) {
+	encoded, signature, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return Position{}, ErrInvalid
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Position{}, ErrInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(data)) {
+		return Position{}, ErrInvalid
+	}
+
+	var p payload
+	if err := json.Unmarshal(data, &p); err != nil || p.Listing != listing {
+		return Position{}, ErrInvalid
+	}
+	return p.Position, nil
+}
+
+// sign computes the signature of data
+func (s *Signer) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// Listing fingerprints a listing: the tool name and its arguments except
+// the pagination ones (cursor, limit, offset)
+func Listing(tool string, params map[string]interface{}) string {
+	filters := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if k != "cursor" && k != "limit" && k != "offset" {
+			filters[k] = v
+		}
+	}
+	// encoding/json sorts map keys, so equal filters encode alike
+	data, _ := json.Marshal(filters)
+	sum := sha256.Sum256(append([]byte(tool+"\x00"), data...))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// Page returns the items of a listing sorted in order that follow after
+// (all items when after is nil), at most limit of them (all when limit <= 0),
+// and whether more items follow the page
+func Page[T any](items []T, position func(T) Position, order Order, after *Position, limit int) ([]T, bool) {
+	start := 0
+	if after != nil {
+		for start < len(items) && !order.Before(*after, position(items[start])) {
+			start++
+		}
+	}
+	items = items[start:]
+	if limit > 0 && len(items) > limit {
+		return items[:limit], true
+	}
+	return items, false
+}
+
+// Sort sorts items in order
+func Sort[T any](items []T, position func(T) Position, order Order) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return order.Before(position(items[i]), position(items[j]))
+	})
+}
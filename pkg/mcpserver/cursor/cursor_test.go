@@ -0,0 +1,117 @@
+package cursor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	listing := Listing("list_content", map[string]interface{}{"owner_id": "o", "limit": 10})
+	score := 1.5
+	pos := Position{Score: &score, CreatedAt: time.Date(2025, 3, 14, 10, 0, 0, 123, time.UTC), ID: uuid.New()}
+
+	token := signer.Encode(listing, pos)
+	got, err := signer.Decode(listing, token)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if got.ID != pos.ID || !got.CreatedAt.Equal(pos.CreatedAt) || got.Score == nil || *got.Score != score {
+		t.Errorf("Decoded %+v, want %+v", got, pos)
+	}
+
+	// Pagination arguments don't change the listing
+	if Listing("list_content", map[string]interface{}{"owner_id": "o", "cursor": token, "limit": 5}) != listing {
+		t.Error("Expected the same listing fingerprint")
+	}
+
+	payload, _, _ := strings.Cut(token, ".")
+	for name, bad := range map[string]string{
+		"other filters": "",
+		"other key":     "",
+		"tampered":      payload + "x." + strings.SplitN(token, ".", 2)[1],
+		"unsigned":      payload,
+		"garbage":       "not a cursor",
+	} {
+		l, s := listing, signer
+		switch name {
+		case "other filters":
+			l, bad = Listing("list_content", map[string]interface{}{"owner_id": "p"}), token
+		case "other key":
+			s, bad = NewSigner(nil), token
+		}
+		if _, err := s.Decode(l, bad); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	now := time.Now()
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	items := []Position{
+		{CreatedAt: now.Add(-time.Hour), ID: ids[0]},
+		{CreatedAt: now, ID: ids[1]},
+		{CreatedAt: now, ID: ids[2]},
+		{CreatedAt: now.Add(-2 * time.Hour), ID: ids[3]},
+	}
+	position := func(p Position) Position { return p }
+	Sort(items, position, Order{})
+	if !items[0].CreatedAt.Equal(now) || items[0].ID.String() > items[1].ID.String() || items[3].ID != ids[3] {
+		t.Fatalf("Unexpected order: %+v", items)
+	}
+
+	page, more := Page(items, position, Order{}, nil, 2)
+	if len(page) != 2 || !more {
+		t.Fatalf("Unexpected first page: %+v, %v", page, more)
+	}
+	page, more = Page(items, position, Order{}, &page[1], 2)
+	if len(page) != 2 || more || page[0].ID != ids[0] {
+		t.Errorf("Unexpected second page: %+v, %v", page, more)
+	}
+
+	// Items added before the cursor don't shift later pages
+	items = append([]Position{{CreatedAt: now.Add(time.Minute), ID: uuid.New()}}, items...)
+	page, _ = Page(items, position, Order{}, &items[2], 10)
+	if len(page) != 2 || page[0].ID != ids[0] {
+		t.Errorf("Unexpected page after an insert: %+v", page)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	now := time.Now()
+	name := func(s string) *string { return &s }
+	size := func(n int64) *int64 { return &n }
+	a := Position{Name: name("alpha"), Size: size(300), CreatedAt: now, ID: uuid.New()}
+	b := Position{Name: name("beta"), Size: size(100), CreatedAt: now.Add(-time.Hour), ID: uuid.New()}
+	c := Position{Name: name("beta"), Size: size(100), CreatedAt: now.Add(time.Hour), ID: uuid.New()}
+
+	tests := []struct {
+		order Order
+		want  []Position
+	}{
+		{Order{}, []Position{c, a, b}},
+		{Order{By: ByCreatedAt, Ascending: true}, []Position{b, a, c}},
+		{Order{By: ByName, Ascending: true}, []Position{a, b, c}},
+		{Order{By: ByName}, []Position{c, b, a}},
+		{Order{By: BySize}, []Position{a, c, b}},
+		{Order{By: BySize, Ascending: true}, []Position{b, c, a}},
+	}
+	for _, test := range tests {
+		items := []Position{a, b, c}
+		Sort(items, func(p Position) Position { return p }, test.order)
+		for i := range items {
+			if items[i].ID != test.want[i].ID {
+				t.Errorf("%+v: item %d is %v, want %v", test.order, i, *items[i].Name, *test.want[i].Name)
+			}
+		}
+	}
+
+	if key := (Order{By: BySize}).Key(a); key.Size == nil || key.Name != nil || key.ID != a.ID {
+		t.Errorf("Unexpected key: %+v", key)
+	}
+}
@@ -21,6 +21,10 @@ var (
 	ErrUnauthorized = errors.New("unauthorized")
 	// ErrForbidden is returned for forbidden access
 	ErrForbidden = errors.New("forbidden")
+	// ErrConflict is returned when a resource changed since the caller last read it
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidTransition is returned for status changes the lifecycle does not allow
+	ErrInvalidTransition = errors.New("invalid status transition")
 )
 
 // NewValidationError creates an error for validation failures
@@ -53,6 +57,19 @@ func NewForbiddenError(message string) error {
 	return fmt.Errorf("%s: %w", message, ErrForbidden)
 }
 
+// NewConflictError creates an error for concurrent modification conflicts
+func NewConflictError(message string) error {
+	return fmt.Errorf("%s: %w", message, ErrConflict)
+}
+
+// NewInvalidTransitionError creates an error for a disallowed status transition
+func NewInvalidTransitionError(from, to string, allowed []string) error {
+	if len(allowed) == 0 {
+		return fmt.Errorf("cannot transition from %s to %s: %w (%s is final)", from, to, ErrInvalidTransition, from)
+	}
+	return fmt.Errorf("cannot transition from %s to %s: %w (allowed from %s: %v)", from, to, ErrInvalidTransition, from, allowed)
+}
+
 // MapError maps simple-content errors to meaningful MCP errors
 // This function attempts to classify errors based on their string content
 // For more precise mapping, we'd need typed errors from simple-content
@@ -66,7 +83,7 @@ func MapError(err error) error {
 	// Try to classify based on error message
 	// These are heuristics - ideally simple-content would export typed errors
 	switch {
-	case contains(errStr, "not found"):
+	case contains(errStr, "not found"), contains(errStr, "no rows"):
 		return fmt.Errorf("%v: %w", err, ErrNotFound)
 	case contains(errStr, "validation"), contains(errStr, "invalid"):
 		return fmt.Errorf("%v: %w", err, ErrValidation)
@@ -82,6 +99,11 @@ func MapError(err error) error {
 	}
 }
 
+// IsNotFound reports whether err indicates a missing resource
+func IsNotFound(err error) bool {
+	return errors.Is(MapError(err), ErrNotFound)
+}
+
 // contains checks if a string contains a substring (case-insensitive)
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr ||
@@ -0,0 +1,240 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tendant/simple-content/pkg/simplecontent/admin"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/patch"
+)
+
+// expiresAtKey is the metadata key holding the RFC 3339 expiry time of a content
+const expiresAtKey = reservedMetadataPrefix + "expires_at"
+
+// objectDeleter is implemented by services that can remove object records
+// (simplecontent's default service implements StorageService)
+type objectDeleter interface {
+	DeleteObject(ctx context.Context, id uuid.UUID) error
+}
+
+// parseExpiry reads the expires_at / ttl_seconds arguments. It reports false
+// when neither was given; a nil time with true means the expiry is cleared
+// (expires_at: null).
+func parseExpiry(params map[string]interface{}, now time.Time) (*time.Time, bool, error) {
+	rawExpiresAt, hasExpiresAt := params["expires_at"]
+	rawTTL, hasTTL := params["ttl_seconds"]
+	if hasExpiresAt && hasTTL {
+		return nil, false, mcperrors.NewValidationError("expires_at", fmt.Errorf("cannot be combined with ttl_seconds"))
+	}
+
+	if hasTTL {
+		ttl, ok := rawTTL.(float64)
+		if !ok || ttl <= 0 || ttl != float64(int64(ttl)) {
+			return nil, false, mcperrors.NewValidationError("ttl_seconds", fmt.Errorf("must be a positive integer"))
+		}
+		expiresAt := now.Add(time.Duration(ttl) * time.Second).UTC()
+		return &expiresAt, true, nil
+	}
+
+	if !hasExpiresAt {
+		return nil, false, nil
+	}
+	if rawExpiresAt == nil {
+		return nil, true, nil
+	}
+
+	value, ok := rawExpiresAt.(string)
+	if !ok {
+		return nil, false, mcperrors.NewValidationError("expires_at", fmt.Errorf("must be an RFC 3339 timestamp"))
+	}
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, false, mcperrors.NewValidationError("expires_at", err)
+	}
+	if !expiresAt.After(now) {
+		return nil, false, mcperrors.NewValidationError("expires_at", fmt.Errorf("must be in the future"))
+	}
+	expiresAt = expiresAt.UTC()
+	return &expiresAt, true, nil
+}
+
+// withExpiry returns a copy of metadata with the expiry set, or removed when expiresAt is nil
+func withExpiry(metadata map[string]interface{}, expiresAt *time.Time) map[string]interface{} {
+	result := map[string]interface{}{}
+	if metadata != nil {
+		result = patch.Clone(metadata).(map[string]interface{})
+	}
+	if expiresAt == nil {
+		delete(result, expiresAtKey)
+	} else {
+		result[expiresAtKey] = expiresAt.Format(time.RFC3339)
+	}
+	return result
+}
+
+// contentExpiry returns the expiry stored in metadata, if any
+func contentExpiry(metadata map[string]interface{}) (time.Time, bool) {
+	value, ok := metadata[expiresAtKey].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
+
+// checkExpired returns a not-found error for content whose expiry has
+// passed, so it cannot be read while it waits for the expiry sweeper
+func (s *Server) checkExpired(ctx context.Context, contentID uuid.UUID) error {
+	metadata, err := s.service.GetContentMetadata(ctx, contentID)
+	if err != nil {
+		return nil
+	}
+	if expiresAt, ok := contentExpiry(metadata.Metadata); ok && !expiresAt.After(time.Now()) {
+		return mcperrors.NewNotFoundError("content", contentID.String())
+	}
+	return nil
+}
+
+// SweepExpired removes content whose expiry has passed, together with its
+// derived content. Depending on the configured ExpiryAction the content is
+// soft deleted or purged along with its stored blobs. It requires an
+// AdminService to enumerate content and returns the number of contents removed.
+func (s *Server) SweepExpired(ctx context.Context) (int, error) {
+	if s.adminService == nil {
+		return 0, fmt.Errorf("expiry sweep requires an admin service")
+	}
+
+	// Collect expired content first so deletions don't shift the pages being scanned
+	now := time.Now()
+	var expired []uuid.UUID
+	limit := sweepPageSize
+	for offset := 0; ; offset += limit {
+		pageOffset := offset
+		resp, err := s.adminService.ListAllContents(ctx, admin.ListContentsRequest{
+			Filters: admin.ContentFilters{
+				Limit:  &limit,
+				Offset: &pageOffset,
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, content := range resp.Contents {
+			if content.DeletedAt != nil {
+				continue
+			}
+			metadata, err := s.service.GetContentMetadata(ctx, content.ID)
+			if err != nil {
+				continue
+			}
+			if expiresAt, ok := contentExpiry(metadata.Metadata); ok && !expiresAt.After(now) {
+				expired = append(expired, content.ID)
+			}
+		}
+
+		if len(resp.Contents) < limit {
+			break
+		}
+	}
+
+	removed := 0
+	for _, id := range expired {
+		deleted, err := s.expireContent(ctx, id, now)
+		removed += deleted
+		if err != nil && !mcperrors.IsNotFound(err) {
+			// Keep going: one stuck item (e.g. still processing) must not block the rest
+			log.Printf("Failed to expire content %s: %v", id, err)
+		}
+	}
+
+	return removed, nil
+}
+
+// expireContent removes one expired content tree and returns the number of
+// contents deleted. The expiry is re-checked under the content lock in case
+// it was extended after the scan.
+func (s *Server) expireContent(ctx context.Context, contentID uuid.UUID, now time.Time) (int, error) {
+	unlock := s.locks.lock(contentID)
+	defer unlock()
+
+	metadata, err := s.service.GetContentMetadata(ctx, contentID)
+	if err != nil {
+		return 0, err
+	}
+	if expiresAt, ok := contentExpiry(metadata.Metadata); !ok || expiresAt.After(now) {
+		return 0, nil
+	}
+
+	// Delete the records before purging their blobs: a failed delete must not
+	// leave live content without data
+	deleted, err := s.deleteContentTree(ctx, contentID, true)
+	if s.config.ExpiryAction == ExpiryPurge {
+		for _, id := range deleted {
+			if purgeErr := s.purgeObjects(ctx, id); purgeErr != nil && err == nil {
+				err = purgeErr
+			}
+		}
+	}
+	return len(deleted), err
+}
+
+// purgeObjects deletes the blobs of a content and, when the service supports
+// it, their object records
+func (s *Server) purgeObjects(ctx context.Context, contentID uuid.UUID) error {
+	deleter, _ := s.service.(objectDeleter)
+
+	objects, err := s.service.GetObjectsByContentID(ctx, contentID)
+	if err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		if object.DeletedAt != nil {
+			continue
+		}
+		backend, err := s.service.GetBackend(object.StorageBackendName)
+		if err != nil {
+			return err
+		}
+		if err := backend.Delete(ctx, object.ObjectKey); err != nil {
+			return fmt.Errorf("failed to delete blob %s: %w", object.ObjectKey, err)
+		}
+		if deleter != nil {
+			if err := deleter.DeleteObject(ctx, object.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runExpirySweeper periodically removes expired content until ctx is cancelled
+func (s *Server) runExpirySweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := s.SweepExpired(ctx)
+			if err != nil {
+				log.Printf("Expiry sweep failed: %v", err)
+				continue
+			}
+			if removed > 0 {
+				log.Printf("Expiry sweep removed %d content item(s)", removed)
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Package extract extracts plain UTF-8 text from documents in pure Go.
+//
+// Plain text, Markdown, HTML (tags stripped), JSON, CSV, DOCX and ODT are
+// supported. Text is decoded from its source charset (byte order mark,
+// declared charset, UTF-8, or Windows-1252 as a last resort) and line endings
+// are normalized. The result also carries a word count and a best-effort
+// language guess.
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// ErrUnsupported is returned for MIME types text cannot be extracted from
+var ErrUnsupported = errors.New("unsupported document type")
+
+// formats maps supported MIME types to their format name
+var formats = map[string]string{
+	"text/plain":            "text",
+	"text/markdown":         "markdown",
+	"text/x-markdown":       "markdown",
+	"text/html":             "html",
+	"application/xhtml+xml": "html",
+	"application/json":      "json",
+	"text/csv":              "csv",
+	"application/csv":       "csv",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": "docx",
+	"application/vnd.oasis.opendocument.text":                                 "odt",
+}
+
+// Result is the text extracted from a document
+type Result struct {
+	Text      string
+	Format    string // text, markdown, html, json, csv, docx or odt
+	Charset   string // Charset the source was decoded from
+	Language  string // ISO 639-1 code, empty when unknown
+	WordCount int
+	CharCount int
+}
+
+// Supported reports whether text can be extracted from documents of the given MIME type
+func Supported(mimeType string) bool {
+	_, ok := formats[mediaType(mimeType)]
+	return ok
+}
+
+// Text extracts the text of a document of the given MIME type
+func Text(data []byte, mimeType string) (*Result, error) {
+	format, ok := formats[mediaType(mimeType)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, mimeType)
+	}
+
+	result := &Result{Format: format, Charset: "utf-8"}
+
+	var text string
+	switch format {
+	case "docx":
+		extracted, err := docxText(data)
+		if err != nil {
+			return nil, err
+		}
+		text = extracted
+	case "odt":
+		extracted, err := odtText(data)
+		if err != nil {
+			return nil, err
+		}
+		text = extracted
+	default:
+		declared := declaredCharset(mimeType)
+		if declared == "" && format == "html" {
+			declared = htmlCharset(data)
+		}
+		decoded, charset, err := toUTF8(data, declared)
+		if err != nil {
+			return nil, err
+		}
+		result.Charset = charset
+
+		text = decoded
+		switch format {
+		case "html":
+			text = htmlText(text)
+		case "json":
+			text = jsonText(text)
+		}
+	}
+
+	result.Text = normalize(text)
+	result.WordCount = countWords(result.Text)
+	result.CharCount = utf8.RuneCountInString(result.Text)
+	result.Language = DetectLanguage(result.Text)
+	return result, nil
+}
+
+// mediaType returns the lowercased MIME type without parameters
+func mediaType(mimeType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+}
+
+// declaredCharset returns the charset parameter of a MIME type, if any
+func declaredCharset(mimeType string) string {
+	_, params, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(params["charset"])
+}
+
+// htmlCharsetPattern finds <meta charset="..."> and the charset in
+// <meta http-equiv="Content-Type" content="text/html; charset=...">
+var htmlCharsetPattern = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_:.-]+)`)
+
+// htmlCharset returns the charset declared in the head of an HTML document
+func htmlCharset(data []byte) string {
+	head := data[:min(len(data), 1024)]
+	if m := htmlCharsetPattern.FindSubmatch(head); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	return ""
+}
+
+// toUTF8 decodes data to UTF-8. A byte order mark wins over the declared
+// charset; without either, valid UTF-8 is kept and anything else is read as
+// Windows-1252. It returns the charset that was used.
+func toUTF8(data []byte, declared string) (string, string, error) {
+	var enc encoding.Encoding
+	charset := declared
+
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:]), "utf-8", nil
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		enc, charset = xunicode.UTF16(xunicode.LittleEndian, xunicode.ExpectBOM), "utf-16le"
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		enc, charset = xunicode.UTF16(xunicode.BigEndian, xunicode.ExpectBOM), "utf-16be"
+	case declared != "" && declared != "utf-8" && declared != "utf8":
+		var err error
+		if enc, err = htmlindex.Get(declared); err != nil {
+			enc = nil // Unknown charset names fall through to detection
+		}
+	}
+
+	if enc == nil {
+		if utf8.Valid(data) {
+			return string(data), "utf-8", nil
+		}
+		enc, charset = charmap.Windows1252, "windows-1252"
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode %s text: %w", charset, err)
+	}
+	return string(decoded), charset, nil
+}
+
+// jsonText pretty-prints JSON so that nested values end up on their own
+// lines; invalid JSON is returned unchanged
+func jsonText(text string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(text), "", "  "); err != nil {
+		return text
+	}
+	return buf.String()
+}
+
+// blankLines matches runs of more than one empty line
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// normalize converts line endings to \n, strips trailing whitespace from
+// lines and collapses runs of blank lines
+func normalize(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.ReplaceAll(text, "\u00a0", " ") // No-break spaces
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	text = strings.Join(lines, "\n")
+
+	return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+}
+
+// countWords counts runs of letters and digits. Ideographic and kana
+// characters count as one word each since those scripts don't use spaces.
+func countWords(text string) int {
+	count := 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			count++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			if !inWord {
+				count++
+				inWord = true
+			}
+		case inWord && (r == '\'' || r == '’'):
+			// Apostrophes inside words ("don't") don't split them
+		default:
+			inWord = false
+		}
+	}
+	return count
+}
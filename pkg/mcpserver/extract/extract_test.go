@@ -0,0 +1,145 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func buildArchive(t *testing.T, name, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create(name)
+	if err != nil {
+		t.Fatalf("Failed to create archive entry: %v", err)
+	}
+	if _, err := f.Write([]byte(content)); err != nil {
+		t.Fatalf("Failed to write archive entry: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestHTML(t *testing.T) {
+	src := `<!DOCTYPE html>
+<html><head><title>Quarterly  Report</title><style>p { color: red }</style></head>
+<body><script>alert("x")</script>
+<h1>Results</h1><p>Revenue &amp; profit<br>grew.</p>
+<!-- hidden -->
+<table><tr><td>Q1</td><td>10</td></tr></table>
+</body></html>`
+
+	result, err := Text([]byte(src), "text/html")
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+
+	expected := "Quarterly Report\n\nResults\nRevenue & profit\ngrew.\nQ1\t10"
+	if result.Text != expected {
+		t.Errorf("Unexpected text:\n%q\nexpected:\n%q", result.Text, expected)
+	}
+	if result.Format != "html" || result.WordCount != 8 {
+		t.Errorf("Unexpected result: format %s, %d words", result.Format, result.WordCount)
+	}
+}
+
+func TestDOCX(t *testing.T) {
+	document := `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Hello</w:t></w:r><w:r><w:t xml:space="preserve"> world</w:t></w:r></w:p>
+<w:p><w:r><w:t>Name</w:t><w:tab/><w:t>Value</w:t></w:r></w:p>
+</w:body></w:document>`
+
+	result, err := Text(buildArchive(t, "word/document.xml", document),
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if result.Text != "Hello world\nName\tValue" {
+		t.Errorf("Unexpected text: %q", result.Text)
+	}
+
+	// Archives without the document part are rejected
+	if _, err := Text(buildArchive(t, "other.xml", "<x/>"),
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document"); err == nil {
+		t.Error("Expected an error for an archive without word/document.xml")
+	}
+}
+
+func TestODT(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:text>
+<text:h>Title</text:h>
+<text:p>One<text:s text:c="2"/>two<text:line-break/>three</text:p>
+</office:text></office:body></office:document-content>`
+
+	result, err := Text(buildArchive(t, "content.xml", content), "application/vnd.oasis.opendocument.text")
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if result.Text != "Title\nOne  two\nthree" {
+		t.Errorf("Unexpected text: %q", result.Text)
+	}
+}
+
+func TestCharsets(t *testing.T) {
+	// Invalid UTF-8 falls back to Windows-1252
+	result, err := Text([]byte("caf\xe9\r\nna\xefve"), "text/plain")
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if result.Text != "café\nnaïve" || result.Charset != "windows-1252" {
+		t.Errorf("Unexpected result: %q (%s)", result.Text, result.Charset)
+	}
+
+	// A byte order mark wins over the declared charset
+	utf16 := []byte{0xFF, 0xFE, 'h', 0, 'i', 0}
+	result, err = Text(utf16, "text/plain; charset=iso-8859-1")
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if result.Text != "hi" || result.Charset != "utf-16le" {
+		t.Errorf("Unexpected result: %q (%s)", result.Text, result.Charset)
+	}
+
+	// Declared charsets are honoured
+	result, err = Text([]byte("\xc0 bient\xf4t"), "text/csv; charset=ISO-8859-1")
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if result.Text != "À bientôt" || result.Format != "csv" {
+		t.Errorf("Unexpected result: %q (%s)", result.Text, result.Format)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	if Supported("image/png") || !Supported("text/markdown; charset=utf-8") {
+		t.Error("Unexpected Supported result")
+	}
+	if _, err := Text([]byte{0}, "application/pdf"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]string{
+		"The report shows that the revenue of the company is growing and it is on track for the year.": "en",
+		"Der Bericht zeigt, dass die Umsätze des Unternehmens steigen und das ist nicht schlecht.":     "de",
+		"Le rapport montre que les ventes de la société sont en hausse pour la première fois.":         "fr",
+		"Это отчёт о продажах компании за первый квартал.":                                             "ru",
+		"これは会社の売上についての報告です。":                                                                           "ja",
+		"1234 5678":                  "",
+		"Lorem ipsum dolor sit amet": "",
+	}
+	for text, expected := range tests {
+		if language := DetectLanguage(text); language != expected {
+			t.Errorf("DetectLanguage(%q) = %q, expected %q", text, language, expected)
+		}
+	}
+}
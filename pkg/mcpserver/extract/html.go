@@ -0,0 +1,178 @@
+package extract
+
+import (
+	"html"
+	"strings"
+)
+
+// skippedElements have content that is not part of the readable text
+var skippedElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"head":     true,
+	"title":    true, // Written first by htmlText
+}
+
+// blockElements start a new line
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hr": true, "li": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true, "table": true,
+	"tr": true, "ul": true,
+}
+
+// htmlText strips the tags of an HTML document. Script, style and head
+// contents are dropped, entities are decoded, whitespace is collapsed outside
+// <pre>, block elements end up on their own lines and table cells are
+// separated by tabs. The document title is kept as the first line.
+func htmlText(src string) string {
+	var b strings.Builder
+	if title := htmlTitle(src); title != "" {
+		b.WriteString(title)
+		b.WriteString("\n\n")
+	}
+
+	inPre := false
+	for len(src) > 0 {
+		lt := strings.IndexByte(src, '<')
+		if lt < 0 {
+			writeHTMLText(&b, src, inPre)
+			break
+		}
+		writeHTMLText(&b, src[:lt], inPre)
+		src = src[lt:]
+
+		// Comments, doctypes and processing instructions
+		if strings.HasPrefix(src, "<!--") {
+			end := strings.Index(src, "-->")
+			if end < 0 {
+				break
+			}
+			src = src[end+3:]
+			continue
+		}
+
+		gt := strings.IndexByte(src, '>')
+		if gt < 0 {
+			break
+		}
+		tag := src[1:gt]
+		src = src[gt+1:]
+
+		name, closing := tagName(tag)
+		switch {
+		case name == "":
+			// Not an element (e.g. <!DOCTYPE>)
+		case skippedElements[name] && !closing && !strings.HasSuffix(tag, "/"):
+			src = skipElement(src, name)
+		case name == "pre":
+			inPre = !closing
+			newLine(&b)
+		case blockElements[name]:
+			newLine(&b)
+		case (name == "td" || name == "th") && !closing && !atLineStart(&b):
+			b.WriteByte('\t')
+		}
+	}
+	return b.String()
+}
+
+// tagName returns the lowercased element name of the text between < and >
+// and whether it is a closing tag
+func tagName(tag string) (string, bool) {
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimPrefix(tag, "/")
+
+	end := strings.IndexAny(tag, " \t\r\n/")
+	if end < 0 {
+		end = len(tag)
+	}
+	name := strings.ToLower(tag[:end])
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		return "", false
+	}
+	return name, closing
+}
+
+// skipElement returns src after the closing tag of the named element
+func skipElement(src, name string) string {
+	end := strings.Index(strings.ToLower(src), "</"+name)
+	if end < 0 {
+		return ""
+	}
+	src = src[end:]
+	if gt := strings.IndexByte(src, '>'); gt >= 0 {
+		return src[gt+1:]
+	}
+	return ""
+}
+
+// htmlTitle returns the text of the <title> element, if any
+func htmlTitle(src string) string {
+	lower := strings.ToLower(src)
+	start := strings.Index(lower, "<title")
+	if start < 0 {
+		return ""
+	}
+	gt := strings.IndexByte(lower[start:], '>')
+	if gt < 0 {
+		return ""
+	}
+	start += gt + 1
+	end := strings.Index(lower[start:], "</title")
+	if end < 0 {
+		return ""
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(src[start:start+end])), " ")
+}
+
+// writeHTMLText writes decoded character data, collapsing whitespace unless
+// it is preformatted
+func writeHTMLText(b *strings.Builder, text string, preformatted bool) {
+	text = html.UnescapeString(text)
+	if preformatted {
+		b.WriteString(text)
+		return
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		if text != "" && !atLineStart(b) {
+			b.WriteByte(' ')
+		}
+		return
+	}
+	if isSpace(text[0]) && !atLineStart(b) {
+		b.WriteByte(' ')
+	}
+	b.WriteString(strings.Join(fields, " "))
+	if isSpace(text[len(text)-1]) {
+		b.WriteByte(' ')
+	}
+}
+
+// atLineStart reports whether b is empty or ends with a line or cell break
+// (or a space already)
+func atLineStart(b *strings.Builder) bool {
+	if b.Len() == 0 {
+		return true
+	}
+	last := b.String()[b.Len()-1]
+	return last == '\n' || last == '\t' || last == ' '
+}
+
+// newLine ends the current line unless b is already at the start of one
+func newLine(b *strings.Builder) {
+	if b.Len() > 0 && b.String()[b.Len()-1] != '\n' {
+		b.WriteByte('\n')
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
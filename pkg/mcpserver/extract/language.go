@@ -0,0 +1,119 @@
+package extract
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languageSampleRunes bounds how much text DetectLanguage looks at
+const languageSampleRunes = 20000
+
+// scriptLanguages maps writing systems used by essentially one language to
+// its ISO 639-1 code. Han is resolved separately (Chinese or Japanese).
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// stopwords are frequent function words of languages written in Latin script
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "with", "for", "was", "on", "are", "this", "be", "as", "have", "not", "by", "you"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "sich", "des", "ein", "eine", "auf", "für", "dem", "den", "auch", "zu", "von", "ich", "wir"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "que", "dans", "pour", "pas", "qui", "sur", "du", "au", "avec", "ce", "sont", "nous", "vous"},
+	"es": {"el", "la", "los", "las", "y", "que", "es", "en", "del", "por", "una", "con", "para", "no", "se", "como", "más", "pero", "su", "lo"},
+	"it": {"il", "di", "che", "è", "la", "e", "per", "non", "una", "sono", "del", "della", "con", "gli", "anche", "come", "questo", "nel", "si", "ha"},
+	"pt": {"o", "a", "os", "de", "que", "não", "uma", "com", "para", "é", "do", "da", "em", "se", "mais", "por", "como", "são", "ao", "na"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "zijn", "met", "voor", "ook", "maar", "als", "er", "bij", "ik", "wij"},
+}
+
+// stopwordIndex maps each stopword to the languages it belongs to
+var stopwordIndex = func() map[string][]string {
+	index := make(map[string][]string)
+	for language, words := range stopwords {
+		for _, word := range words {
+			index[word] = append(index[word], language)
+		}
+	}
+	return index
+}()
+
+// DetectLanguage guesses the ISO 639-1 code of the language of text. Scripts
+// that identify a language decide directly; Latin text is scored by its
+// stopwords. It returns an empty string when the text gives no clear signal.
+func DetectLanguage(text string) string {
+	if len(text) > languageSampleRunes*4 {
+		text = text[:languageSampleRunes*4]
+	}
+
+	// Count letters per script
+	letters, han, kana := 0, 0, 0
+	scripts := make([]int, len(scriptLanguages))
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		default:
+			for i, s := range scriptLanguages {
+				if unicode.Is(s.script, r) {
+					scripts[i]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	if (han+kana)*2 > letters {
+		if kana*10 > han+kana {
+			return "ja"
+		}
+		return "zh"
+	}
+	for i, count := range scripts {
+		if count*2 > letters {
+			return scriptLanguages[i].language
+		}
+	}
+
+	// Score Latin text by stopword hits
+	scores := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for _, language := range stopwordIndex[word] {
+			scores[language]++
+		}
+	}
+
+	best, bestScore, runnerUp := "", 0, 0
+	for language, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, runnerUp = language, score, bestScore
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+
+	// Require a few hits and a clear lead over the next language
+	if bestScore < 3 || bestScore*4 < runnerUp*5 {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,149 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxXMLSize bounds the decompressed size of the document XML read from a
+// DOCX or ODT archive
+const maxXMLSize = 64 << 20
+
+// docxText extracts the paragraphs of word/document.xml from a DOCX archive
+func docxText(data []byte) (string, error) {
+	body, err := readZipEntry(data, "word/document.xml")
+	if err != nil {
+		return "", fmt.Errorf("invalid DOCX document: %w", err)
+	}
+
+	var b strings.Builder
+	inText := false
+	err = walkXML(body, func(token xml.Token) {
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid DOCX document: %w", err)
+	}
+	return b.String(), nil
+}
+
+// odtText extracts the paragraphs and headings of content.xml from an ODT archive
+func odtText(data []byte) (string, error) {
+	body, err := readZipEntry(data, "content.xml")
+	if err != nil {
+		return "", fmt.Errorf("invalid ODT document: %w", err)
+	}
+
+	var b strings.Builder
+	depth := 0 // Nesting of text:p / text:h elements
+	err = walkXML(body, func(token xml.Token) {
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p", "h":
+				depth++
+			case "tab":
+				b.WriteByte('\t')
+			case "line-break":
+				b.WriteByte('\n')
+			case "s":
+				// <text:s text:c="3"/> stands for repeated spaces
+				count := 1
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "c" {
+						if c, err := strconv.Atoi(attr.Value); err == nil && c > 0 {
+							count = min(c, 1000)
+						}
+					}
+				}
+				b.WriteString(strings.Repeat(" ", count))
+			}
+		case xml.EndElement:
+			if t.Name.Local == "p" || t.Name.Local == "h" {
+				depth--
+				if depth == 0 {
+					b.WriteByte('\n')
+				}
+			}
+		case xml.CharData:
+			if depth > 0 {
+				b.Write(t)
+			}
+		}
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid ODT document: %w", err)
+	}
+	return b.String(), nil
+}
+
+// readZipEntry returns the decompressed content of the named archive entry
+func readZipEntry(data []byte, name string) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		content, err := io.ReadAll(io.LimitReader(rc, maxXMLSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(content) > maxXMLSize {
+			return nil, fmt.Errorf("%s is larger than %d bytes", name, maxXMLSize)
+		}
+		return content, nil
+	}
+	return nil, fmt.Errorf("%s not found", name)
+}
+
+// walkXML calls fn for every token of an XML document
+func walkXML(data []byte, fn func(xml.Token)) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fn(token)
+	}
+}
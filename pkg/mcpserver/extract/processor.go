@@ -0,0 +1,66 @@
+package extract
+
+import (
+	"context"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/jobs"
+)
+
+// DerivationType is the derivation type of extracted text
+const DerivationType = "text"
+
+// Variant is the variant name of extracted text
+const Variant = "text"
+
+// Processor derives a plain text variant from documents
+type Processor struct{}
+
+// NewProcessor creates a text extraction processor
+func NewProcessor() *Processor {
+	return &Processor{}
+}
+
+// Name implements jobs.Processor
+func (p *Processor) Name() string {
+	return "text"
+}
+
+// Supports implements jobs.Processor
+func (p *Processor) Supports(mimeType, derivationType string) bool {
+	return derivationType == DerivationType && Supported(mimeType)
+}
+
+// Variants implements jobs.Processor
+func (p *Processor) Variants(job *jobs.Job) ([]string, error) {
+	return []string{Variant}, nil
+}
+
+// Process implements jobs.Processor
+func (p *Processor) Process(ctx context.Context, input *jobs.Input) ([]*jobs.Output, error) {
+	result, err := Text(input.Data, input.MimeType)
+	if err != nil {
+		// Malformed documents and undecodable text stay that way on retry
+		return nil, jobs.Permanent(err)
+	}
+
+	return []*jobs.Output{{
+		Variant:  Variant,
+		MimeType: "text/plain",
+		Data:     []byte(result.Text),
+		Metadata: result.Metadata(),
+	}}, nil
+}
+
+// Metadata returns the extraction details recorded on derived text content
+func (r *Result) Metadata() map[string]interface{} {
+	metadata := map[string]interface{}{
+		"format":     r.Format,
+		"charset":    r.Charset,
+		"word_count": r.WordCount,
+		"char_count": r.CharCount,
+	}
+	if r.Language != "" {
+		metadata["language"] = r.Language
+	}
+	return metadata
+}
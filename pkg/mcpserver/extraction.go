@@ -0,0 +1,109 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/extract"
+)
+
+// downloadExtractedText returns the plain text of a document for
+// download_content with format=extracted_text. The processed text variant
+// derived by an extraction job is used when there is one; otherwise the text
+// is extracted from the content itself.
+func (s *Server) downloadExtractedText(ctx context.Context, contentID uuid.UUID) (*mcp.CallToolResult, error) {
+	content, err := s.service.GetContent(ctx, contentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	if err := s.authorizeAccess(ctx, content.OwnerID, content.TenantID); err != nil {
+		return nil, err
+	}
+
+	source := "extracted"
+	var derivedID uuid.UUID
+	data, mimeType := []byte(nil), content.DocumentType
+
+	textID, err := s.extractedTextID(ctx, contentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	if textID != uuid.Nil {
+		if data, err = s.readContent(ctx, textID); err != nil {
+			return nil, err
+		}
+		source, derivedID, mimeType = "derived", textID, "text/plain"
+	}
+
+	if data == nil {
+		if !extract.Supported(content.DocumentType) {
+			return nil, mcperrors.NewValidationError("format",
+				fmt.Errorf("text cannot be extracted from %q content", content.DocumentType))
+		}
+		if data, err = s.readContent(ctx, contentID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Derived text is plain UTF-8 already; running it through the extractor
+	// again is cheap and yields its counts and language
+	result, err := extract.Text(data, mimeType)
+	if err != nil {
+		if errors.Is(err, extract.ErrUnsupported) {
+			return nil, mcperrors.NewValidationError("format", err)
+		}
+		return nil, mcperrors.NewValidationError("content_id", fmt.Errorf("failed to extract text: %w", err))
+	}
+
+	response := map[string]interface{}{
+		"text":       result.Text,
+		"file_name":  content.Name,
+		"mime_type":  "text/plain",
+		"size":       len(result.Text),
+		"format":     result.Format,
+		"charset":    result.Charset,
+		"word_count": result.WordCount,
+		"char_count": result.CharCount,
+		"language":   result.Language,
+		"source":     source,
+	}
+	if derivedID != uuid.Nil {
+		response["derived_id"] = derivedID.String()
+
+		// Format and charset of the original document were recorded by the job
+		metadata, err := s.loadContentMetadata(ctx, derivedID)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		for _, key := range []string{"format", "charset"} {
+			if value, ok := metadata.Metadata[key].(string); ok {
+				response[key] = value
+			}
+		}
+	}
+	return newTextResult(formatJSON(response)), nil
+}
+
+// extractedTextID returns the ID of the processed text variant derived from
+// a content by an extraction job, or uuid.Nil when there is none
+func (s *Server) extractedTextID(ctx context.Context, contentID uuid.UUID) (uuid.UUID, error) {
+	derivedList, err := s.service.ListDerivedContent(ctx,
+		simplecontent.WithParentID(contentID),
+		simplecontent.WithVariant(extract.Variant),
+	)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, derived := range derivedList {
+		if derived.DerivationType == extract.DerivationType && derived.Status == string(simplecontent.ContentStatusProcessed) {
+			return derived.ContentID, nil
+		}
+	}
+	return uuid.Nil, nil
+}
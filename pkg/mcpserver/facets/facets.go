@@ -0,0 +1,158 @@
+// Package facets summarizes content for content_facets.
+//
+// Facets counts content by tag, MIME type, status, derivation type, storage
+// backend and creation date bucket, and totals their size. A Counter builds
+// them item by item, e.g. from content listed through the service; an
+// Aggregator computes them for a Filter in one go, and PostgresAggregator
+// does so with GROUP BY queries on the simple-content PostgreSQL schema.
+package facets
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+)
+
+// Original is the derivation type facet of content that isn't derived
+const Original = "original"
+
+// Interval is the width of creation date buckets
+type Interval string
+
+// Intervals
+const (
+	Day   Interval = "day"
+	Week  Interval = "week" // Starting on Monday
+	Month Interval = "month"
+	Year  Interval = "year"
+)
+
+// ParseInterval parses an interval name
+func ParseInterval(s string) (Interval, error) {
+	switch i := Interval(s); i {
+	case Day, Week, Month, Year:
+		return i, nil
+	}
+	return "", fmt.Errorf("unknown interval %q (day, week, month or year)", s)
+}
+
+// Truncate returns the start of the bucket holding t, in UTC
+func (i Interval) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	year, month, day := t.Date()
+	switch i {
+	case Day:
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	case Week:
+		offset := (int(t.Weekday()) + 6) % 7 // Days since Monday
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, time.UTC)
+	case Year:
+		return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Filter selects the content to summarize. Empty fields don't filter.
+type Filter struct {
+	OwnerID              uuid.UUID
+	TenantID             uuid.UUID
+	IDs                  []uuid.UUID          // Restricts the content to these IDs when not nil
+	Statuses             []string             // Any of these statuses
+	Tags                 []string             // All of these tags
+	Metadata             []*metafilter.Filter // Conditions on the custom metadata
+	ExcludeDocumentTypes []string             // Content of these document types is left out
+}
+
+// Bucket counts the content created in an interval starting at Start
+type Bucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// Facets are the counts of content by facet value. StorageBackends counts
+// each content once per backend holding one of its objects.
+type Facets struct {
+	Total           int            `json:"total"`
+	TotalBytes      int64          `json:"total_bytes"`
+	Tags            map[string]int `json:"tags"`
+	MimeTypes       map[string]int `json:"mime_types"`
+	Statuses        map[string]int `json:"statuses"`
+	DerivationTypes map[string]int `json:"derivation_types"`
+	StorageBackends map[string]int `json:"storage_backends"`
+	Created         []Bucket       `json:"created"` // Oldest first, empty buckets omitted
+	Interval        Interval       `json:"interval"`
+}
+
+// New returns empty facets with creation date buckets of interval
+func New(interval Interval) *Facets {
+	return &Facets{
+		Tags:            make(map[string]int),
+		MimeTypes:       make(map[string]int),
+		Statuses:        make(map[string]int),
+		DerivationTypes: make(map[string]int),
+		StorageBackends: make(map[string]int),
+		Created:         []Bucket{},
+		Interval:        interval,
+	}
+}
+
+// AddCreated counts n content in the bucket starting at start, keeping
+// buckets in order
+func (f *Facets) AddCreated(start time.Time, n int) {
+	i := sort.Search(len(f.Created), func(i int) bool { return !f.Created[i].Start.Before(start) })
+	if i < len(f.Created) && f.Created[i].Start.Equal(start) {
+		f.Created[i].Count += n
+		return
+	}
+	f.Created = slices.Insert(f.Created, i, Bucket{Start: start, Count: n})
+}
+
+// Item is a content with the values of its facets
+type Item struct {
+	DocumentType    string
+	Status          string
+	DerivationType  string
+	CreatedAt       time.Time
+	Tags            []string
+	Size            int64
+	StorageBackends []string
+}
+
+// Add counts an item
+func (f *Facets) Add(item Item) {
+	f.Total++
+	f.TotalBytes += item.Size
+	for _, tag := range uniq(item.Tags) {
+		f.Tags[tag]++
+	}
+	f.MimeTypes[item.DocumentType]++
+	f.Statuses[item.Status]++
+	derivationType := item.DerivationType
+	if derivationType == "" {
+		derivationType = Original
+	}
+	f.DerivationTypes[derivationType]++
+	for _, backend := range uniq(item.StorageBackends) {
+		f.StorageBackends[backend]++
+	}
+	f.AddCreated(f.Interval.Truncate(item.CreatedAt), 1)
+}
+
+// uniq returns the distinct values of values
+func uniq(values []string) []string {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return slices.Compact(values)
+}
+
+// Aggregator computes facets
+type Aggregator interface {
+	// Facets summarizes the live content matching filter
+	Facets(ctx context.Context, filter Filter, interval Interval) (*Facets, error)
+}
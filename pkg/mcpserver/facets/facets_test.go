@@ -0,0 +1,49 @@
+package facets
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInterval(t *testing.T) {
+	at := time.Date(2025, 3, 14, 22, 30, 0, 0, time.FixedZone("PDT", -7*3600)) // 2025-03-15 05:30 UTC, a Saturday
+	for interval, want := range map[Interval]string{
+		Day:   "2025-03-15",
+		Week:  "2025-03-10",
+		Month: "2025-03-01",
+		Year:  "2025-01-01",
+	} {
+		if got := interval.Truncate(at).Format(time.DateOnly); got != want {
+			t.Errorf("%s: got %s, want %s", interval, got, want)
+		}
+	}
+	if got := Week.Truncate(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)).Format(time.DateOnly); got != "2025-03-10" {
+		t.Errorf("Expected a Monday to start its week, got %s", got)
+	}
+
+	if _, err := ParseInterval("quarter"); err == nil {
+		t.Error("Expected an error for an unknown interval")
+	}
+}
+
+func TestAdd(t *testing.T) {
+	f := New(Month)
+	march := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	january := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	f.Add(Item{DocumentType: "application/pdf", Status: "uploaded", CreatedAt: march, Tags: []string{"invoice", "invoice", "2025"}, Size: 100, StorageBackends: []string{"s3", "s3"}})
+	f.Add(Item{DocumentType: "image/png", Status: "processed", DerivationType: "thumbnail", CreatedAt: january, Size: 20, StorageBackends: []string{"default"}})
+	f.Add(Item{DocumentType: "application/pdf", Status: "uploaded", CreatedAt: march.AddDate(0, 0, 3), Tags: []string{"invoice"}})
+
+	if f.Total != 3 || f.TotalBytes != 120 {
+		t.Errorf("Unexpected totals: %d items, %d bytes", f.Total, f.TotalBytes)
+	}
+	if f.Tags["invoice"] != 2 || f.Tags["2025"] != 1 || f.MimeTypes["application/pdf"] != 2 || f.Statuses["processed"] != 1 {
+		t.Errorf("Unexpected counts: %+v", f)
+	}
+	if f.DerivationTypes[Original] != 2 || f.DerivationTypes["thumbnail"] != 1 || f.StorageBackends["s3"] != 1 {
+		t.Errorf("Unexpected derivation types or backends: %v, %v", f.DerivationTypes, f.StorageBackends)
+	}
+	if len(f.Created) != 2 || !f.Created[0].Start.Equal(Month.Truncate(january)) || f.Created[1].Count != 2 {
+		t.Errorf("Unexpected buckets: %+v", f.Created)
+	}
+}
@@ -0,0 +1,120 @@
+package facets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+)
+
+// DBTX is satisfied by pgxpool.Pool, pgx.Conn and pgx.Tx
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...interface{}) pgx.Row
+}
+
+// PostgresAggregator computes facets with GROUP BY queries on the content,
+// content_metadata and object tables of the simple-content PostgreSQL schema
+type PostgresAggregator struct {
+	db DBTX
+}
+
+// NewPostgresAggregator creates an aggregator on db
+func NewPostgresAggregator(db DBTX) *PostgresAggregator {
+	return &PostgresAggregator{db: db}
+}
+
+// Facets summarizes the live content matching filter
+func (a *PostgresAggregator) Facets(ctx context.Context, filter Filter, interval Interval) (*Facets, error) {
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"c.deleted_at IS NULL"}
+	if filter.OwnerID != uuid.Nil {
+		conditions = append(conditions, "c.owner_id = "+arg(filter.OwnerID))
+	}
+	if filter.TenantID != uuid.Nil {
+		conditions = append(conditions, "c.tenant_id = "+arg(filter.TenantID))
+	}
+	if filter.IDs != nil {
+		conditions = append(conditions, "c.id = ANY("+arg(filter.IDs)+")")
+	}
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "c.status = ANY("+arg(filter.Statuses)+")")
+	}
+	if len(filter.Tags) > 0 {
+		conditions = append(conditions, "COALESCE(m.tags, '{}') @> "+arg(filter.Tags))
+	}
+	if len(filter.Metadata) > 0 {
+		conditions = append(conditions, metafilter.SQL("COALESCE(m.metadata, '{}'::jsonb)", filter.Metadata, arg))
+	}
+	if len(filter.ExcludeDocumentTypes) > 0 {
+		conditions = append(conditions, "COALESCE(c.document_type, '') <> ALL("+arg(filter.ExcludeDocumentTypes)+")")
+	}
+
+	// One row per facet value: facet, value, count and, for the total, bytes
+	sql := `WITH matched AS (
+			SELECT c.id, COALESCE(c.document_type, '') AS document_type, c.status,
+				COALESCE(NULLIF(c.derivation_type, ''), ` + arg(Original) + `) AS derivation_type,
+				date_trunc(` + arg(string(interval)) + `, c.created_at AT TIME ZONE 'UTC') AS bucket,
+				COALESCE(m.tags, '{}') AS tags, COALESCE(m.file_size, 0) AS file_size
+			FROM content c LEFT JOIN content_metadata m ON m.content_id = c.id
+			WHERE ` + strings.Join(conditions, " AND ") + `
+		)
+		SELECT 'total', '', COUNT(*), COALESCE(SUM(file_size), 0)::bigint, NULL::timestamp FROM matched
+		UNION ALL SELECT 'tag', tag, COUNT(DISTINCT id), 0, NULL FROM matched, unnest(tags) AS tag GROUP BY tag
+		UNION ALL SELECT 'mime_type', document_type, COUNT(*), 0, NULL FROM matched GROUP BY document_type
+		UNION ALL SELECT 'status', status, COUNT(*), 0, NULL FROM matched GROUP BY status
+		UNION ALL SELECT 'derivation_type', derivation_type, COUNT(*), 0, NULL FROM matched GROUP BY derivation_type
+		UNION ALL SELECT 'storage_backend', o.storage_backend_name, COUNT(DISTINCT o.content_id), 0, NULL
+			FROM object o JOIN matched ON matched.id = o.content_id
+			WHERE o.deleted_at IS NULL GROUP BY o.storage_backend_name
+		UNION ALL SELECT 'created', '', COUNT(*), 0, bucket FROM matched GROUP BY bucket`
+
+	rows, err := a.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate content: %w", err)
+	}
+	defer rows.Close()
+
+	f := New(interval)
+	for rows.Next() {
+		var facet, value string
+		var count, bytes int64
+		var bucket *time.Time
+		if err := rows.Scan(&facet, &value, &count, &bytes, &bucket); err != nil {
+			return nil, fmt.Errorf("failed to scan facet: %w", err)
+		}
+		switch facet {
+		case "total":
+			f.Total, f.TotalBytes = int(count), bytes
+		case "tag":
+			f.Tags[value] = int(count)
+		case "mime_type":
+			f.MimeTypes[value] = int(count)
+		case "status":
+			f.Statuses[value] = int(count)
+		case "derivation_type":
+			f.DerivationTypes[value] = int(count)
+		case "storage_backend":
+			f.StorageBackends[value] = int(count)
+		case "created":
+			// timestamp without time zone scans as UTC
+			f.AddCreated(bucket.UTC(), int(count))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
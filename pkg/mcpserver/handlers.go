@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,7 +14,11 @@ import (
 	"github.com/tendant/simple-content/pkg/simplecontent"
 	"github.com/tendant/simple-content/pkg/simplecontent/admin"
 
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/cursor"
 	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/patch"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/search"
 )
 
 // handleUploadContent uploads content with data in a single operation
@@ -35,6 +40,20 @@ func (s *Server) handleUploadContent(ctx context.Context, req *mcp.CallToolReque
 		return nil, mcperrors.NewValidationError("name", fmt.Errorf("required"))
 	}
 
+	// Server-maintained keys cannot be set by callers
+	metadata := getMap(params, "metadata")
+	if err := checkReservedKeys(nil, metadata); err != nil {
+		return nil, err
+	}
+
+	expiresAt, _, err := parseExpiry(params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != nil {
+		metadata = withExpiry(metadata, expiresAt)
+	}
+
 	// Decode data (base64 or URL)
 	reader, err := s.decodeData(params["data"])
 	if err != nil {
@@ -48,11 +67,12 @@ func (s *Server) handleUploadContent(ctx context.Context, req *mcp.CallToolReque
 		Name:               name,
 		Description:        getStringOr(params, "description", ""),
 		DocumentType:       getStringOr(params, "document_type", "application/octet-stream"),
-		StorageBackendName: getStringOr(params, "storage_backend", ""),
+		StorageBackendName: getStringOr(params, "storage_backend", "default"),
 		Reader:             reader,
 		FileName:           getStringOr(params, "file_name", ""),
+		FileSize:           int64(reader.Len()),
 		Tags:               getStringSlice(params, "tags"),
-		CustomMetadata:     getMap(params, "metadata"),
+		CustomMetadata:     metadata,
 	}
 
 	// Call service
@@ -60,24 +80,26 @@ func (s *Server) handleUploadContent(ctx context.Context, req *mcp.CallToolReque
 	if err != nil {
 		return nil, s.mapError(err)
 	}
+	s.indexContent(ctx, content.ID)
+	s.indexBody(ctx, content.ID)
+	s.autoDerive(ctx, content)
+
+	result := map[string]interface{}{
+		"id":         content.ID.String(),
+		"status":     string(content.Status),
+		"created_at": content.CreatedAt,
+	}
+	if expiresAt != nil {
+		result["expires_at"] = expiresAt
+	}
 
 	// Get download URL using GetContentDetails
-	details, err := s.service.GetContentDetails(ctx, content.ID)
-	if err != nil {
-		// Don't fail if we can't get details, just return without URL
-		return newTextResult(formatJSON(map[string]interface{}{
-			"id":         content.ID.String(),
-			"status":     string(content.Status),
-			"created_at": content.CreatedAt,
-		})), nil
+	// Don't fail if we can't get details, just return without URL
+	if details, err := s.service.GetContentDetails(ctx, content.ID); err == nil {
+		result["download_url"] = details.Download
 	}
 
-	return newTextResult(formatJSON(map[string]interface{}{
-		"id":           content.ID.String(),
-		"status":       string(content.Status),
-		"download_url": details.Download,
-		"created_at":   content.CreatedAt,
-	})), nil
+	return newTextResult(formatJSON(result)), nil
 }
 
 // handleGetContent retrieves content metadata by ID
@@ -97,6 +119,16 @@ func (s *Server) handleGetContent(ctx context.Context, req *mcp.CallToolRequest)
 		return nil, s.mapError(err)
 	}
 
+	var expiresAt interface{}
+	if metadata, err := s.service.GetContentMetadata(ctx, contentID); err == nil {
+		if t, ok := contentExpiry(metadata.Metadata); ok {
+			if !t.After(time.Now()) {
+				return nil, mcperrors.NewNotFoundError("content", contentID.String())
+			}
+			expiresAt = t
+		}
+	}
+
 	return newTextResult(formatJSON(map[string]interface{}{
 		"id":              content.ID.String(),
 		"owner_id":        content.OwnerID.String(),
@@ -107,6 +139,8 @@ func (s *Server) handleGetContent(ctx context.Context, req *mcp.CallToolRequest)
 		"derivation_type": content.DerivationType,
 		"created_at":      content.CreatedAt,
 		"updated_at":      content.UpdatedAt,
+		"expires_at":      expiresAt,
+		"etag":            contentETag(content),
 	})), nil
 }
 
@@ -122,6 +156,10 @@ func (s *Server) handleGetContentDetails(ctx context.Context, req *mcp.CallToolR
 		return nil, mcperrors.NewValidationError("content_id", err)
 	}
 
+	if err := s.checkExpired(ctx, contentID); err != nil {
+		return nil, err
+	}
+
 	includeUpload := getBoolOr(params, "include_upload_url", false)
 
 	var options []simplecontent.ContentDetailsOption
@@ -135,23 +173,23 @@ func (s *Server) handleGetContentDetails(ctx context.Context, req *mcp.CallToolR
 	}
 
 	return newTextResult(formatJSON(map[string]interface{}{
-		"id":          details.ID,
-		"download":    details.Download,
-		"upload":      details.Upload,
-		"preview":     details.Preview,
-		"thumbnail":   details.Thumbnail,
-		"thumbnails":  details.Thumbnails,
-		"previews":    details.Previews,
-		"transcodes":  details.Transcodes,
-		"file_name":   details.FileName,
-		"file_size":   details.FileSize,
-		"mime_type":   details.MimeType,
-		"tags":        details.Tags,
-		"checksum":    details.Checksum,
-		"ready":       details.Ready,
-		"expires_at":  details.ExpiresAt,
-		"created_at":  details.CreatedAt,
-		"updated_at":  details.UpdatedAt,
+		"id":         details.ID,
+		"download":   details.Download,
+		"upload":     details.Upload,
+		"preview":    details.Preview,
+		"thumbnail":  details.Thumbnail,
+		"thumbnails": details.Thumbnails,
+		"previews":   details.Previews,
+		"transcodes": details.Transcodes,
+		"file_name":  details.FileName,
+		"file_size":  details.FileSize,
+		"mime_type":  details.MimeType,
+		"tags":       details.Tags,
+		"checksum":   details.Checksum,
+		"ready":      details.Ready,
+		"expires_at": details.ExpiresAt,
+		"created_at": details.CreatedAt,
+		"updated_at": details.UpdatedAt,
 	})), nil
 }
 
@@ -169,45 +207,110 @@ func (s *Server) handleListContent(ctx context.Context, req *mcp.CallToolRequest
 	limit := getIntOr(params, "limit", s.config.DefaultPageSize)
 	offset := getIntOr(params, "offset", 0)
 
-	// Use admin service if RequireOwnerID is false and admin service is available
-	if !s.config.RequireOwnerID && s.adminService != nil {
-		// Build filters for admin operations
-		filters := admin.ContentFilters{
-			Limit:  &limit,
-			Offset: &offset,
+	collectionID, inCollection, err := parseCollectionFilter(params)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataFilters, err := parseMetadataFilters(params)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges, err := parseRangeFilter(params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	scope := metafilter.Scope{MinSize: ranges.minSize, MaxSize: ranges.maxSize}
+	scope.OwnerID, scope.TenantID, err = s.parseScope(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := parseOrder(params, cursor.ByCreatedAt, cursor.ByUpdatedAt, cursor.ByName, cursor.BySize)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := s.parseCursor("list_content", params)
+	if err != nil {
+		return nil, err
+	}
+
+	// A MetadataQuerier that lists content filters, sorts and pages the
+	// listing in the database. Otherwise the admin service sorts and pages by
+	// creation time and filters by time range; metadata filters, size bounds
+	// and other sort keys apply before pagination, so they need every content.
+	lister, dbPaged := s.config.MetadataQuerier.(metafilter.Lister)
+	dbPaged = dbPaged && !inCollection
+	useAdmin := !inCollection && !dbPaged && !s.config.RequireOwnerID && s.adminService != nil
+	adminPaged := useAdmin && len(metadataFilters) == 0 && !ranges.hasSize() && order.By == cursor.ByCreatedAt && limit > 0
+	var more bool
+	position := contentPosition
+	if inCollection {
+		// Collection members are resolved directly, then scoped like any listing
+		contents, err = s.collectionContents(ctx, collectionID)
+		if err != nil {
+			return nil, err
+		}
+		contents = slices.DeleteFunc(contents, func(content *simplecontent.Content) bool {
+			return (scope.OwnerID != uuid.Nil && content.OwnerID != scope.OwnerID) ||
+				(scope.TenantID != uuid.Nil && content.TenantID != scope.TenantID)
+		})
+	} else if dbPaged {
+		// Without the admin service, listings are per owner like ListContent
+		if scope.OwnerID == uuid.Nil && (s.config.RequireOwnerID || s.adminService == nil) {
+			return nil, mcperrors.NewValidationError("owner_id", fmt.Errorf("owner_id is required"))
 		}
+		listing := &metafilter.Listing{
+			Scope:                scope,
+			Filters:              metadataFilters,
+			Status:               getStringOr(params, "status", ""),
+			ExcludeDocumentTypes: []string{collectionDocumentType},
+			Order:                order,
+			After:                after,
+			Offset:               offset,
+			Limit:                limit,
+		}
+		ranges.listingFilters(listing)
+		contents, position, more, err = listPage(ctx, lister, listing)
+		if err != nil {
+			return nil, err
+		}
+	} else if useAdmin {
+		// Build filters for admin operations
+		filters := admin.ContentFilters{}
 
-		if ownerID, err := parseUUID(params["owner_id"]); err == nil && ownerID != uuid.Nil {
-			filters.OwnerID = &ownerID
+		if scope.OwnerID != uuid.Nil {
+			filters.OwnerID = &scope.OwnerID
 		}
 
-		if tenantID, err := parseUUID(params["tenant_id"]); err == nil && tenantID != uuid.Nil {
-			filters.TenantID = &tenantID
+		if scope.TenantID != uuid.Nil {
+			filters.TenantID = &scope.TenantID
 		}
 
 		if statusStr := getStringOr(params, "status", ""); statusStr != "" {
 			filters.Status = &statusStr
 		}
 
-		// Call admin list
-		req := admin.ListContentsRequest{
-			Filters: filters,
+		ranges.adminFilters(&filters)
+
+		if adminPaged {
+			contents, more, err = s.adminPage(ctx, filters, order, after, offset, limit)
+		} else {
+			contents, err = s.listAllContents(ctx, filters)
 		}
-		resp, err := s.adminService.ListAllContents(ctx, req)
 		if err != nil {
 			return nil, s.mapError(err)
 		}
-		contents = resp.Contents
+		// Collection records are listed through list_collection
+		contents = withoutCollections(contents)
 	} else {
 		// Use standard service method (requires owner_id)
-		listReq := simplecontent.ListContentRequest{}
-
-		if ownerID, err := parseUUID(params["owner_id"]); err == nil {
-			listReq.OwnerID = ownerID
-		}
-
-		if tenantID, err := parseUUID(params["tenant_id"]); err == nil {
-			listReq.TenantID = tenantID
+		listReq := simplecontent.ListContentRequest{
+			OwnerID:  scope.OwnerID,
+			TenantID: scope.TenantID,
 		}
 
 		// Call service
@@ -215,32 +318,45 @@ func (s *Server) handleListContent(ctx context.Context, req *mcp.CallToolRequest
 		if err != nil {
 			return nil, s.mapError(err)
 		}
+		contents = withoutCollections(contents)
+	}
 
-		// Apply client-side filtering for status since ListContent doesn't support it
-		if statusStr := getStringOr(params, "status", ""); statusStr != "" {
-			temp := make([]*simplecontent.Content, 0)
-			for _, c := range contents {
-				if string(c.Status) == statusStr {
-					temp = append(temp, c)
-				}
+	if !dbPaged {
+		contents, err = s.filterByMetadata(ctx, contents, scope, metadataFilters)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply client-side filtering for status and times since ListContent doesn't support them
+	if !useAdmin && !dbPaged {
+		statusStr := getStringOr(params, "status", "")
+		temp := make([]*simplecontent.Content, 0)
+		for _, c := range contents {
+			if (statusStr == "" || string(c.Status) == statusStr) && ranges.matchesTimes(c.CreatedAt, c.UpdatedAt) {
+				temp = append(temp, c)
 			}
-			contents = temp
 		}
+		contents = temp
 	}
 
-	// Apply client-side pagination only for standard service method
-	// (admin service already handled pagination)
+	// Apply client-side pagination only when neither the database nor the
+	// admin service paged (they don't count matches either, so total is only
+	// known here)
 	pagedContents := contents
-	if s.config.RequireOwnerID || s.adminService == nil {
-		start := offset
-		end := offset + limit
-		if start > len(contents) {
-			start = len(contents)
+	total := -1
+	if !adminPaged && !dbPaged {
+		position, err = s.contentPositions(ctx, contents, order)
+		if err != nil {
+			return nil, err
 		}
-		if end > len(contents) {
-			end = len(contents)
+		cursor.Sort(contents, position, order)
+		total = len(contents)
+		if after != nil {
+			pagedContents, more = cursor.Page(contents, position, order, after, limit)
+		} else {
+			pagedContents, more = offsetPage(contents, offset, limit)
 		}
-		pagedContents = contents[start:end]
 	}
 
 	// Format results
@@ -259,12 +375,37 @@ func (s *Server) handleListContent(ctx context.Context, req *mcp.CallToolRequest
 		}
 	}
 
-	return newTextResult(formatJSON(map[string]interface{}{
+	result := map[string]interface{}{
 		"items":  items,
-		"total":  len(contents),
 		"limit":  limit,
 		"offset": offset,
-	})), nil
+	}
+	if total >= 0 {
+		result["total"] = total
+	}
+	if more && len(pagedContents) > 0 {
+		result["next_cursor"] = s.nextCursor("list_content", params, order, position(pagedContents[len(pagedContents)-1]))
+	}
+	return newTextResult(formatJSON(result)), nil
+}
+
+// listAllContents pages through every content matching filters
+func (s *Server) listAllContents(ctx context.Context, filters admin.ContentFilters) ([]*simplecontent.Content, error) {
+	var contents []*simplecontent.Content
+	limit := sweepPageSize
+	for offset := 0; ; offset += limit {
+		pageOffset := offset
+		filters.Limit = &limit
+		filters.Offset = &pageOffset
+		resp, err := s.adminService.ListAllContents(ctx, admin.ListContentsRequest{Filters: filters})
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, resp.Contents...)
+		if len(resp.Contents) < limit {
+			return contents, nil
+		}
+	}
 }
 
 // handleDownloadContent downloads content data
@@ -279,7 +420,14 @@ func (s *Server) handleDownloadContent(ctx context.Context, req *mcp.CallToolReq
 		return nil, mcperrors.NewValidationError("content_id", err)
 	}
 
+	if err := s.checkExpired(ctx, contentID); err != nil {
+		return nil, err
+	}
+
 	format := getStringOr(params, "format", "url")
+	if format == "extracted_text" {
+		return s.downloadExtractedText(ctx, contentID)
+	}
 
 	// Get content details for URL
 	details, err := s.service.GetContentDetails(ctx, contentID)
@@ -330,12 +478,42 @@ func (s *Server) handleUpdateContent(ctx context.Context, req *mcp.CallToolReque
 		return nil, mcperrors.NewValidationError("content_id", err)
 	}
 
+	unlock := s.locks.lock(contentID)
+	defer unlock()
+
 	// Get current content
 	content, err := s.service.GetContent(ctx, contentID)
 	if err != nil {
 		return nil, s.mapError(err)
 	}
 
+	// Reject the update if the caller's version is stale
+	if err := checkIfMatch(params, content); err != nil {
+		return nil, err
+	}
+
+	// Capture the current state for the diff
+	metadata, err := s.loadContentMetadata(ctx, contentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	before := contentSnapshot(content, metadata.Tags, metadata.Metadata)
+
+	// Work out the new tags and metadata before writing anything
+	tags, tagsChanged := applyTagChanges(params, metadata.Tags)
+	custom, metadataChanged, err := applyMetadataPatch(params, metadata.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, expiryChanged, err := parseExpiry(params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if expiryChanged {
+		custom = withExpiry(custom, expiresAt)
+		metadataChanged = true
+	}
+
 	// Update fields if provided
 	if name := getStringOr(params, "name", ""); name != "" {
 		content.Name = name
@@ -355,25 +533,34 @@ func (s *Server) handleUpdateContent(ctx context.Context, req *mcp.CallToolReque
 		return nil, s.mapError(err)
 	}
 
-	// If tags or metadata provided, update them separately
-	if tags := getStringSlice(params, "tags"); len(tags) > 0 || getMap(params, "metadata") != nil {
-		metadataReq := simplecontent.SetContentMetadataRequest{
-			ContentID:      contentID,
-			Tags:           tags,
-			CustomMetadata: getMap(params, "metadata"),
+	// If tags or metadata changed, update them separately
+	if tagsChanged || metadataChanged {
+		if err := s.saveContentMetadata(ctx, metadata, tags, custom); err != nil {
+			return nil, s.mapError(err)
 		}
-		if err := s.service.SetContentMetadata(ctx, metadataReq); err != nil {
+
+		// Re-read so the diff reflects what was actually stored
+		if metadata, err = s.loadContentMetadata(ctx, contentID); err != nil {
 			return nil, s.mapError(err)
 		}
 	}
 
+	s.indexContent(ctx, contentID)
+
+	after := contentSnapshot(content, metadata.Tags, metadata.Metadata)
+
 	return newTextResult(formatJSON(map[string]interface{}{
 		"success":    true,
-		"updated_at": time.Now(),
+		"updated_at": content.UpdatedAt,
+		"etag":       contentETag(content),
+		"patch_mode": getStringOr(params, "patch_mode", "merge"),
+		"before":     before,
+		"after":      after,
+		"changes":    patch.Diff(before, after),
 	})), nil
 }
 
-// handleDeleteContent soft deletes content
+// handleDeleteContent soft deletes content, optionally cascading to derived content
 func (s *Server) handleDeleteContent(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var params map[string]interface{}
 	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
@@ -385,98 +572,185 @@ func (s *Server) handleDeleteContent(ctx context.Context, req *mcp.CallToolReque
 		return nil, mcperrors.NewValidationError("content_id", err)
 	}
 
-	err = s.service.DeleteContent(ctx, contentID)
+	cascade := getBoolOr(params, "cascade", false)
+	dryRun := getBoolOr(params, "dry_run", false)
+
+	unlock := s.locks.lock(contentID)
+	defer unlock()
+
+	content, err := s.service.GetContent(ctx, contentID)
 	if err != nil {
 		return nil, s.mapError(err)
 	}
 
+	// Reject the delete if the caller's version is stale
+	if err := checkIfMatch(params, content); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		derived := []derivedNode{}
+		if cascade {
+			derived, err = s.collectDerivedTree(ctx, contentID)
+			if err != nil {
+				return nil, s.mapError(err)
+			}
+		}
+
+		return newTextResult(formatJSON(map[string]interface{}{
+			"dry_run":      true,
+			"content_id":   contentID.String(),
+			"cascade":      cascade,
+			"derived":      derived,
+			"would_delete": len(derived) + 1,
+		})), nil
+	}
+
+	deleted, err := s.deleteContentTree(ctx, contentID, cascade)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+
+	deletedIDs := make([]string, len(deleted))
+	for i, id := range deleted {
+		deletedIDs[i] = id.String()
+	}
+
 	return newTextResult(formatJSON(map[string]interface{}{
-		"success":    true,
-		"deleted_at": time.Now(),
+		"success":     true,
+		"deleted_ids": deletedIDs,
+		"deleted_at":  time.Now(),
 	})), nil
 }
 
-// handleSearchContent searches content by metadata, tags, or full-text
+// handleSearchContent searches content through the search index, ranking
+// matches of the query against name, tags and description, or against
+// passages of the content text with scope=body
 func (s *Server) handleSearchContent(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var params map[string]interface{}
 	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
 		return nil, mcperrors.NewValidationError("arguments", err)
 	}
 
-	// Build request - use basic ListContent
-	// Note: simple-content doesn't have a dedicated search method
-	// We'll use ListContent and filter client-side for Phase 1
-	listReq := simplecontent.ListContentRequest{}
+	// Get pagination parameters
+	limit := getIntOr(params, "limit", s.config.DefaultPageSize)
+	offset := getIntOr(params, "offset", 0)
 
-	if ownerID, err := parseUUID(params["owner_id"]); err == nil {
-		listReq.OwnerID = ownerID
+	// The query string is compiled up front so syntax errors point at the problem
+	plan, err := search.Compile(getStringOr(params, "query", ""))
+	if err != nil {
+		return nil, mcperrors.NewValidationError("query", fmt.Errorf("%w (syntax: %s)", err, searchSyntaxURI))
 	}
 
-	if tenantID, err := parseUUID(params["tenant_id"]); err == nil {
-		listReq.TenantID = tenantID
+	scope := getStringOr(params, "scope", "metadata")
+	if scope != "metadata" && scope != "body" {
+		return nil, mcperrors.NewValidationError("scope", fmt.Errorf("must be metadata or body"))
 	}
 
-	// Call service
-	contents, err := s.service.ListContent(ctx, listReq)
+	metadataFilters, err := parseMetadataFilters(params)
 	if err != nil {
-		return nil, s.mapError(err)
+		return nil, err
 	}
 
-	// Get pagination parameters
-	limit := getIntOr(params, "limit", s.config.DefaultPageSize)
-	offset := getIntOr(params, "offset", 0)
+	// Range filters run in the index as query conditions
+	ranges, err := parseRangeFilter(params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	plan = plan.And(ranges.searchConditions()...)
 
-	// Apply client-side filtering
-	filtered := contents
+	order, err := parseOrder(params, cursor.ByRelevance, cursor.ByCreatedAt, cursor.ByUpdatedAt, cursor.ByName, cursor.BySize)
+	if err != nil {
+		return nil, err
+	}
 
-	// Query-based filtering (search in name and description)
-	query := getStringOr(params, "query", "")
-	if query != "" {
-		temp := make([]*simplecontent.Content, 0)
-		queryLower := toLowerString(query)
-		for _, content := range filtered {
-			if containsString(toLowerString(content.Name), queryLower) ||
-				containsString(toLowerString(content.Description), queryLower) {
-				temp = append(temp, content)
-			}
+	after, err := s.parseCursor("search_content", params)
+	if err != nil {
+		return nil, err
+	}
+	if scope == "body" && (after != nil || order != cursor.Order{By: cursor.ByRelevance}) {
+		return nil, mcperrors.NewValidationError("scope", fmt.Errorf("scope body is sorted by relevance and pages with offset"))
+	}
+
+	query := &search.Query{
+		Plan:      plan,
+		Statuses:  getStringSlice(params, "status"),
+		Tags:      getStringSlice(params, "tags"),
+		Metadata:  metadataFilters,
+		SortBy:    order.By,
+		Ascending: order.Ascending,
+		Limit:     limit,
+		Offset:    offset,
+	}
+	if after != nil {
+		query.After = searchHit(after)
+	}
+
+	query.OwnerID, query.TenantID, err = s.parseScope(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionID, inCollection, err := parseCollectionFilter(params)
+	if err != nil {
+		return nil, err
+	}
+	var members []*simplecontent.Content
+	if inCollection {
+		members, err = s.collectionContents(ctx, collectionID)
+		if err != nil {
+			return nil, err
 		}
-		filtered = temp
-	}
-
-	// Status filtering (support array of statuses)
-	if statusArray := params["status"]; statusArray != nil {
-		if statusSlice, ok := statusArray.([]interface{}); ok && len(statusSlice) > 0 {
-			temp := make([]*simplecontent.Content, 0)
-			statusMap := make(map[string]bool)
-			for _, s := range statusSlice {
-				if statusStr, ok := s.(string); ok {
-					statusMap[statusStr] = true
-				}
-			}
-			for _, content := range filtered {
-				if statusMap[string(content.Status)] {
-					temp = append(temp, content)
-				}
-			}
-			filtered = temp
+		query.IDs = make([]uuid.UUID, len(members))
+		for i, member := range members {
+			query.IDs[i] = member.ID
 		}
 	}
 
-	// Apply pagination
-	start := offset
-	end := offset + limit
-	if start > len(filtered) {
-		start = len(filtered)
+	if scope == "body" {
+		if _, ok := s.bodyIndex(); !ok {
+			return nil, mcperrors.NewValidationError("scope", fmt.Errorf("body search is not enabled on this server"))
+		}
+		if len(plan.Terms) == 0 {
+			return nil, mcperrors.NewValidationError("query", fmt.Errorf("scope body requires words to search for"))
+		}
 	}
-	if end > len(filtered) {
-		end = len(filtered)
+
+	index, err := s.populatedIndex(ctx, query, members, scope == "body")
+	if err != nil {
+		return nil, err
+	}
+	if scope == "body" {
+		return s.searchBodies(ctx, index.(search.BodyIndex), query)
 	}
-	pagedContents := filtered[start:end]
 
-	// Format results
-	items := make([]map[string]interface{}, len(pagedContents))
-	for i, content := range pagedContents {
-		items[i] = map[string]interface{}{
+	// One more hit than requested tells whether a next page exists
+	if limit > 0 {
+		query.Limit = limit + 1
+	}
+	result, err := index.Search(ctx, query)
+	if err != nil {
+		return nil, mcperrors.NewInternalError(fmt.Errorf("search failed: %w", err))
+	}
+	hits, more := result.Hits, false
+	if limit > 0 && len(hits) > limit {
+		hits, more = hits[:limit], true
+	}
+
+	// Format results from the current content records
+	items := make([]map[string]interface{}, 0, len(hits))
+	for _, hit := range hits {
+		content, err := s.service.GetContent(ctx, hit.ID)
+		if err != nil {
+			if mcperrors.IsNotFound(err) {
+				// Deleted behind the index's back
+				s.unindexContent(ctx, hit.ID)
+				continue
+			}
+			return nil, s.mapError(err)
+		}
+
+		item := map[string]interface{}{
 			"id":              content.ID.String(),
 			"owner_id":        content.OwnerID.String(),
 			"tenant_id":       content.TenantID.String(),
@@ -486,52 +760,31 @@ func (s *Server) handleSearchContent(ctx context.Context, req *mcp.CallToolReque
 			"derivation_type": content.DerivationType,
 			"created_at":      content.CreatedAt,
 			"updated_at":      content.UpdatedAt,
+			"score":           hit.Score,
 		}
+		if len(hit.Highlights) > 0 {
+			item["highlights"] = hit.Highlights
+		}
+		items = append(items, item)
 	}
 
-	return newTextResult(formatJSON(map[string]interface{}{
+	response := map[string]interface{}{
 		"items":  items,
-		"total":  len(filtered),
+		"total":  result.Total,
 		"limit":  limit,
 		"offset": offset,
-	})), nil
-}
-
-// Helper functions for string operations
-
-func toLowerString(s string) string {
-	result := make([]byte, len(s))
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if c >= 'A' && c <= 'Z' {
-			result[i] = c + ('a' - 'A')
-		} else {
-			result[i] = c
-		}
-	}
-	return string(result)
-}
-
-func containsString(s, substr string) bool {
-	if len(substr) == 0 {
-		return true
-	}
-	if len(s) < len(substr) {
-		return false
-	}
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if matchAtPos(s, substr, i) {
-			return true
-		}
-	}
-	return false
-}
-
-func matchAtPos(s, substr string, pos int) bool {
-	for i := 0; i < len(substr); i++ {
-		if s[pos+i] != substr[i] {
-			return false
-		}
 	}
-	return true
+	if more {
+		// The cursor follows the last hit even when its content was deleted
+		last := hits[len(hits)-1]
+		response["next_cursor"] = s.nextCursor("search_content", params, order, cursor.Position{
+			Score:     &last.Score,
+			UpdatedAt: &last.UpdatedAt,
+			Name:      &last.Name,
+			Size:      &last.Size,
+			CreatedAt: last.CreatedAt,
+			ID:        last.ID,
+		})
+	}
+	return newTextResult(formatJSON(response)), nil
 }
@@ -5,11 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
-	"github.com/tendant/simple-content/pkg/simplecontent"
 	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content/pkg/simplecontent"
 )
 
 // BatchUploadItem represents a single item in a batch upload request
@@ -26,10 +27,11 @@ type BatchUploadItem struct {
 
 // BatchUploadResult represents the result of a single upload in a batch
 type BatchUploadResult struct {
-	Index     int    `json:"index"`
-	Success   bool   `json:"success"`
-	ContentID string `json:"content_id,omitempty"`
-	Error     string `json:"error,omitempty"`
+	Index     int        `json:"index"`
+	Success   bool       `json:"success"`
+	ContentID string     `json:"content_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
 }
 
 // handleBatchUpload handles batch upload of multiple content items
@@ -75,7 +77,9 @@ func (s *Server) handleBatchUpload(ctx context.Context, req *mcp.CallToolRequest
 	}
 
 	// Parse each item
+	now := time.Now()
 	items := make([]BatchUploadItem, len(itemsArray))
+	expiries := make([]*time.Time, len(itemsArray))
 	for i, itemRaw := range itemsArray {
 		itemMap, ok := itemRaw.(map[string]interface{})
 		if !ok {
@@ -95,6 +99,25 @@ func (s *Server) handleBatchUpload(ctx context.Context, req *mcp.CallToolRequest
 		if items[i].Data == "" {
 			return nil, mcperrors.NewValidationError(fmt.Sprintf("items[%d].data", i), fmt.Errorf("data is required"))
 		}
+		if err := checkReservedKeys(nil, items[i].Metadata); err != nil {
+			return nil, mcperrors.NewValidationError(fmt.Sprintf("items[%d].metadata", i), err)
+		}
+
+		// Items without their own expiry inherit the batch-level one
+		expiryParams := params
+		if _, ok := itemMap["expires_at"]; ok {
+			expiryParams = itemMap
+		} else if _, ok := itemMap["ttl_seconds"]; ok {
+			expiryParams = itemMap
+		}
+		expiresAt, _, err := parseExpiry(expiryParams, now)
+		if err != nil {
+			return nil, mcperrors.NewValidationError(fmt.Sprintf("items[%d]", i), err)
+		}
+		if expiresAt != nil {
+			items[i].Metadata = withExpiry(items[i].Metadata, expiresAt)
+			expiries[i] = expiresAt
+		}
 	}
 
 	// Process uploads in parallel
@@ -129,6 +152,7 @@ func (s *Server) handleBatchUpload(ctx context.Context, req *mcp.CallToolRequest
 				DocumentType:   uploadItem.DocumentType,
 				Reader:         reader,
 				FileName:       uploadItem.FileName,
+				FileSize:       int64(reader.Len()),
 				Tags:           uploadItem.Tags,
 				CustomMetadata: uploadItem.Metadata,
 			}
@@ -157,8 +181,12 @@ func (s *Server) handleBatchUpload(ctx context.Context, req *mcp.CallToolRequest
 				Index:     index,
 				Success:   true,
 				ContentID: content.ID.String(),
+				ExpiresAt: expiries[index],
 			}
 			mu.Unlock()
+			s.indexContent(ctx, content.ID)
+			s.indexBody(ctx, content.ID)
+			s.autoDerive(ctx, content)
 		}(i, item)
 	}
 
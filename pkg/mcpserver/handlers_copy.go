@@ -0,0 +1,364 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// copiedItem records a derived content copied along with its parent
+type copiedItem struct {
+	SourceID string `json:"source_id"`
+	ID       string `json:"id"`
+	Variant  string `json:"variant"`
+}
+
+// handleCopyContent copies content (and optionally its derived content) to another owner or tenant
+func (s *Server) handleCopyContent(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Unmarshal arguments
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	// Parse content_id (required)
+	contentID, err := parseUUID(params["content_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("content_id", err)
+	}
+
+	source, err := s.service.GetContent(ctx, contentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+
+	// Destination defaults to the source owner and tenant
+	targetOwnerID, targetTenantID, err := parseTarget(params, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeAccess(ctx, source.OwnerID, source.TenantID); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeAccess(ctx, targetOwnerID, targetTenantID); err != nil {
+		return nil, err
+	}
+
+	data, err := s.readContent(ctx, contentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copies stay on the backend of their source unless told otherwise
+	storageBackend := getStringOr(params, "storage_backend", "")
+	uploadBackend := storageBackend
+	if uploadBackend == "" {
+		if uploadBackend, err = s.contentBackend(ctx, contentID); err != nil {
+			return nil, s.mapError(err)
+		}
+	}
+
+	uploadReq := simplecontent.UploadContentRequest{
+		OwnerID:            targetOwnerID,
+		TenantID:           targetTenantID,
+		Name:               getStringOr(params, "name", source.Name),
+		Description:        source.Description,
+		DocumentType:       source.DocumentType,
+		StorageBackendName: uploadBackend,
+		Reader:             bytes.NewReader(data),
+		FileSize:           int64(len(data)),
+	}
+	if metadata, err := s.service.GetContentMetadata(ctx, contentID); err == nil {
+		uploadReq.FileName = metadata.FileName
+		uploadReq.Tags = metadata.Tags
+		uploadReq.CustomMetadata = copyMap(metadata.Metadata)
+	}
+
+	copied, err := s.service.UploadContent(ctx, uploadReq)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	s.indexContent(ctx, copied.ID)
+	s.indexBody(ctx, copied.ID)
+
+	derived := []copiedItem{}
+	if getBoolOr(params, "include_derived", false) {
+		derived, err = s.copyDerivedTree(ctx, contentID, copied, storageBackend)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+	}
+
+	return newTextResult(formatJSON(map[string]interface{}{
+		"id":         copied.ID.String(),
+		"source_id":  contentID.String(),
+		"owner_id":   copied.OwnerID.String(),
+		"tenant_id":  copied.TenantID.String(),
+		"status":     copied.Status,
+		"derived":    derived,
+		"created_at": copied.CreatedAt,
+	})), nil
+}
+
+// handleTransferContent reassigns content (and optionally its derived content) to another owner or tenant
+func (s *Server) handleTransferContent(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Unmarshal arguments
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	// Parse content_id (required)
+	contentID, err := parseUUID(params["content_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("content_id", err)
+	}
+
+	if _, ok := params["target_owner_id"]; !ok {
+		return nil, mcperrors.NewValidationError("target_owner_id", fmt.Errorf("required"))
+	}
+
+	content, err := s.service.GetContent(ctx, contentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+
+	targetOwnerID, targetTenantID, err := parseTarget(params, content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeAccess(ctx, content.OwnerID, content.TenantID); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeAccess(ctx, targetOwnerID, targetTenantID); err != nil {
+		return nil, err
+	}
+
+	ids := []uuid.UUID{contentID}
+	if getBoolOr(params, "include_derived", true) {
+		nodes, err := s.collectDerivedTree(ctx, contentID)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		for _, node := range nodes {
+			ids = append(ids, node.ContentID)
+		}
+	}
+
+	previousOwnerID := content.OwnerID
+	previousTenantID := content.TenantID
+
+	// Load the whole tree before changing anything, so a missing item fails
+	// the transfer without moving part of it
+	items := make([]*simplecontent.Content, 0, len(ids))
+	for _, id := range ids {
+		item, err := s.service.GetContent(ctx, id)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		items = append(items, item)
+	}
+
+	transferred := make([]string, 0, len(items))
+	for i, item := range items {
+		if err := s.reassignContent(ctx, item, targetOwnerID, targetTenantID); err != nil {
+			return nil, s.rollbackTransfer(ctx, items[:i], err)
+		}
+		transferred = append(transferred, item.ID.String())
+	}
+
+	return newTextResult(formatJSON(map[string]interface{}{
+		"id":                 contentID.String(),
+		"previous_owner_id":  previousOwnerID.String(),
+		"previous_tenant_id": previousTenantID.String(),
+		"owner_id":           targetOwnerID.String(),
+		"tenant_id":          targetTenantID.String(),
+		"transferred_ids":    transferred,
+	})), nil
+}
+
+// reassignContent moves content to ownerID and tenantID. The content
+// record passed in is left unchanged, so it still holds the previous owner
+// and tenant for a rollback.
+func (s *Server) reassignContent(ctx context.Context, content *simplecontent.Content, ownerID, tenantID uuid.UUID) error {
+	updated := *content
+	updated.OwnerID = ownerID
+	updated.TenantID = tenantID
+	if err := s.service.UpdateContent(ctx, simplecontent.UpdateContentRequest{Content: &updated}); err != nil {
+		return err
+	}
+	s.indexContent(ctx, content.ID)
+	return nil
+}
+
+// rollbackTransfer moves the already transferred items, as loaded before the
+// transfer, back to their previous owner and tenant after a transfer failed
+// with cause. Items that
+// cannot be restored are listed in the returned error, so the caller can
+// retry the transfer or fix them up.
+func (s *Server) rollbackTransfer(ctx context.Context, moved []*simplecontent.Content, cause error) error {
+	var stranded []string
+	for i := len(moved) - 1; i >= 0; i-- {
+		item := moved[i]
+		if err := s.reassignContent(ctx, item, item.OwnerID, item.TenantID); err != nil {
+			stranded = append(stranded, item.ID.String())
+		}
+	}
+	if len(stranded) > 0 {
+		return mcperrors.NewInternalError(fmt.Errorf("transfer failed and could not be rolled back: %v; content still transferred: %v", cause, stranded))
+	}
+	return s.mapError(cause)
+}
+
+// copyDerivedTree copies derived content of sourceID, recursively, underneath
+// target. Copies go to storageBackend, or to the backend of their source
+// when it is empty.
+func (s *Server) copyDerivedTree(ctx context.Context, sourceID uuid.UUID, target *simplecontent.Content, storageBackend string) ([]copiedItem, error) {
+	children, err := s.service.ListDerivedContent(ctx, simplecontent.WithParentID(sourceID))
+	if err != nil {
+		return nil, err
+	}
+
+	var copied []copiedItem
+	for _, child := range children {
+		childContent, err := s.service.GetContent(ctx, child.ContentID)
+		if err != nil {
+			if mcperrors.IsNotFound(err) {
+				continue
+			}
+			return copied, err
+		}
+
+		data, err := s.readContent(ctx, child.ContentID)
+		if err != nil {
+			return copied, err
+		}
+
+		backend := storageBackend
+		if backend == "" {
+			if backend, err = s.contentBackend(ctx, child.ContentID); err != nil {
+				return copied, err
+			}
+		}
+
+		derivedReq := simplecontent.UploadDerivedContentRequest{
+			ParentID:           target.ID,
+			OwnerID:            target.OwnerID,
+			TenantID:           target.TenantID,
+			DerivationType:     child.DerivationType,
+			Variant:            child.Variant,
+			StorageBackendName: backend,
+			Reader:             bytes.NewReader(data),
+			FileSize:           int64(len(data)),
+			Metadata:           copyMap(child.DerivationParams),
+		}
+		metadata, err := s.service.GetContentMetadata(ctx, child.ContentID)
+		if err == nil {
+			derivedReq.FileName = metadata.FileName
+			derivedReq.Tags = metadata.Tags
+		}
+
+		derived, err := s.service.UploadDerivedContent(ctx, derivedReq)
+		if err != nil {
+			return copied, err
+		}
+
+		// UploadDerivedContent has no field for custom metadata
+		if metadata != nil && len(metadata.Metadata) > 0 {
+			record, err := s.loadContentMetadata(ctx, derived.ID)
+			if err != nil {
+				return copied, err
+			}
+			custom := copyMap(record.Metadata)
+			for k, v := range metadata.Metadata {
+				custom[k] = v
+			}
+			if err := s.saveContentMetadata(ctx, record, record.Tags, custom); err != nil {
+				return copied, err
+			}
+		}
+
+		// UploadDerivedContent does not carry the MIME type over
+		if childContent.DocumentType != "" {
+			derived.DocumentType = childContent.DocumentType
+			if err := s.service.UpdateContent(ctx, simplecontent.UpdateContentRequest{Content: derived}); err != nil {
+				return copied, err
+			}
+		}
+		s.indexContent(ctx, derived.ID)
+		s.indexBody(ctx, derived.ID)
+
+		copied = append(copied, copiedItem{
+			SourceID: child.ContentID.String(),
+			ID:       derived.ID.String(),
+			Variant:  child.Variant,
+		})
+
+		nested, err := s.copyDerivedTree(ctx, child.ContentID, derived, storageBackend)
+		copied = append(copied, nested...)
+		if err != nil {
+			return copied, err
+		}
+	}
+
+	return copied, nil
+}
+
+// readContent downloads the full data of a content into memory
+func (s *Server) readContent(ctx context.Context, contentID uuid.UUID) ([]byte, error) {
+	reader, err := s.service.DownloadContent(ctx, contentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, mcperrors.NewInternalError(fmt.Errorf("failed to read content: %w", err))
+	}
+	return data, nil
+}
+
+// parseTarget resolves target_owner_id and target_tenant_id, defaulting to the current values of content
+func parseTarget(params map[string]interface{}, content *simplecontent.Content) (uuid.UUID, uuid.UUID, error) {
+	ownerID := content.OwnerID
+	if v, ok := params["target_owner_id"]; ok {
+		id, err := parseUUID(v)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, mcperrors.NewValidationError("target_owner_id", err)
+		}
+		ownerID = id
+	}
+
+	tenantID := content.TenantID
+	if v, ok := params["target_tenant_id"]; ok {
+		id, err := parseUUID(v)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, mcperrors.NewValidationError("target_tenant_id", err)
+		}
+		tenantID = id
+	}
+
+	return ownerID, tenantID, nil
+}
+
+// copyMap returns a shallow copy of m
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
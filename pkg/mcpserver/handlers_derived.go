@@ -1,12 +1,19 @@
 package mcpserver
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
-	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/cursor"
 	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content/pkg/simplecontent"
 )
 
 // handleListDerivedContent lists derived content (thumbnails, previews) for a parent content
@@ -57,20 +64,52 @@ func (s *Server) handleListDerivedContent(ctx context.Context, req *mcp.CallTool
 		}
 	}
 
-	// Pagination
+	// Creation time bounds apply in the repository; update time and size
+	// bounds to the listed derivations, whose sizes come with their metadata
+	ranges, err := parseRangeFilter(params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if ranges.createdAfter != nil {
+		options = append(options, simplecontent.WithCreatedAfter(*ranges.createdAfter))
+	}
+	if ranges.createdBefore != nil {
+		options = append(options, simplecontent.WithCreatedBefore(*ranges.createdBefore))
+	}
+	if ranges.hasSize() {
+		options = append(options, simplecontent.WithMetadata())
+	}
+
+	// Pagination. A parent has few derivations, so they are all listed and
+	// paged here in a stable order.
 	limit := getIntOr(params, "limit", s.config.DefaultPageSize)
 	offset := getIntOr(params, "offset", 0)
-	options = append(options, simplecontent.WithPagination(limit, offset))
+	after, err := s.parseCursor("list_derived_content", params)
+	if err != nil {
+		return nil, err
+	}
 
 	// Call service
 	derivedList, err := s.service.ListDerivedContent(ctx, options...)
 	if err != nil {
 		return nil, s.mapError(err)
 	}
+	derivedList = slices.DeleteFunc(derivedList, func(derived *simplecontent.DerivedContent) bool {
+		var size int64
+		if derived.Metadata != nil {
+			size = derived.Metadata.FileSize
+		}
+		return !ranges.matchesTimes(derived.CreatedAt, derived.UpdatedAt) || !sizeWithin(size, ranges.minSize, ranges.maxSize)
+	})
 
-	// Count total (if needed for pagination)
-	// Note: CountDerivedContent is a helper function, not a service method
-	// For now, we'll just return what we have
+	cursor.Sort(derivedList, derivedPosition, cursor.Order{})
+	total := len(derivedList)
+	var more bool
+	if after != nil {
+		derivedList, more = cursor.Page(derivedList, derivedPosition, cursor.Order{}, after, limit)
+	} else {
+		derivedList, more = offsetPage(derivedList, offset, limit)
+	}
 
 	// Format result
 	items := make([]map[string]interface{}, len(derivedList))
@@ -93,13 +132,22 @@ func (s *Server) handleListDerivedContent(ctx context.Context, req *mcp.CallTool
 	result := map[string]interface{}{
 		"items":  items,
 		"count":  len(items),
+		"total":  total,
 		"limit":  limit,
 		"offset": offset,
 	}
+	if more && len(derivedList) > 0 {
+		result["next_cursor"] = s.nextCursor("list_derived_content", params, cursor.Order{}, derivedPosition(derivedList[len(derivedList)-1]))
+	}
 
 	return newTextResult(formatJSON(result)), nil
 }
 
+// derivedPosition returns the position of derived content in listings
+func derivedPosition(derived *simplecontent.DerivedContent) cursor.Position {
+	return cursor.Position{CreatedAt: derived.CreatedAt, ID: derived.ContentID}
+}
+
 // handleGetThumbnails gets thumbnails by size (convenience wrapper)
 func (s *Server) handleGetThumbnails(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Unmarshal arguments
@@ -164,3 +212,102 @@ func (s *Server) handleGetThumbnails(ctx context.Context, req *mcp.CallToolReque
 
 	return newTextResult(formatJSON(result)), nil
 }
+
+// handleCreateDerivedContent uploads data as derived content of a parent
+// (e.g. a summary, translation or thumbnail produced by the agent)
+func (s *Server) handleCreateDerivedContent(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	parentID, err := parseUUID(params["parent_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("parent_id", err)
+	}
+
+	variant := strings.ToLower(strings.TrimSpace(getStringOr(params, "variant", "")))
+	if variant == "" {
+		return nil, mcperrors.NewValidationError("variant", fmt.Errorf("required"))
+	}
+
+	// Derivation type defaults to the variant prefix (thumbnail_256 -> thumbnail)
+	derivationType := getStringOr(params, "derivation_type", "")
+	if derivationType == "" {
+		derivationType = simplecontent.DerivationTypeFromVariant(variant)
+	}
+	derivationType = simplecontent.NormalizeDerivationType(derivationType)
+
+	metadata := getMap(params, "metadata")
+	if err := checkReservedKeys(nil, metadata); err != nil {
+		return nil, err
+	}
+
+	parent, err := s.service.GetContent(ctx, parentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	if err := s.authorizeAccess(ctx, parent.OwnerID, parent.TenantID); err != nil {
+		return nil, err
+	}
+
+	reader, err := s.decodeData(params["data"])
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, mcperrors.NewValidationError("data", err)
+	}
+
+	// Derived content belongs to the parent's owner and tenant
+	derived, err := s.service.UploadDerivedContent(ctx, simplecontent.UploadDerivedContentRequest{
+		ParentID:           parentID,
+		OwnerID:            parent.OwnerID,
+		TenantID:           parent.TenantID,
+		DerivationType:     derivationType,
+		Variant:            variant,
+		StorageBackendName: getStringOr(params, "storage_backend", "default"),
+		Reader:             bytes.NewReader(data),
+		FileName:           getStringOr(params, "file_name", ""),
+		FileSize:           int64(len(data)),
+		Tags:               getStringSlice(params, "tags"),
+		Metadata:           metadata,
+	})
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+
+	// The derived-content API leaves name and document type empty
+	derived.Name = getStringOr(params, "name", parent.Name+" ("+variant+")")
+	derived.Description = getStringOr(params, "description", "")
+	derived.DocumentType = getStringOr(params, "document_type", "application/octet-stream")
+	if err := s.service.UpdateContent(ctx, simplecontent.UpdateContentRequest{Content: derived}); err != nil {
+		return nil, s.mapError(err)
+	}
+
+	// Store the MIME type alongside file name, size, tags and metadata
+	if err := s.saveContentMetadata(ctx, &simplecontent.ContentMetadata{
+		ContentID: derived.ID,
+		MimeType:  derived.DocumentType,
+		FileName:  getStringOr(params, "file_name", ""),
+		FileSize:  int64(len(data)),
+	}, getStringSlice(params, "tags"), metadata); err != nil {
+		return nil, s.mapError(err)
+	}
+	s.indexBody(ctx, derived.ID)
+
+	result := map[string]interface{}{
+		"id":              derived.ID.String(),
+		"parent_id":       parentID.String(),
+		"derivation_type": derivationType,
+		"variant":         variant,
+		"status":          derived.Status,
+		"created_at":      derived.CreatedAt,
+	}
+	if details, err := s.service.GetContentDetails(ctx, derived.ID); err == nil {
+		result["download_url"] = details.Download
+	}
+
+	return newTextResult(formatJSON(result)), nil
+}
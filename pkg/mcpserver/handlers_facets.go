@@ -0,0 +1,190 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/admin"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/facets"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/search"
+)
+
+// handleContentFacets counts content by tag, MIME type, status, derivation
+// type, storage backend and creation date
+func (s *Server) handleContentFacets(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	// Collection records are summarized through list_collection
+	filter := facets.Filter{ExcludeDocumentTypes: []string{collectionDocumentType}}
+	var err error
+	filter.OwnerID, filter.TenantID, err = s.parseScope(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.RequireOwnerID && filter.OwnerID == uuid.Nil && filter.TenantID == uuid.Nil {
+		return nil, mcperrors.NewValidationError("owner_id", fmt.Errorf("owner_id or tenant_id is required"))
+	}
+
+	interval, err := facets.ParseInterval(getStringOr(params, "interval", string(facets.Month)))
+	if err != nil {
+		return nil, mcperrors.NewValidationError("interval", err)
+	}
+
+	filter.Statuses = getStringSlice(params, "status")
+	filter.Tags = getStringSlice(params, "tags")
+	filter.Metadata, err = parseMetadataFilters(params)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionID, inCollection, err := parseCollectionFilter(params)
+	if err != nil {
+		return nil, err
+	}
+	var members []*simplecontent.Content
+	if inCollection {
+		members, err = s.collectionContents(ctx, collectionID)
+		if err != nil {
+			return nil, err
+		}
+		filter.IDs = make([]uuid.UUID, len(members))
+		for i, member := range members {
+			filter.IDs[i] = member.ID
+		}
+	}
+
+	// A query string narrows the content down to its search results
+	if queryString := getStringOr(params, "query", ""); queryString != "" {
+		plan, err := search.Compile(queryString)
+		if err != nil {
+			return nil, mcperrors.NewValidationError("query", fmt.Errorf("%w (syntax: %s)", err, searchSyntaxURI))
+		}
+		query := &search.Query{
+			Plan:     plan,
+			OwnerID:  filter.OwnerID,
+			TenantID: filter.TenantID,
+			IDs:      filter.IDs,
+		}
+		index, err := s.populatedIndex(ctx, query, members, false)
+		if err != nil {
+			return nil, err
+		}
+		result, err := index.Search(ctx, query)
+		if err != nil {
+			return nil, mcperrors.NewInternalError(fmt.Errorf("search failed: %w", err))
+		}
+		filter.IDs = make([]uuid.UUID, len(result.Hits))
+		for i, hit := range result.Hits {
+			filter.IDs[i] = hit.ID
+		}
+	}
+
+	var result *facets.Facets
+	if s.config.FacetAggregator != nil {
+		result, err = s.config.FacetAggregator.Facets(ctx, filter, interval)
+		if err != nil {
+			return nil, mcperrors.NewInternalError(err)
+		}
+	} else {
+		result, err = s.countFacets(ctx, filter, interval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newTextResult(formatJSON(result)), nil
+}
+
+// countFacets computes facets content by content through the service
+func (s *Server) countFacets(ctx context.Context, filter facets.Filter, interval facets.Interval) (*facets.Facets, error) {
+	var contents []*simplecontent.Content
+	var err error
+	if s.adminService != nil {
+		filters := admin.ContentFilters{}
+		if filter.OwnerID != uuid.Nil {
+			filters.OwnerID = &filter.OwnerID
+		}
+		if filter.TenantID != uuid.Nil {
+			filters.TenantID = &filter.TenantID
+		}
+		contents, err = s.listAllContents(ctx, filters)
+	} else if filter.OwnerID != uuid.Nil {
+		contents, err = s.service.ListContent(ctx, simplecontent.ListContentRequest{
+			OwnerID:  filter.OwnerID,
+			TenantID: filter.TenantID,
+		})
+	} else {
+		return nil, mcperrors.NewValidationError("owner_id", fmt.Errorf("owner_id is required without an admin service"))
+	}
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+
+	var ids map[uuid.UUID]bool
+	if filter.IDs != nil {
+		ids = make(map[uuid.UUID]bool, len(filter.IDs))
+		for _, id := range filter.IDs {
+			ids[id] = true
+		}
+	}
+
+	result := facets.New(interval)
+	for _, content := range contents {
+		if content.DeletedAt != nil || slices.Contains(filter.ExcludeDocumentTypes, content.DocumentType) ||
+			(ids != nil && !ids[content.ID]) ||
+			(len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, content.Status)) {
+			continue
+		}
+
+		metadata, err := s.loadContentMetadata(ctx, content.ID)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		if !containsAll(metadata.Tags, filter.Tags) || !metafilter.Match(metadata.Metadata, filter.Metadata) {
+			continue
+		}
+
+		objects, err := s.service.GetObjectsByContentID(ctx, content.ID)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		var backends []string
+		for _, object := range objects {
+			if object.DeletedAt == nil {
+				backends = append(backends, object.StorageBackendName)
+			}
+		}
+
+		result.Add(facets.Item{
+			DocumentType:    content.DocumentType,
+			Status:          content.Status,
+			DerivationType:  content.DerivationType,
+			CreatedAt:       content.CreatedAt,
+			Tags:            metadata.Tags,
+			Size:            metadata.FileSize,
+			StorageBackends: backends,
+		})
+	}
+	return result, nil
+}
+
+// containsAll reports whether values contains every one of wanted
+func containsAll(values, wanted []string) bool {
+	for _, w := range wanted {
+		if !slices.Contains(values, w) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/jobs"
+)
+
+// handleRequestDerivation queues a derivation job for a content
+func (s *Server) handleRequestDerivation(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	contentID, err := parseUUID(params["content_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("content_id", err)
+	}
+
+	derivationType := strings.ToLower(strings.TrimSpace(getStringOr(params, "derivation_type", "")))
+	if derivationType == "" {
+		return nil, mcperrors.NewValidationError("derivation_type", fmt.Errorf("required"))
+	}
+
+	jobParams := getMap(params, "params")
+	if _, ok := params["params"]; ok && jobParams == nil {
+		return nil, mcperrors.NewValidationError("params", fmt.Errorf("must be an object"))
+	}
+
+	maxAttempts := getIntOr(params, "max_attempts", 0)
+	attemptsLimit := max(1, s.config.JobMaxAttempts, s.config.JobAttemptsLimit)
+	if _, ok := params["max_attempts"]; ok && (maxAttempts < 1 || maxAttempts > attemptsLimit) {
+		return nil, mcperrors.NewValidationError("max_attempts", fmt.Errorf("must be between 1 and %d", attemptsLimit))
+	}
+
+	content, err := s.service.GetContent(ctx, contentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	if err := s.authorizeAccess(ctx, content.OwnerID, content.TenantID); err != nil {
+		return nil, err
+	}
+	if isCollection(content) {
+		return nil, mcperrors.NewValidationError("content_id", fmt.Errorf("collections cannot be processed"))
+	}
+
+	job, err := s.enqueueDerivation(ctx, content, derivationType, jobParams, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTextResult(formatJSON(job)), nil
+}
+
+// handleGetJob returns a derivation job
+func (s *Server) handleGetJob(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	jobID, err := parseUUID(params["job_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("job_id", err)
+	}
+
+	job, err := s.jobQueue.Get(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, jobs.ErrNotFound) {
+			return nil, mcperrors.NewNotFoundError("job", jobID.String())
+		}
+		return nil, mcperrors.NewInternalError(err)
+	}
+	if err := s.authorizeAccess(ctx, job.OwnerID, job.TenantID); err != nil {
+		return nil, err
+	}
+
+	return newTextResult(formatJSON(job)), nil
+}
+
+// handleListJobs lists derivation jobs of a content or an owner
+func (s *Server) handleListJobs(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	limit := getIntOr(params, "limit", s.config.DefaultPageSize)
+	offset := getIntOr(params, "offset", 0)
+	if limit <= 0 || limit > s.config.MaxPageSize {
+		return nil, mcperrors.NewValidationError("limit", fmt.Errorf("must be between 1 and %d", s.config.MaxPageSize))
+	}
+	if offset < 0 {
+		return nil, mcperrors.NewValidationError("offset", fmt.Errorf("cannot be negative"))
+	}
+
+	filter := jobs.Filter{Limit: limit, Offset: offset}
+
+	if status := getStringOr(params, "status", ""); status != "" {
+		filter.Status = jobs.Status(status)
+		if !filter.Status.Valid() {
+			return nil, mcperrors.NewValidationError("status", fmt.Errorf("unknown job status %q", status))
+		}
+	}
+
+	for _, field := range []string{"content_id", "owner_id", "tenant_id"} {
+		if _, ok := params[field]; !ok {
+			continue
+		}
+		id, err := parseUUID(params[field])
+		if err != nil {
+			return nil, mcperrors.NewValidationError(field, err)
+		}
+		switch field {
+		case "content_id":
+			filter.ContentID = id
+		case "owner_id":
+			filter.OwnerID = id
+		case "tenant_id":
+			filter.TenantID = id
+		}
+	}
+
+	if filter.ContentID != uuid.Nil {
+		content, err := s.service.GetContent(ctx, filter.ContentID)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		if err := s.authorizeAccess(ctx, content.OwnerID, content.TenantID); err != nil {
+			return nil, err
+		}
+	} else {
+		if filter.OwnerID == uuid.Nil && s.config.RequireOwnerID {
+			return nil, mcperrors.NewValidationError("owner_id", fmt.Errorf("owner_id or content_id is required"))
+		}
+		if err := s.authorizeAccess(ctx, filter.OwnerID, filter.TenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	list, err := s.jobQueue.List(ctx, filter)
+	if err != nil {
+		return nil, mcperrors.NewInternalError(err)
+	}
+
+	return newTextResult(formatJSON(map[string]interface{}{
+		"items":  list,
+		"count":  len(list), // Jobs on this page
+		"limit":  limit,
+		"offset": offset,
+	})), nil
+}
@@ -4,10 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
-	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/cursor"
 	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/admin"
 )
 
 // handleGetContentStatus checks content processing status
@@ -30,15 +36,28 @@ func (s *Server) handleGetContentStatus(ctx context.Context, req *mcp.CallToolRe
 		return nil, s.mapError(err)
 	}
 
+	result, _, err := s.contentStatus(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTextResult(formatJSON(result)), nil
+}
+
+// contentStatus builds the get_content_status payload of a content. It also
+// returns the derived content it looked at.
+func (s *Server) contentStatus(ctx context.Context, content *simplecontent.Content) (map[string]interface{}, []*simplecontent.DerivedContent, error) {
 	// Check for derived content (thumbnails, previews)
 	hasThumbnails := false
 	hasPreviews := false
+	derivationTypes := map[string]int{}
 
 	derivedList, err := s.service.ListDerivedContent(ctx,
-		simplecontent.WithParentID(contentID),
+		simplecontent.WithParentID(content.ID),
 	)
 	if err == nil && len(derivedList) > 0 {
 		for _, derived := range derivedList {
+			derivationTypes[derived.DerivationType]++
 			if derived.DerivationType == "thumbnail" {
 				hasThumbnails = true
 			} else if derived.DerivationType == "preview" {
@@ -47,23 +66,43 @@ func (s *Server) handleGetContentStatus(ctx context.Context, req *mcp.CallToolRe
 		}
 	}
 
-	// Determine ready state
-	ready := content.Status == "uploaded" || content.Status == "processed"
+	// Processing is complete once no derivation job is queued or running
+	pendingJobs, err := s.pendingJobs(ctx, content.ID)
+	if err != nil {
+		return nil, nil, mcperrors.NewInternalError(err)
+	}
+	processingComplete := pendingJobs == 0 && content.Status != "processing"
+
+	// Compare with the derivations the policy expects for the MIME type
+	derivations, err := s.derivationReport(ctx, content, derivedList)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Content is ready once uploaded or processed and every derivation the
+	// policy expects for it is present
+	ready := (content.Status == "uploaded" || content.Status == "processed") && derivations["complete"] == true
 
 	// Format result
 	result := map[string]interface{}{
-		"id":             content.ID.String(),
-		"status":         content.Status,
-		"ready":          ready,
-		"has_thumbnails": hasThumbnails,
-		"has_previews":   hasPreviews,
-		"updated_at":     content.UpdatedAt,
+		"id":                  content.ID.String(),
+		"status":              content.Status,
+		"ready":               ready,
+		"has_thumbnails":      hasThumbnails,
+		"has_previews":        hasPreviews,
+		"derived_count":       len(derivedList),
+		"derived_types":       derivationTypes,
+		"pending_jobs":        pendingJobs,
+		"processing_complete": processingComplete,
+		"derivations":         derivations,
+		"updated_at":          content.UpdatedAt,
 	}
 
-	return newTextResult(formatJSON(result)), nil
+	return result, derivedList, nil
 }
 
-// handleListByStatus lists content by lifecycle status
+// handleListByStatus lists content by lifecycle status, newest first, in
+// pages continued by cursor, or only counts it with count_only
 func (s *Server) handleListByStatus(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Unmarshal arguments
 	var params map[string]interface{}
@@ -82,38 +121,129 @@ func (s *Server) handleListByStatus(ctx context.Context, req *mcp.CallToolReques
 	if err != nil {
 		return nil, mcperrors.NewValidationError("status", err)
 	}
+	statusStr = string(status)
 
-	// Call service
-	contentList, err := s.service.GetContentByStatus(ctx, status)
+	ranges, err := parseRangeFilter(params, time.Now())
 	if err != nil {
-		return nil, s.mapError(err)
+		return nil, err
+	}
+
+	scope := metafilter.Scope{MinSize: ranges.minSize, MaxSize: ranges.maxSize}
+	scope.OwnerID, scope.TenantID, err = s.parseScope(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.RequireOwnerID && scope.OwnerID == uuid.Nil && scope.TenantID == uuid.Nil {
+		return nil, mcperrors.NewValidationError("owner_id", fmt.Errorf("owner_id or tenant_id is required"))
+	}
+	if s.adminService == nil && scope.OwnerID == uuid.Nil {
+		return nil, mcperrors.NewValidationError("owner_id", fmt.Errorf("owner_id is required without an admin service"))
+	}
+
+	countOnly := getBoolOr(params, "count_only", false)
+	limit := getIntOr(params, "limit", 100)
+	if !countOnly && (limit <= 0 || limit > s.config.MaxPageSize) {
+		return nil, mcperrors.NewValidationError("limit", fmt.Errorf("must be between 1 and %d", s.config.MaxPageSize))
 	}
+	after, err := s.parseCursor("list_by_status", params)
+	if err != nil {
+		return nil, err
+	}
+	order := cursor.Order{By: cursor.ByCreatedAt}
 
-	// Optional: filter by owner_id
-	if ownerIDRaw, ok := params["owner_id"]; ok {
-		if ownerID, err := parseUUID(ownerIDRaw); err == nil {
-			filtered := make([]*simplecontent.Content, 0)
-			for _, c := range contentList {
-				if c.OwnerID == ownerID {
-					filtered = append(filtered, c)
-				}
+	// A MetadataQuerier that lists content filters, counts and pages in the
+	// database. Otherwise the admin service filters by status, owner, tenant
+	// and time range in the repository, where it also counts and pages. Size
+	// bounds then need the metadata of every match, as does listing an
+	// owner's content through the service.
+	lister, dbListed := s.config.MetadataQuerier.(metafilter.Lister)
+	var filters admin.ContentFilters
+	var contents []*simplecontent.Content
+	var more bool
+	total := -1
+	if s.adminService != nil {
+		filters.Status = &statusStr
+		if scope.OwnerID != uuid.Nil {
+			filters.OwnerID = &scope.OwnerID
+		}
+		if scope.TenantID != uuid.Nil {
+			filters.TenantID = &scope.TenantID
+		}
+		ranges.adminFilters(&filters)
+	}
+	switch {
+	case dbListed:
+		listing := &metafilter.Listing{
+			Scope:                scope,
+			Status:               statusStr,
+			ExcludeDocumentTypes: []string{collectionDocumentType},
+			Order:                order,
+			After:                after,
+			Limit:                limit,
+		}
+		ranges.listingFilters(listing)
+		if countOnly {
+			total, err = lister.Count(ctx, listing)
+			if err != nil {
+				return nil, mcperrors.NewInternalError(fmt.Errorf("content count failed: %w", err))
 			}
-			contentList = filtered
+		} else {
+			contents, _, more, err = listPage(ctx, lister, listing)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	case s.adminService != nil && !ranges.hasSize() && countOnly:
+		total, err = s.countWithoutCollections(ctx, filters)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+
+	case s.adminService != nil && !ranges.hasSize():
+		contents, more, err = s.adminPage(ctx, filters, order, after, 0, limit)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+
+	default:
+		if s.adminService != nil {
+			contents, err = s.listAllContents(ctx, filters)
+		} else {
+			contents, err = s.service.ListContent(ctx, simplecontent.ListContentRequest{
+				OwnerID:  scope.OwnerID,
+				TenantID: scope.TenantID,
+			})
+		}
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		contents = slices.DeleteFunc(withoutCollections(contents), func(c *simplecontent.Content) bool {
+			return c.Status != statusStr || !ranges.matchesTimes(c.CreatedAt, c.UpdatedAt)
+		})
+		contents, err = s.filterByMetadata(ctx, contents, scope, nil)
+		if err != nil {
+			return nil, err
 		}
+		total = len(contents)
+		cursor.Sort(contents, contentPosition, order)
+		contents, more = cursor.Page(contents, contentPosition, order, after, limit)
 	}
 
-	// Apply limit
-	limit := getIntOr(params, "limit", 100)
-	if len(contentList) > limit {
-		contentList = contentList[:limit]
+	if countOnly {
+		return newTextResult(formatJSON(map[string]interface{}{
+			"status": statusStr,
+			"total":  total,
+		})), nil
 	}
 
 	// Format result
-	items := make([]map[string]interface{}, len(contentList))
-	for i, content := range contentList {
+	items := make([]map[string]interface{}, len(contents))
+	for i, content := range contents {
 		items[i] = map[string]interface{}{
 			"id":         content.ID.String(),
 			"owner_id":   content.OwnerID.String(),
+			"tenant_id":  content.TenantID.String(),
 			"name":       content.Name,
 			"status":     content.Status,
 			"created_at": content.CreatedAt,
@@ -125,7 +255,30 @@ func (s *Server) handleListByStatus(ctx context.Context, req *mcp.CallToolReques
 		"status": statusStr,
 		"items":  items,
 		"count":  len(items),
+		"limit":  limit,
+	}
+	if total >= 0 {
+		result["total"] = total
+	}
+	if more && len(contents) > 0 {
+		result["next_cursor"] = s.nextCursor("list_by_status", params, order, contentPosition(contents[len(contents)-1]))
 	}
 
 	return newTextResult(formatJSON(result)), nil
 }
+
+// countWithoutCollections counts the content matching filters in the
+// repository, leaving out collection records
+func (s *Server) countWithoutCollections(ctx context.Context, filters admin.ContentFilters) (int, error) {
+	all, err := s.adminService.CountContents(ctx, admin.CountRequest{Filters: filters})
+	if err != nil {
+		return 0, err
+	}
+	documentType := collectionDocumentType
+	filters.DocumentType = &documentType
+	collections, err := s.adminService.CountContents(ctx, admin.CountRequest{Filters: filters})
+	if err != nil {
+		return 0, err
+	}
+	return int(all.Count - collections.Count), nil
+}
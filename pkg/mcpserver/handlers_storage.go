@@ -0,0 +1,406 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/admin"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/cursor"
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+)
+
+// objectUpdater is implemented by services that expose object records
+// (simplecontent's default service implements StorageService)
+type objectUpdater interface {
+	UpdateObject(ctx context.Context, object *simplecontent.Object) error
+}
+
+// objectMigration reports the outcome of moving one object between backends
+type objectMigration struct {
+	ContentID     string `json:"content_id"`
+	ObjectID      string `json:"object_id"`
+	SourceBackend string `json:"source_backend"`
+	TargetBackend string `json:"target_backend"`
+	Status        string `json:"status"` // migrated, would_migrate, skipped, failed
+	Size          int64  `json:"size,omitempty"`
+	Checksum      string `json:"checksum,omitempty"`
+	Warning       string `json:"warning,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// handleMigrateStorage moves content blobs from one storage backend to another
+func (s *Server) handleMigrateStorage(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Unmarshal arguments
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	targetBackend := getStringOr(params, "target_backend", "")
+	if targetBackend == "" {
+		return nil, mcperrors.NewValidationError("target_backend", fmt.Errorf("required"))
+	}
+	if _, err := s.service.GetBackend(targetBackend); err != nil {
+		return nil, mcperrors.NewValidationError("target_backend", err)
+	}
+
+	sourceBackend := getStringOr(params, "source_backend", "")
+	if sourceBackend != "" {
+		if _, err := s.service.GetBackend(sourceBackend); err != nil {
+			return nil, mcperrors.NewValidationError("source_backend", err)
+		}
+	}
+	if sourceBackend == targetBackend {
+		return nil, mcperrors.NewValidationError("target_backend", fmt.Errorf("must differ from source_backend"))
+	}
+
+	updater, ok := s.service.(objectUpdater)
+	if !ok {
+		return nil, mcperrors.NewInternalError(fmt.Errorf("service does not support updating objects"))
+	}
+
+	dryRun := getBoolOr(params, "dry_run", false)
+
+	contentIDs, next, err := s.selectMigrationContents(ctx, params, sourceBackend, targetBackend)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]objectMigration, 0, len(contentIDs))
+	migrated, failed := 0, 0
+	for _, contentID := range contentIDs {
+		objects, err := s.service.GetObjectsByContentID(ctx, contentID)
+		if err != nil {
+			results = append(results, objectMigration{
+				ContentID:     contentID.String(),
+				TargetBackend: targetBackend,
+				Status:        "failed",
+				Error:         err.Error(),
+			})
+			failed++
+			continue
+		}
+
+		for _, object := range objects {
+			if object.DeletedAt != nil {
+				continue
+			}
+
+			result := objectMigration{
+				ContentID:     contentID.String(),
+				ObjectID:      object.ID.String(),
+				SourceBackend: object.StorageBackendName,
+				TargetBackend: targetBackend,
+			}
+
+			switch {
+			case object.StorageBackendName == targetBackend,
+				sourceBackend != "" && object.StorageBackendName != sourceBackend:
+				result.Status = "skipped"
+			case dryRun:
+				result.Status = "would_migrate"
+			default:
+				if err := s.migrateObject(ctx, updater, object, targetBackend, &result); err != nil {
+					result.Status = "failed"
+					result.Error = err.Error()
+					failed++
+				} else {
+					result.Status = "migrated"
+					migrated++
+				}
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	result := map[string]interface{}{
+		"dry_run":        dryRun,
+		"target_backend": targetBackend,
+		"contents":       len(contentIDs),
+		"migrated":       migrated,
+		"failed":         failed,
+		"objects":        results,
+	}
+	if next != "" {
+		result["next_cursor"] = next
+	}
+	return newTextResult(formatJSON(result)), nil
+}
+
+// migrationScanPages is the number of pages of limit contents a
+// migrate_storage call examines at most while looking for contents to migrate
+const migrationScanPages = 10
+
+// selectMigrationContents resolves the content IDs addressed by a
+// migrate_storage call. With filters it pages through the matching contents
+// oldest first, keeping those with blobs to move, and returns the cursor
+// continuing after the last content it examined ("" when none are left).
+func (s *Server) selectMigrationContents(ctx context.Context, params map[string]interface{}, sourceBackend, targetBackend string) ([]uuid.UUID, string, error) {
+	includeDerived := getBoolOr(params, "include_derived", false)
+
+	if v, ok := params["content_id"]; ok {
+		contentID, err := parseUUID(v)
+		if err != nil {
+			return nil, "", mcperrors.NewValidationError("content_id", err)
+		}
+		content, err := s.service.GetContent(ctx, contentID)
+		if err != nil {
+			return nil, "", s.mapError(err)
+		}
+		if err := s.authorizeAccess(ctx, content.OwnerID, content.TenantID); err != nil {
+			return nil, "", err
+		}
+
+		ids := []uuid.UUID{contentID}
+		if includeDerived {
+			nodes, err := s.collectDerivedTree(ctx, contentID)
+			if err != nil {
+				return nil, "", s.mapError(err)
+			}
+			for _, node := range nodes {
+				ids = append(ids, node.ContentID)
+			}
+		}
+		return ids, "", nil
+	}
+
+	limit := getIntOr(params, "limit", s.config.MaxBatchSize)
+	if limit <= 0 || limit > s.config.MaxPageSize {
+		return nil, "", mcperrors.NewValidationError("limit", fmt.Errorf("must be between 1 and %d", s.config.MaxPageSize))
+	}
+
+	var ownerID, tenantID uuid.UUID
+	if v, ok := params["owner_id"]; ok {
+		id, err := parseUUID(v)
+		if err != nil {
+			return nil, "", mcperrors.NewValidationError("owner_id", err)
+		}
+		ownerID = id
+	}
+	if v, ok := params["tenant_id"]; ok {
+		id, err := parseUUID(v)
+		if err != nil {
+			return nil, "", mcperrors.NewValidationError("tenant_id", err)
+		}
+		tenantID = id
+	}
+	status := getStringOr(params, "status", "")
+
+	after, err := s.parseCursor("migrate_storage", params)
+	if err != nil {
+		return nil, "", err
+	}
+	order := cursor.Order{Ascending: true}
+
+	// fetch returns the page of limit contents after a position and whether
+	// more contents follow it
+	var fetch func(after *cursor.Position) ([]*simplecontent.Content, bool, error)
+	if s.adminService != nil {
+		var filters admin.ContentFilters
+		if ownerID != uuid.Nil {
+			filters.OwnerID = &ownerID
+		}
+		if tenantID != uuid.Nil {
+			filters.TenantID = &tenantID
+		}
+		if status != "" {
+			filters.Status = &status
+		}
+		fetch = func(after *cursor.Position) ([]*simplecontent.Content, bool, error) {
+			return s.adminPage(ctx, filters, order, after, 0, limit)
+		}
+	} else {
+		// Without an admin service only a single owner's content can be listed
+		if ownerID == uuid.Nil {
+			return nil, "", mcperrors.NewValidationError("content_id", fmt.Errorf("content_id or owner_id is required"))
+		}
+
+		listed, err := s.service.ListContent(ctx, simplecontent.ListContentRequest{
+			OwnerID:  ownerID,
+			TenantID: tenantID,
+		})
+		if err != nil {
+			return nil, "", s.mapError(err)
+		}
+		listed = slices.DeleteFunc(listed, func(content *simplecontent.Content) bool {
+			return isCollection(content) || (status != "" && string(content.Status) != status)
+		})
+		cursor.Sort(listed, contentPosition, order)
+		fetch = func(after *cursor.Position) ([]*simplecontent.Content, bool, error) {
+			page, more := cursor.Page(listed, contentPosition, order, after, limit)
+			return page, more, nil
+		}
+	}
+
+	// Already migrated contents are skipped rather than counted against the
+	// limit, so repeated calls with next_cursor always make progress
+	var ids []uuid.UUID
+	for pages := 0; pages < migrationScanPages; pages++ {
+		page, more, err := fetch(after)
+		if err != nil {
+			return nil, "", s.mapError(err)
+		}
+		for i, content := range page {
+			position := contentPosition(content)
+			after = &position
+
+			pending, err := s.hasPendingObjects(ctx, content, sourceBackend, targetBackend)
+			if err != nil {
+				return nil, "", err
+			}
+			if pending {
+				ids = append(ids, content.ID)
+			}
+			if len(ids) == limit {
+				if i == len(page)-1 && !more {
+					return ids, "", nil
+				}
+				return ids, s.nextCursor("migrate_storage", params, order, *after), nil
+			}
+		}
+		if !more {
+			return ids, "", nil
+		}
+	}
+	return ids, s.nextCursor("migrate_storage", params, order, *after), nil
+}
+
+// contentBackend returns the storage backend holding the data of a content:
+// the backend of its live object, or "default" when it has none
+func (s *Server) contentBackend(ctx context.Context, contentID uuid.UUID) (string, error) {
+	objects, err := s.service.GetObjectsByContentID(ctx, contentID)
+	if err != nil {
+		return "", err
+	}
+	for _, object := range objects {
+		if object.DeletedAt == nil {
+			return object.StorageBackendName, nil
+		}
+	}
+	return "default", nil
+}
+
+// hasPendingObjects reports whether content has live objects in
+// sourceBackend (any backend when empty) that are not yet in targetBackend
+func (s *Server) hasPendingObjects(ctx context.Context, content *simplecontent.Content, sourceBackend, targetBackend string) (bool, error) {
+	if content.DeletedAt != nil {
+		return false, nil
+	}
+	if err := s.authorizeAccess(ctx, content.OwnerID, content.TenantID); err != nil {
+		return false, err
+	}
+
+	objects, err := s.service.GetObjectsByContentID(ctx, content.ID)
+	if err != nil {
+		return false, s.mapError(err)
+	}
+	for _, object := range objects {
+		if object.DeletedAt == nil && object.StorageBackendName != targetBackend &&
+			(sourceBackend == "" || object.StorageBackendName == sourceBackend) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// migrateObject copies an object's blob to targetBackend, verifies the copy,
+// repoints the object record and only then removes the source blob
+func (s *Server) migrateObject(ctx context.Context, updater objectUpdater, object *simplecontent.Object, targetBackend string, result *objectMigration) error {
+	source, err := s.service.GetBackend(object.StorageBackendName)
+	if err != nil {
+		return fmt.Errorf("source backend: %w", err)
+	}
+	target, err := s.service.GetBackend(targetBackend)
+	if err != nil {
+		return fmt.Errorf("target backend: %w", err)
+	}
+
+	data, sourceSum, err := readBlob(ctx, source, object.ObjectKey)
+	if err != nil {
+		return fmt.Errorf("failed to read source blob: %w", err)
+	}
+	result.Size = int64(len(data))
+	result.Checksum = sourceSum
+
+	// Never spread a source blob that no longer matches its recorded checksum
+	expectedSum := s.storedChecksum(ctx, object.ContentID)
+	if expectedSum != "" && expectedSum != sourceSum {
+		return fmt.Errorf("checksum mismatch: stored %s, source %s", expectedSum, sourceSum)
+	}
+
+	if err := target.Upload(ctx, object.ObjectKey, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to write target blob: %w", err)
+	}
+
+	// Read the copy back so a truncated or corrupted write is never committed
+	_, targetSum, err := readBlob(ctx, target, object.ObjectKey)
+	if err != nil || targetSum != sourceSum {
+		if delErr := target.Delete(ctx, object.ObjectKey); delErr != nil {
+			result.Warning = fmt.Sprintf("failed to remove partial copy: %v", delErr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to verify target blob: %w", err)
+		}
+		return fmt.Errorf("checksum mismatch: source %s, target %s", sourceSum, targetSum)
+	}
+
+	sourceBackend := object.StorageBackendName
+	object.StorageBackendName = targetBackend
+	if err := updater.UpdateObject(ctx, object); err != nil {
+		object.StorageBackendName = sourceBackend
+		if delErr := target.Delete(ctx, object.ObjectKey); delErr != nil {
+			result.Warning = fmt.Sprintf("failed to remove copy: %v", delErr)
+		}
+		return fmt.Errorf("failed to update object: %w", err)
+	}
+
+	// The record now points at the target; a leftover source blob is only wasted space
+	if err := source.Delete(ctx, object.ObjectKey); err != nil {
+		result.Warning = fmt.Sprintf("failed to remove source blob: %v", err)
+	}
+
+	return nil
+}
+
+// storedChecksum returns the lower-case hex SHA-256 checksum recorded in the
+// metadata of a content, or "" when none is recorded
+func (s *Server) storedChecksum(ctx context.Context, contentID uuid.UUID) string {
+	metadata, err := s.service.GetContentMetadata(ctx, contentID)
+	if err != nil || metadata.Checksum == "" {
+		return ""
+	}
+	switch strings.ToLower(strings.ReplaceAll(metadata.ChecksumAlgorithm, "-", "")) {
+	case "", "sha256":
+		return strings.ToLower(metadata.Checksum)
+	}
+	return ""
+}
+
+// readBlob downloads a blob and returns its data with a hex SHA-256 checksum
+func readBlob(ctx context.Context, store simplecontent.BlobStore, objectKey string) ([]byte, string, error) {
+	reader, err := store.Download(ctx, objectKey)
+	if err != nil {
+		return nil, "", err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return data, hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,187 @@
+package mcpserver
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+)
+
+const (
+	// defaultWaitTimeout is the timeout of wait_for_content when none is given
+	defaultWaitTimeout = 30 * time.Second
+	// defaultMaxWaitTimeout is used when Config.MaxWaitTimeout is 0
+	defaultMaxWaitTimeout = 5 * time.Minute
+	// defaultWaitPollInterval is used when Config.WaitPollInterval is 0
+	defaultWaitPollInterval = 500 * time.Millisecond
+)
+
+// handleWaitForContent blocks until a content reaches a status, until the
+// requested derived variants are processed, or until the timeout expires.
+// Without a status or variants it waits for the content to be ready with no
+// derivation job pending. Progress notifications are sent while waiting when
+// the request carries a progress token.
+func (s *Server) handleWaitForContent(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	contentID, err := parseUUID(params["content_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("content_id", err)
+	}
+
+	var target simplecontent.ContentStatus
+	if statusStr := getStringOr(params, "status", ""); statusStr != "" {
+		if target, err = simplecontent.ParseContentStatus(statusStr); err != nil {
+			return nil, mcperrors.NewValidationError("status", err)
+		}
+	}
+
+	variants := getStringSlice(params, "variants")
+	if _, ok := params["variants"]; ok && len(variants) == 0 {
+		return nil, mcperrors.NewValidationError("variants", fmt.Errorf("must be a non-empty array of variant names"))
+	}
+
+	maxTimeout := cmp.Or(s.config.MaxWaitTimeout, defaultMaxWaitTimeout)
+	timeout := min(defaultWaitTimeout, maxTimeout)
+	if _, ok := params["timeout_seconds"]; ok {
+		seconds, ok := params["timeout_seconds"].(float64)
+		if !ok || seconds <= 0 || time.Duration(seconds*float64(time.Second)) > maxTimeout {
+			return nil, mcperrors.NewValidationError("timeout_seconds",
+				fmt.Errorf("must be greater than 0 and at most %g", maxTimeout.Seconds()))
+		}
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	content, err := s.service.GetContent(ctx, contentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	if err := s.authorizeAccess(ctx, content.OwnerID, content.TenantID); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+	ticker := time.NewTicker(cmp.Or(s.config.WaitPollInterval, defaultWaitPollInterval))
+	defer ticker.Stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	deleted := false
+	for {
+		var result map[string]interface{}
+		var derivedList []*simplecontent.DerivedContent
+		if deleted {
+			// Deleted content has no status to look up any more
+			result = map[string]interface{}{
+				"id":                  contentID.String(),
+				"status":              content.Status,
+				"ready":               false,
+				"pending_jobs":        0,
+				"processing_complete": true,
+			}
+		} else if result, derivedList, err = s.contentStatus(ctx, content); err != nil {
+			return nil, err
+		}
+		missing := missingVariants(derivedList, variants)
+
+		status := simplecontent.ContentStatus(content.Status)
+		satisfied := len(missing) == 0
+		switch {
+		case target != "":
+			satisfied = satisfied && status == target
+		case len(variants) == 0:
+			satisfied = result["ready"] == true && result["processing_complete"] == true
+		}
+
+		// Failed and deleted content will not get any further
+		stuck := !satisfied && (deleted || (status != target &&
+			(status == simplecontent.ContentStatusFailed || status == simplecontent.ContentStatusDeleted)))
+		timedOut := !satisfied && !stuck && !time.Now().Before(deadline)
+
+		if satisfied || stuck || timedOut {
+			result["satisfied"] = satisfied
+			result["timed_out"] = timedOut
+			result["waited_ms"] = time.Since(start).Milliseconds()
+			if len(variants) > 0 {
+				result["missing_variants"] = missing
+			}
+			return newTextResult(formatJSON(result)), nil
+		}
+
+		s.notifyWaitProgress(ctx, req, time.Since(start), timeout, result, missing)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		case <-timer.C:
+		}
+
+		next, err := s.service.GetContent(ctx, contentID)
+		switch {
+		case mcperrors.IsNotFound(err):
+			// The service stops returning content once it is deleted
+			gone := *content
+			gone.Status = string(simplecontent.ContentStatusDeleted)
+			content, deleted = &gone, true
+		case err != nil:
+			return nil, s.mapError(err)
+		default:
+			content = next
+		}
+	}
+}
+
+// missingVariants returns the variants that have no processed derived content
+func missingVariants(derivedList []*simplecontent.DerivedContent, variants []string) []string {
+	processed := make(map[string]bool, len(derivedList))
+	for _, derived := range derivedList {
+		if derived.Status == string(simplecontent.ContentStatusProcessed) {
+			processed[derived.Variant] = true
+		}
+	}
+
+	missing := []string{}
+	for _, variant := range variants {
+		if !processed[variant] {
+			missing = append(missing, variant)
+		}
+	}
+	return missing
+}
+
+// notifyWaitProgress reports the state of a wait_for_content call to the
+// client, using the elapsed time as progress towards the timeout. Nothing is
+// sent unless the request carries a progress token.
+func (s *Server) notifyWaitProgress(ctx context.Context, req *mcp.CallToolRequest, elapsed, timeout time.Duration, status map[string]interface{}, missing []string) {
+	token := req.Params.GetProgressToken()
+	if token == nil || req.Session == nil {
+		return
+	}
+
+	message := fmt.Sprintf("status %v, %v pending job(s)", status["status"], status["pending_jobs"])
+	if len(missing) > 0 {
+		message += ", waiting for " + strings.Join(missing, ", ")
+	}
+
+	if err := req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+		ProgressToken: token,
+		Message:       message,
+		Progress:      elapsed.Seconds(),
+		Total:         timeout.Seconds(),
+	}); err != nil {
+		log.Printf("Failed to send progress notification: %v", err)
+	}
+}
@@ -0,0 +1,389 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/extract"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/jobs"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/thumbnail"
+)
+
+// ProcessJobs runs the derivation jobs that are ready, one after another,
+// and returns how many ran. Serve runs jobs on JobWorkers workers; this is
+// for embedders that don't call Serve or run with JobWorkers set to 0.
+func (s *Server) ProcessJobs(ctx context.Context) (int, error) {
+	return s.jobPool.Drain(ctx)
+}
+
+// enqueueDerivation queues a job deriving derivationType from content. It
+// fails with a validation error when no processor handles the content's
+// document type or the processor rejects params.
+func (s *Server) enqueueDerivation(ctx context.Context, content *simplecontent.Content, derivationType string, params map[string]interface{}, maxAttempts int) (*jobs.Job, error) {
+	processor := s.processors.Lookup(content.DocumentType, derivationType)
+	if processor == nil {
+		return nil, mcperrors.NewValidationError("derivation_type",
+			fmt.Errorf("no processor for %s derivation of %s content", derivationType, content.DocumentType))
+	}
+
+	if maxAttempts <= 0 {
+		maxAttempts = max(1, s.config.JobMaxAttempts)
+	}
+
+	now := time.Now().UTC()
+	job := &jobs.Job{
+		ID:             uuid.New(),
+		ContentID:      content.ID,
+		OwnerID:        content.OwnerID,
+		TenantID:       content.TenantID,
+		DerivationType: derivationType,
+		Processor:      processor.Name(),
+		Params:         params,
+		Status:         jobs.StatusQueued,
+		MaxAttempts:    maxAttempts,
+		RunAt:          now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	// Reject bad parameters now rather than in a worker
+	if _, err := processor.Variants(job); err != nil {
+		return nil, mcperrors.NewValidationError("params", err)
+	}
+
+	if err := s.jobQueue.Enqueue(ctx, job); err != nil {
+		return nil, mcperrors.NewInternalError(err)
+	}
+	s.jobPool.Notify()
+	return job, nil
+}
+
+// autoDerive queues the derivations that run for every upload
+func (s *Server) autoDerive(ctx context.Context, content *simplecontent.Content) {
+	for _, derivationType := range s.autoDerivations(content) {
+		if _, err := s.enqueueDerivation(ctx, content, derivationType, nil, 0); err != nil {
+			log.Printf("Failed to queue %s derivation for content %s: %v", derivationType, content.ID, err)
+		}
+	}
+}
+
+// autoDerivations returns the derivation types that run for every upload of
+// content: thumbnails of images when ThumbnailSizes is set and the text of
+// documents when ExtractText is set
+func (s *Server) autoDerivations(content *simplecontent.Content) []string {
+	var derivationTypes []string
+	if len(s.config.ThumbnailSizes) > 0 && thumbnail.Supported(content.DocumentType) {
+		derivationTypes = append(derivationTypes, thumbnail.DerivationType)
+	}
+	if s.config.ExtractText && extract.Supported(content.DocumentType) {
+		derivationTypes = append(derivationTypes, extract.DerivationType)
+	}
+	return derivationTypes
+}
+
+// reprocessDerivations queues the derivations of content again: the latest
+// job of each derivation type it had, with the same parameters, then the
+// derivations that run for every upload and the derivation types its
+// derivation policy expects, with default parameters. Derivations no
+// processor handles anymore are skipped.
+func (s *Server) reprocessDerivations(ctx context.Context, content *simplecontent.Content) ([]*jobs.Job, error) {
+	previous, err := s.jobQueue.List(ctx, jobs.Filter{ContentID: content.ID})
+	if err != nil {
+		return nil, mcperrors.NewInternalError(err)
+	}
+
+	// Jobs are listed newest first
+	params := map[string]map[string]interface{}{}
+	var derivationTypes []string
+	add := func(derivationType string, p map[string]interface{}) {
+		if _, ok := params[derivationType]; !ok && s.processors.Lookup(content.DocumentType, derivationType) != nil {
+			params[derivationType] = p
+			derivationTypes = append(derivationTypes, derivationType)
+		}
+	}
+	for _, job := range previous {
+		add(job.DerivationType, job.Params)
+	}
+	for _, derivationType := range s.autoDerivations(content) {
+		add(derivationType, nil)
+	}
+	// Policy entries naming variants rather than types have no processor of
+	// their own; the derivation types above produce them
+	for _, derivation := range s.config.DerivationPolicy.Expected(content.DocumentType) {
+		add(derivation, nil)
+	}
+
+	queued := make([]*jobs.Job, 0, len(derivationTypes))
+	for _, derivationType := range derivationTypes {
+		job, err := s.enqueueDerivation(ctx, content, derivationType, params[derivationType], 0)
+		if err != nil {
+			return queued, err
+		}
+		queued = append(queued, job)
+	}
+	return queued, nil
+}
+
+// pendingJobs returns the number of queued or running jobs of a content
+func (s *Server) pendingJobs(ctx context.Context, contentID uuid.UUID) (int, error) {
+	pending := 0
+	for _, status := range []jobs.Status{jobs.StatusQueued, jobs.StatusRunning} {
+		list, err := s.jobQueue.List(ctx, jobs.Filter{ContentID: contentID, Status: status})
+		if err != nil {
+			return 0, err
+		}
+		pending += len(list)
+	}
+	return pending, nil
+}
+
+// jobAttempt carries the state of one job attempt between its locked phases
+type jobAttempt struct {
+	job          *jobs.Job
+	actor        string
+	reason       string
+	parent       *simplecontent.Content
+	previous     simplecontent.ContentStatus // Status of the parent before it moved to processing
+	variants     []string
+	data         []byte
+	existing     []*simplecontent.DerivedContent // Derived content of the parent before the attempt
+	placeholders map[string]*simplecontent.Content
+}
+
+// runJob is the jobs.Handler of the server. The variants of the job are
+// created as processing placeholders, then the source content moves to
+// processing while the processor runs and to processed once the outputs are
+// stored. Earlier derived content of the same variants is replaced. On
+// failure the placeholders are removed and the content returns to its
+// previous status, leaving the retry to the pool.
+//
+// The content lock is held for the status transitions and the writes, not
+// while the processor runs: the processing status keeps the content from
+// being modified or deleted in the meantime.
+func (s *Server) runJob(ctx context.Context, job *jobs.Job) error {
+	processor := s.processors.Get(job.Processor)
+	if processor == nil {
+		return jobs.Permanent(fmt.Errorf("processor %q is not registered", job.Processor))
+	}
+
+	attempt := &jobAttempt{
+		job:    job,
+		actor:  "processor:" + processor.Name(),
+		reason: fmt.Sprintf("job %s", job.ID),
+	}
+	if err := s.beginJob(ctx, processor, attempt); err != nil {
+		return err
+	}
+
+	outputs, err := processor.Process(ctx, &jobs.Input{
+		Job:      job,
+		Name:     attempt.parent.Name,
+		MimeType: attempt.parent.DocumentType,
+		Data:     attempt.data,
+	})
+	if err != nil {
+		unlock := s.locks.lock(job.ContentID)
+		s.abortJob(ctx, attempt, err)
+		unlock()
+		return err
+	}
+	return s.completeJob(ctx, processor, attempt, outputs)
+}
+
+// beginJob reads the source data, creates the placeholders and moves the
+// source to processing
+func (s *Server) beginJob(ctx context.Context, processor jobs.Processor, attempt *jobAttempt) error {
+	unlock := s.locks.lock(attempt.job.ContentID)
+	defer unlock()
+
+	parent, err := s.service.GetContent(ctx, attempt.job.ContentID)
+	if err != nil {
+		if mcperrors.IsNotFound(err) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	attempt.parent = parent
+
+	// Read the data and create the placeholders before moving to processing:
+	// the library refuses both for content being processed
+	attempt.previous = simplecontent.ContentStatus(parent.Status)
+	if attempt.previous != simplecontent.ContentStatusUploaded && attempt.previous != simplecontent.ContentStatusProcessed {
+		return fmt.Errorf("content is %s, expected uploaded or processed", parent.Status)
+	}
+
+	attempt.variants, err = processor.Variants(attempt.job)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	attempt.data, err = s.readContent(ctx, parent.ID)
+	if err != nil {
+		return err
+	}
+
+	attempt.existing, err = s.service.ListDerivedContent(ctx, simplecontent.WithParentID(parent.ID))
+	if err != nil {
+		return err
+	}
+
+	attempt.placeholders = make(map[string]*simplecontent.Content, len(attempt.variants))
+	for _, variant := range attempt.variants {
+		derived, err := s.service.CreateDerivedContent(ctx, simplecontent.CreateDerivedContentRequest{
+			ParentID:       parent.ID,
+			OwnerID:        parent.OwnerID,
+			TenantID:       parent.TenantID,
+			DerivationType: attempt.job.DerivationType,
+			Variant:        variant,
+			Metadata:       map[string]interface{}{"job_id": attempt.job.ID.String()},
+			InitialStatus:  simplecontent.ContentStatusProcessing,
+		})
+		if err != nil {
+			s.removePlaceholders(ctx, attempt.placeholders)
+			return err
+		}
+		attempt.placeholders[variant] = derived
+	}
+
+	if err := s.setStatus(ctx, parent.ID, attempt.previous, simplecontent.ContentStatusProcessing, attempt.actor, attempt.reason); err != nil {
+		s.removePlaceholders(ctx, attempt.placeholders)
+		return err
+	}
+	return nil
+}
+
+// completeJob stores the outputs of the processor, replaces the earlier
+// derived content of the same variants and moves the source to processed
+func (s *Server) completeJob(ctx context.Context, processor jobs.Processor, attempt *jobAttempt, outputs []*jobs.Output) error {
+	unlock := s.locks.lock(attempt.job.ContentID)
+	defer unlock()
+
+	parent := attempt.parent
+	current, err := s.service.GetContent(ctx, parent.ID)
+	if err != nil {
+		s.removePlaceholders(ctx, attempt.placeholders)
+		if mcperrors.IsNotFound(err) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	if current.Status != string(simplecontent.ContentStatusProcessing) {
+		// Someone else moved the content on while the processor ran
+		s.removePlaceholders(ctx, attempt.placeholders)
+		return jobs.Permanent(fmt.Errorf("content moved to %s while the job ran", current.Status))
+	}
+
+	if err := s.storeOutputs(ctx, processor, attempt, outputs); err != nil {
+		s.abortJob(ctx, attempt, err)
+		return err
+	}
+
+	// The new outputs replace what earlier jobs derived for the same variants
+	for _, old := range attempt.existing {
+		if _, ok := attempt.placeholders[old.Variant]; ok && old.DerivationType == attempt.job.DerivationType {
+			if _, err := s.deleteContentTree(ctx, old.ContentID, true); err != nil && !mcperrors.IsNotFound(err) {
+				log.Printf("Failed to remove replaced derived content %s: %v", old.ContentID, err)
+			}
+		}
+	}
+
+	job := attempt.job
+	job.ResultIDs = job.ResultIDs[:0]
+	for _, variant := range attempt.variants {
+		job.ResultIDs = append(job.ResultIDs, attempt.placeholders[variant].ID)
+	}
+
+	if err := s.setStatus(ctx, parent.ID, simplecontent.ContentStatusProcessing, simplecontent.ContentStatusProcessed, attempt.actor, attempt.reason); err != nil {
+		return err
+	}
+	// Extracted text makes the parent searchable by its body
+	s.indexBody(ctx, parent.ID)
+	return nil
+}
+
+// abortJob removes the placeholders of a failed attempt and returns the
+// source to its previous status. Callers hold the content lock.
+func (s *Server) abortJob(ctx context.Context, attempt *jobAttempt, cause error) {
+	s.removePlaceholders(ctx, attempt.placeholders)
+	reason := fmt.Sprintf("%s failed: %v", attempt.reason, cause)
+	if err := s.setStatus(ctx, attempt.parent.ID, simplecontent.ContentStatusProcessing, attempt.previous, attempt.actor, reason); err != nil {
+		log.Printf("Failed to restore status of content %s: %v", attempt.parent.ID, err)
+	}
+}
+
+// storeOutputs uploads each output of the processor into its placeholder, on
+// the storage backend of the source content
+func (s *Server) storeOutputs(ctx context.Context, processor jobs.Processor, attempt *jobAttempt, outputs []*jobs.Output) error {
+	parent := attempt.parent
+	backend, err := s.contentBackend(ctx, parent.ID)
+	if err != nil {
+		return err
+	}
+
+	byVariant := make(map[string]*jobs.Output, len(outputs))
+	for _, output := range outputs {
+		byVariant[output.Variant] = output
+	}
+
+	for variant, derived := range attempt.placeholders {
+		output, ok := byVariant[variant]
+		if !ok {
+			return jobs.Permanent(fmt.Errorf("processor %s produced no %s output", processor.Name(), variant))
+		}
+
+		if _, err := s.service.UploadObjectForContent(ctx, simplecontent.UploadObjectForContentRequest{
+			ContentID:          derived.ID,
+			StorageBackendName: backend,
+			Reader:             bytes.NewReader(output.Data),
+			MimeType:           output.MimeType,
+		}); err != nil {
+			return err
+		}
+
+		// The derived-content API leaves name and document type empty
+		derived.Name = parent.Name + " (" + variant + ")"
+		derived.DocumentType = output.MimeType
+		if err := s.service.UpdateContent(ctx, simplecontent.UpdateContentRequest{Content: derived}); err != nil {
+			return err
+		}
+
+		custom := map[string]interface{}{"job_id": attempt.job.ID.String()}
+		for k, v := range output.Metadata {
+			custom[k] = v
+		}
+		if err := s.saveContentMetadata(ctx, &simplecontent.ContentMetadata{
+			ContentID: derived.ID,
+			MimeType:  output.MimeType,
+			FileSize:  int64(len(output.Data)),
+		}, nil, custom); err != nil {
+			return err
+		}
+
+		if err := s.service.UpdateContentStatus(ctx, derived.ID, simplecontent.ContentStatusProcessed); err != nil {
+			return err
+		}
+		s.indexContent(ctx, derived.ID)
+	}
+	return nil
+}
+
+// removePlaceholders deletes the placeholders of a failed job attempt. They
+// are marked failed first since content being processed cannot be deleted.
+func (s *Server) removePlaceholders(ctx context.Context, placeholders map[string]*simplecontent.Content) {
+	for _, derived := range placeholders {
+		if err := s.service.UpdateContentStatus(ctx, derived.ID, simplecontent.ContentStatusFailed); err != nil {
+			log.Printf("Failed to mark placeholder %s as failed: %v", derived.ID, err)
+			continue
+		}
+		if _, err := s.deleteContentTree(ctx, derived.ID, true); err != nil {
+			log.Printf("Failed to remove placeholder %s: %v", derived.ID, err)
+		}
+	}
+}
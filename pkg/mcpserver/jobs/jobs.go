@@ -0,0 +1,160 @@
+// Package jobs runs derivation jobs (thumbnails, previews, text extraction)
+// in the background.
+//
+// A Job asks for one derivation type of one content. Jobs wait in a Queue
+// (MemoryQueue or PostgresQueue) until a worker of a Pool claims them and
+// hands them to a Handler, which typically looks up the Processor registered
+// for the content's MIME type and derivation type. Failed jobs are retried
+// with exponential backoff until they run out of attempts.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status is the state of a job
+type Status string
+
+const (
+	// StatusQueued jobs wait to be claimed by a worker (including retries)
+	StatusQueued Status = "queued"
+	// StatusRunning jobs are being processed by a worker
+	StatusRunning Status = "running"
+	// StatusSucceeded jobs completed and stored their outputs
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed jobs failed permanently or ran out of attempts
+	StatusFailed Status = "failed"
+)
+
+// Valid reports whether s is a known job status
+func (s Status) Valid() bool {
+	switch s {
+	case StatusQueued, StatusRunning, StatusSucceeded, StatusFailed:
+		return true
+	}
+	return false
+}
+
+// Done reports whether a job in status s will not run again
+func (s Status) Done() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+// ErrNotFound is returned by queues for unknown job IDs
+var ErrNotFound = errors.New("job not found")
+
+// ErrLeaseLost is returned by Queue.Update when the job is no longer running
+// the attempt the caller claimed, e.g. because its lease expired and another
+// worker reclaimed it
+var ErrLeaseLost = errors.New("job lease lost")
+
+// Job is a request to derive content of one derivation type from a content
+type Job struct {
+	ID             uuid.UUID              `json:"id"`
+	ContentID      uuid.UUID              `json:"content_id"`
+	OwnerID        uuid.UUID              `json:"owner_id"`
+	TenantID       uuid.UUID              `json:"tenant_id"`
+	DerivationType string                 `json:"derivation_type"`
+	Processor      string                 `json:"processor"`
+	Params         map[string]interface{} `json:"params,omitempty"`
+	Status         Status                 `json:"status"`
+	Attempts       int                    `json:"attempts"`
+	MaxAttempts    int                    `json:"max_attempts"`
+	LastError      string                 `json:"last_error,omitempty"`
+	ResultIDs      []uuid.UUID            `json:"result_ids,omitempty"` // Derived content produced by the job
+	RunAt          time.Time              `json:"run_at"`               // Earliest time the job may be claimed
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+	FinishedAt     *time.Time             `json:"finished_at,omitempty"`
+}
+
+// clone returns a copy of job that shares no mutable state with it
+func (j *Job) clone() *Job {
+	c := *j
+	if j.Params != nil {
+		c.Params = make(map[string]interface{}, len(j.Params))
+		for k, v := range j.Params {
+			c.Params[k] = v
+		}
+	}
+	c.ResultIDs = append([]uuid.UUID(nil), j.ResultIDs...)
+	if j.FinishedAt != nil {
+		finishedAt := *j.FinishedAt
+		c.FinishedAt = &finishedAt
+	}
+	return &c
+}
+
+// Filter selects jobs in Queue.List. Zero values match everything.
+type Filter struct {
+	ContentID uuid.UUID
+	OwnerID   uuid.UUID
+	TenantID  uuid.UUID
+	Status    Status
+	Limit     int
+	Offset    int
+}
+
+// matches reports whether job passes the filter, ignoring paging
+func (f Filter) matches(job *Job) bool {
+	if f.ContentID != uuid.Nil && job.ContentID != f.ContentID {
+		return false
+	}
+	if f.OwnerID != uuid.Nil && job.OwnerID != f.OwnerID {
+		return false
+	}
+	if f.TenantID != uuid.Nil && job.TenantID != f.TenantID {
+		return false
+	}
+	if f.Status != "" && job.Status != f.Status {
+		return false
+	}
+	return true
+}
+
+// Queue stores jobs and hands them out to workers
+type Queue interface {
+	// Enqueue adds a new queued job
+	Enqueue(ctx context.Context, job *Job) error
+	// Claim marks the oldest queued job whose RunAt is not after now as
+	// running, increments its attempts and returns it. It returns nil when
+	// no job is ready.
+	Claim(ctx context.Context, now time.Time) (*Job, error)
+	// Update stores the outcome of the attempt the caller claimed. It returns
+	// ErrLeaseLost when the job is no longer running that attempt, so a late
+	// write cannot overwrite the state of a worker that reclaimed it.
+	Update(ctx context.Context, job *Job) error
+	// Heartbeat records at now that a running job is still being worked on,
+	// renewing its lease in queues that reclaim abandoned jobs
+	Heartbeat(ctx context.Context, id uuid.UUID, now time.Time) error
+	// Get returns a job by ID or ErrNotFound
+	Get(ctx context.Context, id uuid.UUID) (*Job, error)
+	// List returns jobs matching filter, newest first
+	List(ctx context.Context, filter Filter) ([]*Job, error)
+}
+
+// permanentError marks errors that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that the pool fails the job without retrying
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
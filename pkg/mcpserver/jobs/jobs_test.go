@@ -0,0 +1,224 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newJob(contentID uuid.UUID, createdAt time.Time) *Job {
+	return &Job{
+		ID:             uuid.New(),
+		ContentID:      contentID,
+		DerivationType: "thumbnail",
+		Status:         StatusQueued,
+		MaxAttempts:    3,
+		RunAt:          createdAt,
+		CreatedAt:      createdAt,
+		UpdatedAt:      createdAt,
+	}
+}
+
+func TestMemoryQueue(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue()
+	now := time.Now()
+	contentID := uuid.New()
+
+	older := newJob(contentID, now.Add(-2*time.Minute))
+	newer := newJob(uuid.New(), now.Add(-time.Minute))
+	later := newJob(contentID, now)
+	later.RunAt = now.Add(time.Hour)
+	for _, job := range []*Job{newer, later, older} {
+		if err := queue.Enqueue(ctx, job); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	// Ready jobs are claimed oldest first; jobs scheduled later wait
+	for _, want := range []*Job{older, newer} {
+		claimed, err := queue.Claim(ctx, now)
+		if err != nil || claimed == nil || claimed.ID != want.ID {
+			t.Fatalf("Expected to claim %s, got %v (%v)", want.ID, claimed, err)
+		}
+		if claimed.Status != StatusRunning || claimed.Attempts != 1 {
+			t.Errorf("Unexpected claimed job: %+v", claimed)
+		}
+	}
+	if claimed, err := queue.Claim(ctx, now); claimed != nil || err != nil {
+		t.Fatalf("Expected no ready job, got %v (%v)", claimed, err)
+	}
+
+	listed, err := queue.List(ctx, Filter{ContentID: contentID})
+	if err != nil || len(listed) != 2 || listed[0].ID != later.ID {
+		t.Errorf("Expected the content's jobs newest first, got %v (%v)", listed, err)
+	}
+	listed, _ = queue.List(ctx, Filter{Status: StatusRunning, Limit: 1, Offset: 1})
+	if len(listed) != 1 || listed[0].ID != older.ID {
+		t.Errorf("Expected paged running jobs, got %v", listed)
+	}
+
+	if _, err := queue.Get(ctx, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPoolRetries(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue()
+
+	calls := 0
+	pool := NewPool(queue, func(ctx context.Context, job *Job) error {
+		calls++
+		if job.Params["permanent"] == true {
+			return Permanent(errors.New("bad input"))
+		}
+		return errors.New("try again")
+	}, PoolOptions{RetryBackoff: time.Hour, MaxBackoff: 2 * time.Hour})
+
+	retried := newJob(uuid.New(), time.Now())
+	retried.MaxAttempts = 2
+	permanent := newJob(uuid.New(), time.Now())
+	permanent.Params = map[string]interface{}{"permanent": true}
+	queue.Enqueue(ctx, retried)
+	queue.Enqueue(ctx, permanent)
+
+	if ran, err := pool.Drain(ctx); ran != 2 || err != nil {
+		t.Fatalf("Expected 2 jobs to run, got %d (%v)", ran, err)
+	}
+
+	job, _ := queue.Get(ctx, retried.ID)
+	if job.Status != StatusQueued || job.LastError != "try again" || job.RunAt.Before(time.Now().Add(59*time.Minute)) {
+		t.Errorf("Expected a retry after the backoff, got %+v", job)
+	}
+	job, _ = queue.Get(ctx, permanent.ID)
+	if job.Status != StatusFailed || job.Attempts != 1 || job.FinishedAt == nil {
+		t.Errorf("Expected permanent failure without retry, got %+v", job)
+	}
+
+	if ran, _ := pool.Drain(ctx); ran != 0 {
+		t.Errorf("Expected the retry to wait for its backoff, %d ran", ran)
+	}
+
+	// Once its attempts are used up the job fails
+	claimed, err := queue.Claim(ctx, time.Now().Add(2*time.Hour))
+	if err != nil || claimed == nil || claimed.ID != retried.ID {
+		t.Fatalf("Expected to claim the retry, got %v (%v)", claimed, err)
+	}
+	pool.finish(claimed, errors.New("try again"))
+	if claimed.Status != StatusFailed || claimed.Attempts != 2 {
+		t.Errorf("Expected job to fail after MaxAttempts, got %+v", claimed)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 handler calls, got %d", calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	pool := NewPool(NewMemoryQueue(), nil, PoolOptions{RetryBackoff: time.Second, MaxBackoff: 5 * time.Second})
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := pool.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestMemoryQueueEviction(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue()
+	queue.Retention = time.Hour
+	queue.MaxFinished = 2
+	now := time.Now()
+
+	finished := func(age time.Duration) *Job {
+		job := newJob(uuid.New(), now.Add(-age))
+		finishedAt := now.Add(-age)
+		job.Status = StatusSucceeded
+		job.FinishedAt = &finishedAt
+		queue.Enqueue(ctx, job)
+		return job
+	}
+	expired := finished(2 * time.Hour)
+	oldest := finished(30 * time.Minute)
+	older := finished(20 * time.Minute)
+	recent := finished(10 * time.Minute)
+	queued := newJob(uuid.New(), now.Add(-3*time.Hour))
+	queue.Enqueue(ctx, queued)
+
+	for _, job := range []*Job{expired, oldest} {
+		if _, err := queue.Get(ctx, job.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected job %s to be evicted, got %v", job.ID, err)
+		}
+	}
+	for _, job := range []*Job{older, recent, queued} {
+		if _, err := queue.Get(ctx, job.ID); err != nil {
+			t.Errorf("Expected job %s to be kept: %v", job.ID, err)
+		}
+	}
+}
+
+func TestMemoryQueueUpdateLease(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue()
+	now := time.Now()
+	queue.Enqueue(ctx, newJob(uuid.New(), now))
+
+	// The first attempt is retried and claimed again; its owner then writes late
+	stale, _ := queue.Claim(ctx, now)
+	retry := stale.clone()
+	retry.Status = StatusQueued
+	if err := queue.Update(ctx, retry); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	current, _ := queue.Claim(ctx, now)
+	if current == nil || current.Attempts != 2 {
+		t.Fatalf("Expected the job to be claimed again, got %v", current)
+	}
+
+	stale.Status = StatusFailed
+	if err := queue.Update(ctx, stale); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Expected ErrLeaseLost for a stale attempt, got %v", err)
+	}
+	if job, _ := queue.Get(ctx, current.ID); job.Status != StatusRunning || job.Attempts != 2 {
+		t.Errorf("Expected the new attempt to keep running, got %+v", job)
+	}
+
+	current.Status = StatusSucceeded
+	if err := queue.Update(ctx, current); err != nil {
+		t.Errorf("Expected the current attempt to be stored: %v", err)
+	}
+	if err := queue.Update(ctx, current); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Expected ErrLeaseLost for a finished job, got %v", err)
+	}
+	if err := queue.Update(ctx, newJob(uuid.New(), now)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPoolHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue()
+
+	var claimedAt, renewedAt time.Time
+	pool := NewPool(queue, func(ctx context.Context, job *Job) error {
+		claimedAt = job.UpdatedAt
+		time.Sleep(50 * time.Millisecond)
+		current, err := queue.Get(ctx, job.ID)
+		if err != nil {
+			return err
+		}
+		renewedAt = current.UpdatedAt
+		return nil
+	}, PoolOptions{HeartbeatInterval: 5 * time.Millisecond})
+
+	queue.Enqueue(ctx, newJob(uuid.New(), time.Now()))
+	if ran, err := pool.Drain(ctx); ran != 1 || err != nil {
+		t.Fatalf("Expected 1 job to run, got %d (%v)", ran, err)
+	}
+	if !renewedAt.After(claimedAt) {
+		t.Errorf("Expected the lease to be renewed while the job ran: claimed %v, renewed %v", claimedAt, renewedAt)
+	}
+}
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryQueue is an in-process Queue. Jobs are lost when the process exits.
+// Finished jobs are kept for a while so their outcome can be looked up, then
+// evicted.
+type MemoryQueue struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*Job
+
+	// Retention is how long finished jobs are kept (default 24h)
+	Retention time.Duration
+	// MaxFinished is the number of finished jobs kept at most; the oldest
+	// are evicted first (default 10000)
+	MaxFinished int
+}
+
+// NewMemoryQueue creates an empty in-memory queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		jobs:        make(map[uuid.UUID]*Job),
+		Retention:   24 * time.Hour,
+		MaxFinished: 10000,
+	}
+}
+
+// Enqueue adds a new queued job and evicts expired finished jobs
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.evict(time.Now())
+	q.jobs[job.ID] = job.clone()
+	return nil
+}
+
+// evict drops finished jobs older than the retention window, then the oldest
+// finished jobs beyond MaxFinished
+func (q *MemoryQueue) evict(now time.Time) {
+	var finished []*Job
+	for id, job := range q.jobs {
+		if !job.Status.Done() {
+			continue
+		}
+		if q.Retention > 0 && job.FinishedAt != nil && job.FinishedAt.Before(now.Add(-q.Retention)) {
+			delete(q.jobs, id)
+			continue
+		}
+		finished = append(finished, job)
+	}
+
+	if q.MaxFinished <= 0 || len(finished) <= q.MaxFinished {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finishedAt(finished[i]).Before(finishedAt(finished[j]))
+	})
+	for _, job := range finished[:len(finished)-q.MaxFinished] {
+		delete(q.jobs, job.ID)
+	}
+}
+
+// finishedAt returns when a finished job finished, falling back to its last update
+func finishedAt(job *Job) time.Time {
+	if job.FinishedAt != nil {
+		return *job.FinishedAt
+	}
+	return job.UpdatedAt
+}
+
+// Claim marks the oldest ready job as running and returns it
+func (q *MemoryQueue) Claim(ctx context.Context, now time.Time) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var next *Job
+	for _, job := range q.jobs {
+		if job.Status != StatusQueued || job.RunAt.After(now) {
+			continue
+		}
+		if next == nil || job.RunAt.Before(next.RunAt) ||
+			(job.RunAt.Equal(next.RunAt) && job.CreatedAt.Before(next.CreatedAt)) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	next.Status = StatusRunning
+	next.Attempts++
+	next.UpdatedAt = now
+	return next.clone(), nil
+}
+
+// Update stores the outcome of a claimed attempt of a job
+func (q *MemoryQueue) Update(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stored, ok := q.jobs[job.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Status != StatusRunning || stored.Attempts != job.Attempts {
+		return ErrLeaseLost
+	}
+	q.jobs[job.ID] = job.clone()
+	return nil
+}
+
+// Heartbeat records that a running job is still being worked on
+func (q *MemoryQueue) Heartbeat(ctx context.Context, id uuid.UUID, now time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if job.Status == StatusRunning {
+		job.UpdatedAt = now
+	}
+	return nil
+}
+
+// Get returns a job by ID
+func (q *MemoryQueue) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return job.clone(), nil
+}
+
+// List returns jobs matching filter, newest first
+func (q *MemoryQueue) List(ctx context.Context, filter Filter) ([]*Job, error) {
+	q.mu.Lock()
+	var matched []*Job
+	for _, job := range q.jobs {
+		if filter.matches(job) {
+			matched = append(matched, job.clone())
+		}
+	}
+	q.mu.Unlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+
+	if filter.Offset >= len(matched) {
+		return []*Job{}, nil
+	}
+	matched = matched[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(matched) {
+		matched = matched[:filter.Limit]
+	}
+	return matched, nil
+}
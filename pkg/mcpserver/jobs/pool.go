@@ -0,0 +1,223 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Handler processes a claimed job. It may record results on the job (e.g.
+// ResultIDs); the pool stores the job afterwards. Errors are retried unless
+// wrapped with Permanent.
+type Handler func(ctx context.Context, job *Job) error
+
+// PoolOptions configures a Pool
+type PoolOptions struct {
+	Workers      int           // Number of concurrent workers (default 1)
+	PollInterval time.Duration // How often idle workers look for ready jobs (default 1s)
+	RetryBackoff time.Duration // Delay before the first retry, doubled for each further attempt (default 1s)
+	MaxBackoff   time.Duration // Upper bound for retry delays (default 5m)
+
+	// HeartbeatInterval is how often a worker renews the lease of the job it
+	// runs (default 1m); keep it well below the queue's lease
+	HeartbeatInterval time.Duration
+}
+
+// Pool runs queued jobs on a bounded number of workers
+type Pool struct {
+	queue   Queue
+	handler Handler
+	options PoolOptions
+	wake    chan struct{}
+}
+
+// NewPool creates a pool that claims jobs from queue and runs them with handler
+func NewPool(queue Queue, handler Handler, options PoolOptions) *Pool {
+	if options.Workers <= 0 {
+		options.Workers = 1
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = time.Second
+	}
+	if options.RetryBackoff <= 0 {
+		options.RetryBackoff = time.Second
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = 5 * time.Minute
+	}
+	if options.HeartbeatInterval <= 0 {
+		options.HeartbeatInterval = time.Minute
+	}
+
+	return &Pool{
+		queue:   queue,
+		handler: handler,
+		options: options,
+		wake:    make(chan struct{}, options.Workers),
+	}
+}
+
+// Run processes jobs until ctx is cancelled and returns once all workers stopped
+func (p *Pool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// Notify wakes an idle worker, e.g. after a job was enqueued
+func (p *Pool) Notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// work claims and runs jobs, sleeping between polls while the queue is empty
+func (p *Pool) work(ctx context.Context) {
+	ticker := time.NewTicker(p.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		ran, err := p.RunNext(ctx)
+		if err != nil {
+			log.Printf("Job worker: %v", err)
+		}
+		if ran && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunNext claims one ready job and runs it. It reports false when no job was ready.
+func (p *Pool) RunNext(ctx context.Context) (bool, error) {
+	if ctx.Err() != nil {
+		return false, nil
+	}
+
+	job, err := p.queue.Claim(ctx, time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	stop := p.heartbeat(ctx, job)
+	err = p.run(ctx, job)
+	stop()
+	p.finish(job, err)
+
+	// The outcome must be stored even when the pool is shutting down
+	err = p.queue.Update(context.WithoutCancel(ctx), job)
+	if errors.Is(err, ErrLeaseLost) {
+		// Another worker reclaimed the job and owns its state now
+		log.Printf("Job worker: dropped outcome of job %s: %v", job.ID, err)
+		return true, nil
+	}
+	if err != nil {
+		return true, fmt.Errorf("failed to update job %s: %w", job.ID, err)
+	}
+	return true, nil
+}
+
+// Drain runs ready jobs one after another until none is left and returns how many ran
+func (p *Pool) Drain(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		ran, err := p.RunNext(ctx)
+		if err != nil {
+			return count, err
+		}
+		if !ran {
+			return count, nil
+		}
+		count++
+	}
+}
+
+// heartbeat renews the lease of job every HeartbeatInterval until the
+// returned function is called, so long jobs are not reclaimed while they run
+func (p *Pool) heartbeat(ctx context.Context, job *Job) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(p.options.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.queue.Heartbeat(ctx, job.ID, time.Now().UTC()); err != nil && ctx.Err() == nil {
+					log.Printf("Job worker: failed to renew lease of job %s: %v", job.ID, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// run calls the handler, turning panics into permanent errors
+func (p *Pool) run(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Permanent(fmt.Errorf("processor panicked: %v", r))
+		}
+	}()
+	return p.handler(ctx, job)
+}
+
+// finish records the outcome of an attempt, scheduling a retry when attempts remain
+func (p *Pool) finish(job *Job, err error) {
+	now := time.Now().UTC()
+	job.UpdatedAt = now
+
+	if err == nil {
+		job.Status = StatusSucceeded
+		job.LastError = ""
+		job.FinishedAt = &now
+		return
+	}
+
+	job.LastError = err.Error()
+	if IsPermanent(err) || job.Attempts >= job.MaxAttempts {
+		job.Status = StatusFailed
+		job.FinishedAt = &now
+		return
+	}
+
+	job.Status = StatusQueued
+	job.RunAt = now.Add(p.backoff(job.Attempts))
+}
+
+// backoff returns the delay before retrying after the given attempt
+func (p *Pool) backoff(attempt int) time.Duration {
+	delay := p.options.RetryBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.options.MaxBackoff {
+			return p.options.MaxBackoff
+		}
+	}
+	return min(delay, p.options.MaxBackoff)
+}
@@ -0,0 +1,245 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DBTX is satisfied by pgxpool.Pool, pgx.Conn and pgx.Tx
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...interface{}) pgx.Row
+}
+
+// postgresSchema creates the jobs table; it is safe to run repeatedly
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS mcp_jobs (
+    id UUID PRIMARY KEY,
+    content_id UUID NOT NULL,
+    owner_id UUID NOT NULL,
+    tenant_id UUID NOT NULL,
+    derivation_type VARCHAR(100) NOT NULL,
+    processor VARCHAR(100) NOT NULL,
+    params JSONB,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    result_ids JSONB,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NULL
+);
+CREATE INDEX IF NOT EXISTS idx_mcp_jobs_ready ON mcp_jobs(status, run_at);
+CREATE INDEX IF NOT EXISTS idx_mcp_jobs_content ON mcp_jobs(content_id);
+CREATE INDEX IF NOT EXISTS idx_mcp_jobs_owner ON mcp_jobs(owner_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_mcp_jobs_finished ON mcp_jobs(finished_at) WHERE finished_at IS NOT NULL;
+`
+
+// jobColumns lists the columns read into a Job, in scanJob order
+const jobColumns = `id, content_id, owner_id, tenant_id, derivation_type, processor, params,
+	status, attempts, max_attempts, last_error, result_ids, run_at, created_at, updated_at, finished_at`
+
+// PostgresQueue is a Queue stored in PostgreSQL. Several server instances
+// can share it: jobs are claimed with FOR UPDATE SKIP LOCKED.
+type PostgresQueue struct {
+	db DBTX
+
+	// Lease is how long a job may stay running without a heartbeat before
+	// another worker reclaims it, e.g. after a crash (default 15m). Workers
+	// renew it while they run a job (see PoolOptions.HeartbeatInterval).
+	Lease time.Duration
+	// Retention is how long finished jobs are kept (default 24h)
+	Retention time.Duration
+}
+
+// NewPostgresQueue creates a queue on db. Call EnsureSchema to create its table.
+func NewPostgresQueue(db DBTX) *PostgresQueue {
+	return &PostgresQueue{db: db, Lease: 15 * time.Minute, Retention: 24 * time.Hour}
+}
+
+// EnsureSchema creates the jobs table and indexes if they don't exist
+func (q *PostgresQueue) EnsureSchema(ctx context.Context) error {
+	if _, err := q.db.Exec(ctx, postgresSchema); err != nil {
+		return fmt.Errorf("failed to create jobs schema: %w", err)
+	}
+	return nil
+}
+
+// Enqueue adds a new queued job and deletes expired finished jobs
+func (q *PostgresQueue) Enqueue(ctx context.Context, job *Job) error {
+	if q.Retention > 0 {
+		_, err := q.db.Exec(ctx, `DELETE FROM mcp_jobs WHERE finished_at < $1`, time.Now().UTC().Add(-q.Retention))
+		if err != nil {
+			return fmt.Errorf("failed to evict finished jobs: %w", err)
+		}
+	}
+
+	_, err := q.db.Exec(ctx, `
+		INSERT INTO mcp_jobs (`+jobColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+		job.ID, job.ContentID, job.OwnerID, job.TenantID, job.DerivationType, job.Processor, job.Params,
+		job.Status, job.Attempts, job.MaxAttempts, job.LastError, job.ResultIDs, job.RunAt,
+		job.CreatedAt, job.UpdatedAt, job.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// Claim marks the oldest ready job as running and returns it. Jobs left
+// running without a heartbeat for longer than the lease are claimed again,
+// or failed when they have used up their attempts.
+func (q *PostgresQueue) Claim(ctx context.Context, now time.Time) (*Job, error) {
+	expired := now.Add(-q.Lease)
+	_, err := q.db.Exec(ctx, `
+		UPDATE mcp_jobs SET status = $1, last_error = $2, updated_at = $3, finished_at = $3
+		WHERE status = $4 AND updated_at < $5 AND attempts >= max_attempts`,
+		StatusFailed, "lease expired on the last attempt", now, StatusRunning, expired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail abandoned jobs: %w", err)
+	}
+
+	row := q.db.QueryRow(ctx, `
+		UPDATE mcp_jobs SET status = $1, attempts = attempts + 1, updated_at = $2
+		WHERE id = (
+			SELECT id FROM mcp_jobs
+			WHERE (status = $3 AND run_at <= $2) OR (status = $1 AND updated_at < $4 AND attempts < max_attempts)
+			ORDER BY run_at, created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns,
+		StatusRunning, now, StatusQueued, expired)
+
+	job, err := scanJob(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return job, nil
+}
+
+// Heartbeat renews the lease of a running job
+func (q *PostgresQueue) Heartbeat(ctx context.Context, id uuid.UUID, now time.Time) error {
+	_, err := q.db.Exec(ctx, `UPDATE mcp_jobs SET updated_at = $2 WHERE id = $1 AND status = $3`, id, now, StatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to renew job lease: %w", err)
+	}
+	return nil
+}
+
+// Update stores the outcome of a claimed attempt of a job. The write only
+// applies while the job is still running that attempt.
+func (q *PostgresQueue) Update(ctx context.Context, job *Job) error {
+	tag, err := q.db.Exec(ctx, `
+		UPDATE mcp_jobs SET status = $2, last_error = $4, result_ids = $5,
+			run_at = $6, updated_at = $7, finished_at = $8
+		WHERE id = $1 AND attempts = $3 AND status = $9`,
+		job.ID, job.Status, job.Attempts, job.LastError, job.ResultIDs, job.RunAt, job.UpdatedAt, job.FinishedAt,
+		StatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := q.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM mcp_jobs WHERE id = $1)`, job.ID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrLeaseLost
+}
+
+// Get returns a job by ID
+func (q *PostgresQueue) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	job, err := scanJob(q.db.QueryRow(ctx, `SELECT `+jobColumns+` FROM mcp_jobs WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+// List returns jobs matching filter, newest first
+func (q *PostgresQueue) List(ctx context.Context, filter Filter) ([]*Job, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(column string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if filter.ContentID != uuid.Nil {
+		addCondition("content_id", filter.ContentID)
+	}
+	if filter.OwnerID != uuid.Nil {
+		addCondition("owner_id", filter.OwnerID)
+	}
+	if filter.TenantID != uuid.Nil {
+		addCondition("tenant_id", filter.TenantID)
+	}
+	if filter.Status != "" {
+		addCondition("status", filter.Status)
+	}
+
+	query := `SELECT ` + jobColumns + ` FROM mcp_jobs`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY created_at DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// scanJob reads a row selected with jobColumns
+func scanJob(row pgx.Row) (*Job, error) {
+	var job Job
+	err := row.Scan(
+		&job.ID, &job.ContentID, &job.OwnerID, &job.TenantID, &job.DerivationType, &job.Processor, &job.Params,
+		&job.Status, &job.Attempts, &job.MaxAttempts, &job.LastError, &job.ResultIDs, &job.RunAt,
+		&job.CreatedAt, &job.UpdatedAt, &job.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
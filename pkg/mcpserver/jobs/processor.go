@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Processor derives content from a source content, e.g. thumbnails from an
+// image or plain text from a PDF
+type Processor interface {
+	// Name identifies the processor in jobs and status history
+	Name() string
+	// Supports reports whether the processor handles derivationType for
+	// sources of the given MIME type
+	Supports(mimeType, derivationType string) bool
+	// Variants lists the variants a job will produce (e.g. thumbnail_256).
+	// They are created as placeholders before Process runs so that clients
+	// can see them in processing.
+	Variants(job *Job) ([]string, error)
+	// Process derives one Output per variant from the source data
+	Process(ctx context.Context, input *Input) ([]*Output, error)
+}
+
+// Input is the source handed to a Processor
+type Input struct {
+	Job      *Job
+	Name     string // Name of the source content
+	MimeType string
+	Data     []byte
+}
+
+// Output is one derived content produced by a Processor
+type Output struct {
+	Variant  string
+	MimeType string
+	Data     []byte
+	Metadata map[string]interface{} // Stored as custom metadata of the derived content
+}
+
+// Registry holds the available processors
+type Registry struct {
+	mu         sync.RWMutex
+	processors []Processor
+}
+
+// NewRegistry creates a registry with the given processors
+func NewRegistry(processors ...Processor) *Registry {
+	return &Registry{processors: processors}
+}
+
+// Register adds a processor. Processors registered later take precedence.
+func (r *Registry) Register(p Processor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.processors = append(r.processors, p)
+}
+
+// Lookup returns the processor for derivationType of a source MIME type, or
+// nil when none supports it
+func (r *Registry) Lookup(mimeType, derivationType string) Processor {
+	mimeType = NormalizeMimeType(mimeType)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.processors) - 1; i >= 0; i-- {
+		if r.processors[i].Supports(mimeType, derivationType) {
+			return r.processors[i]
+		}
+	}
+	return nil
+}
+
+// Get returns the processor with the given name, or nil
+func (r *Registry) Get(name string) Processor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.processors) - 1; i >= 0; i-- {
+		if r.processors[i].Name() == name {
+			return r.processors[i]
+		}
+	}
+	return nil
+}
+
+// NormalizeMimeType lowercases a MIME type and strips its parameters
+func NormalizeMimeType(mimeType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+}
@@ -0,0 +1,291 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/auth"
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+)
+
+// statusHistoryKey is the metadata key holding the status transition log
+const statusHistoryKey = reservedMetadataPrefix + "status_history"
+
+// maxStatusHistory caps the number of transitions kept per content
+const maxStatusHistory = 50
+
+// statusTransitions lists the statuses each status may move to
+var statusTransitions = map[simplecontent.ContentStatus][]simplecontent.ContentStatus{
+	simplecontent.ContentStatusCreated:    {simplecontent.ContentStatusUploading, simplecontent.ContentStatusUploaded, simplecontent.ContentStatusFailed},
+	simplecontent.ContentStatusUploading:  {simplecontent.ContentStatusUploaded, simplecontent.ContentStatusFailed},
+	simplecontent.ContentStatusUploaded:   {simplecontent.ContentStatusProcessing, simplecontent.ContentStatusArchived, simplecontent.ContentStatusFailed},
+	simplecontent.ContentStatusProcessing: {simplecontent.ContentStatusProcessed, simplecontent.ContentStatusUploaded, simplecontent.ContentStatusFailed},
+	simplecontent.ContentStatusProcessed:  {simplecontent.ContentStatusProcessing, simplecontent.ContentStatusUploaded, simplecontent.ContentStatusArchived, simplecontent.ContentStatusFailed},
+	simplecontent.ContentStatusFailed:     {simplecontent.ContentStatusProcessing, simplecontent.ContentStatusUploaded, simplecontent.ContentStatusCreated},
+	simplecontent.ContentStatusArchived:   {simplecontent.ContentStatusUploaded, simplecontent.ContentStatusProcessed},
+}
+
+// statusActions maps named lifecycle actions to their target status.
+// unarchive has no fixed target; it restores the status held before archiving.
+// reprocess returns content to uploaded and queues its derivations, whose
+// jobs move it through processing.
+var statusActions = map[string]simplecontent.ContentStatus{
+	"archive":     simplecontent.ContentStatusArchived,
+	"mark_failed": simplecontent.ContentStatusFailed,
+	"reprocess":   simplecontent.ContentStatusUploaded,
+	"unarchive":   "",
+}
+
+// reprocessable lists the statuses reprocess applies to
+var reprocessable = []simplecontent.ContentStatus{simplecontent.ContentStatusFailed, simplecontent.ContentStatusProcessed}
+
+// statusTransition is one entry of the status history
+type statusTransition struct {
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Action  string    `json:"action,omitempty"`
+	Actor   string    `json:"actor"`
+	OwnerID string    `json:"owner_id,omitempty"` // Authenticated key owner, when auth is enabled
+	Reason  string    `json:"reason,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// checkTransition returns an error unless from may move to to
+func checkTransition(from, to simplecontent.ContentStatus) error {
+	allowed := statusTransitions[from]
+	for _, status := range allowed {
+		if status == to {
+			return nil
+		}
+	}
+
+	names := make([]string, len(allowed))
+	for i, status := range allowed {
+		names[i] = string(status)
+	}
+	return mcperrors.NewInvalidTransitionError(string(from), string(to), names)
+}
+
+// handleTransitionContentStatus moves content to another lifecycle status
+func (s *Server) handleTransitionContentStatus(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+
+	contentID, err := parseUUID(params["content_id"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("content_id", err)
+	}
+
+	action := getStringOr(params, "action", "")
+	toStatus := getStringOr(params, "to_status", "")
+	if (action == "") == (toStatus == "") {
+		return nil, mcperrors.NewValidationError("action", fmt.Errorf("exactly one of action or to_status is required"))
+	}
+	if action != "" {
+		if _, ok := statusActions[action]; !ok {
+			return nil, mcperrors.NewValidationError("action", fmt.Errorf("must be one of archive, unarchive, mark_failed, reprocess"))
+		}
+	}
+	if toStatus != "" && !simplecontent.ContentStatus(toStatus).IsValid() {
+		return nil, mcperrors.NewValidationError("to_status", fmt.Errorf("unknown status %q", toStatus))
+	}
+
+	unlock := s.locks.lock(contentID)
+	defer unlock()
+
+	content, err := s.service.GetContent(ctx, contentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	if err := s.authorizeAccess(ctx, content.OwnerID, content.TenantID); err != nil {
+		return nil, err
+	}
+	if err := checkIfMatch(params, content); err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.loadContentMetadata(ctx, contentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	history := loadStatusHistory(metadata.Metadata)
+
+	from := simplecontent.ContentStatus(content.Status)
+	to := simplecontent.ContentStatus(toStatus)
+	if action != "" {
+		to = statusActions[action]
+		if action == "unarchive" {
+			to = unarchiveTarget(content, history)
+		}
+	}
+
+	if action == "reprocess" {
+		if !slices.Contains(reprocessable, from) {
+			return nil, fmt.Errorf("cannot reprocess %s content: %w (reprocess moves failed or processed content back to uploaded)", from, mcperrors.ErrInvalidTransition)
+		}
+		// Derivations need the data, which content that failed to upload lacks
+		if err := s.checkHasData(ctx, contentID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkTransition(from, to); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.UpdateContentStatus(ctx, contentID, to); err != nil {
+		return nil, s.mapError(err)
+	}
+	s.indexContent(ctx, contentID)
+
+	entry := statusTransition{
+		From:   string(from),
+		To:     string(to),
+		Action: action,
+		Actor:  getStringOr(params, "actor", "unknown"),
+		Reason: getStringOr(params, "reason", ""),
+		At:     time.Now().UTC(),
+	}
+	if keyInfo, ok := auth.GetKeyInfo(ctx); ok {
+		entry.OwnerID = keyInfo.OwnerID.String()
+	}
+
+	history, err = s.recordTransition(ctx, contentID, entry)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+
+	updated, err := s.service.GetContent(ctx, contentID)
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+
+	result := map[string]interface{}{
+		"success":    true,
+		"content_id": contentID.String(),
+		"from":       string(from),
+		"to":         string(to),
+		"transition": entry,
+		"history":    history,
+		"etag":       contentETag(updated),
+	}
+	if action == "reprocess" {
+		queued, err := s.reprocessDerivations(ctx, updated)
+		if err != nil {
+			return nil, err
+		}
+		jobIDs := make([]string, len(queued))
+		for i, job := range queued {
+			jobIDs[i] = job.ID.String()
+		}
+		result["job_ids"] = jobIDs
+	}
+	return newTextResult(formatJSON(result)), nil
+}
+
+// checkHasData fails unless content has a live object holding its data
+func (s *Server) checkHasData(ctx context.Context, contentID uuid.UUID) error {
+	objects, err := s.service.GetObjectsByContentID(ctx, contentID)
+	if err != nil {
+		return s.mapError(err)
+	}
+	for _, object := range objects {
+		if object.DeletedAt == nil {
+			return nil
+		}
+	}
+	return mcperrors.NewValidationError("content_id", fmt.Errorf("content has no data to reprocess; upload it again"))
+}
+
+// recordTransition appends entry to the status history of a content and
+// returns the updated history. Callers hold the content lock.
+func (s *Server) recordTransition(ctx context.Context, contentID uuid.UUID, entry statusTransition) ([]statusTransition, error) {
+	metadata, err := s.loadContentMetadata(ctx, contentID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := append(loadStatusHistory(metadata.Metadata), entry)
+	if len(history) > maxStatusHistory {
+		history = history[len(history)-maxStatusHistory:]
+	}
+
+	custom := metadata.Metadata
+	custom[statusHistoryKey] = toDocument(history)
+	if err := s.saveContentMetadata(ctx, metadata, metadata.Tags, custom); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// setStatus moves content to another status on behalf of the server itself
+// (e.g. a processor) and records the transition. Callers hold the content lock.
+func (s *Server) setStatus(ctx context.Context, contentID uuid.UUID, from, to simplecontent.ContentStatus, actor, reason string) error {
+	if err := s.service.UpdateContentStatus(ctx, contentID, to); err != nil {
+		return err
+	}
+	s.indexContent(ctx, contentID)
+	_, err := s.recordTransition(ctx, contentID, statusTransition{
+		From:   string(from),
+		To:     string(to),
+		Actor:  actor,
+		Reason: reason,
+		At:     time.Now().UTC(),
+	})
+	return err
+}
+
+// unarchiveTarget picks the status to restore when unarchiving: the status
+// held before the last archive, or the terminal status for the content kind
+func unarchiveTarget(content *simplecontent.Content, history []statusTransition) simplecontent.ContentStatus {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].To == string(simplecontent.ContentStatusArchived) {
+			return simplecontent.ContentStatus(history[i].From)
+		}
+	}
+	if content.DerivationType != "" {
+		return simplecontent.ContentStatusProcessed
+	}
+	return simplecontent.ContentStatusUploaded
+}
+
+// toDocument converts v to its generic JSON form for storage in metadata
+func toDocument(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	return doc
+}
+
+// loadStatusHistory decodes the status history stored in content metadata
+func loadStatusHistory(metadata map[string]interface{}) []statusTransition {
+	raw, ok := metadata[statusHistoryKey]
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+
+	var history []statusTransition
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil
+	}
+	return history
+}
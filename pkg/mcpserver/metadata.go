@@ -0,0 +1,214 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/patch"
+)
+
+// reservedMetadataPrefix marks metadata keys maintained by the server itself
+// (status history, expiry, ...). They cannot be changed through update_content.
+const reservedMetadataPrefix = "mcp:"
+
+// loadContentMetadata returns the metadata record of a content, or an empty
+// record when none has been stored yet
+func (s *Server) loadContentMetadata(ctx context.Context, contentID uuid.UUID) (*simplecontent.ContentMetadata, error) {
+	metadata, err := s.service.GetContentMetadata(ctx, contentID)
+	if err != nil {
+		if mcperrors.IsNotFound(err) {
+			return &simplecontent.ContentMetadata{ContentID: contentID, Metadata: map[string]interface{}{}}, nil
+		}
+		return nil, err
+	}
+	if metadata.Metadata == nil {
+		metadata.Metadata = map[string]interface{}{}
+	}
+	return metadata, nil
+}
+
+// saveContentMetadata stores tags and custom metadata for a content.
+// SetContentMetadata replaces the whole record, so the file name, size and
+// MIME type of the existing record are carried over.
+// The search index picks up the new tags.
+func (s *Server) saveContentMetadata(ctx context.Context, existing *simplecontent.ContentMetadata, tags []string, custom map[string]interface{}) error {
+	err := s.service.SetContentMetadata(ctx, simplecontent.SetContentMetadataRequest{
+		ContentID:      existing.ContentID,
+		ContentType:    existing.MimeType,
+		FileName:       existing.FileName,
+		FileSize:       existing.FileSize,
+		Tags:           tags,
+		CustomMetadata: custom,
+	})
+	if err != nil {
+		return err
+	}
+	s.indexContent(ctx, existing.ContentID)
+	return nil
+}
+
+// contentSnapshot captures the user-editable state of a content for diffs
+func contentSnapshot(content *simplecontent.Content, tags []string, metadata map[string]interface{}) map[string]interface{} {
+	if tags == nil {
+		tags = []string{}
+	}
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"name":        content.Name,
+		"description": content.Description,
+		"tags":        append([]string(nil), tags...),
+		"metadata":    patch.Clone(metadata),
+	}
+}
+
+// applyTagChanges computes the new tag list from tags (replace), add_tags and
+// remove_tags. It reports false when none of them were given.
+func applyTagChanges(params map[string]interface{}, current []string) ([]string, bool) {
+	_, replace := params["tags"]
+	added := getStringSlice(params, "add_tags")
+	removed := getStringSlice(params, "remove_tags")
+	if !replace && len(added) == 0 && len(removed) == 0 {
+		return current, false
+	}
+
+	base := current
+	if replace {
+		base = getStringSlice(params, "tags")
+	}
+
+	drop := make(map[string]bool, len(removed))
+	for _, tag := range removed {
+		drop[tag] = true
+	}
+
+	seen := make(map[string]bool, len(base)+len(added))
+	tags := []string{}
+	for _, tag := range append(append([]string(nil), base...), added...) {
+		if seen[tag] || drop[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags, true
+}
+
+// applyMetadataPatch applies the metadata argument to current according to
+// patch_mode: replace, merge (RFC 7396, default) or json_patch (RFC 6902).
+// It reports false when no metadata was given.
+func applyMetadataPatch(params map[string]interface{}, current map[string]interface{}) (map[string]interface{}, bool, error) {
+	mode := getStringOr(params, "patch_mode", "merge")
+	if mode != "replace" && mode != "merge" && mode != "json_patch" {
+		return nil, false, mcperrors.NewValidationError("patch_mode", fmt.Errorf("must be replace, merge or json_patch"))
+	}
+
+	raw, ok := params["metadata"]
+	if !ok || raw == nil {
+		return current, false, nil
+	}
+
+	if mode == "json_patch" {
+		ops, err := patch.ParseOperations(raw)
+		if err != nil {
+			return nil, false, mcperrors.NewValidationError("metadata", err)
+		}
+		result, err := patch.Apply(current, ops)
+		if err != nil {
+			return nil, false, mcperrors.NewValidationError("metadata", err)
+		}
+		if err := checkReservedKeys(current, result); err != nil {
+			return nil, false, err
+		}
+		return result, true, nil
+	}
+
+	object, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, false, mcperrors.NewValidationError("metadata", fmt.Errorf("must be an object for patch_mode %s", mode))
+	}
+	if mode == "replace" {
+		// Server-maintained keys survive a replace
+		result := patch.Clone(object).(map[string]interface{})
+		for k, v := range current {
+			if _, ok := result[k]; !ok && strings.HasPrefix(k, reservedMetadataPrefix) {
+				result[k] = v
+			}
+		}
+		if err := checkReservedKeys(current, result); err != nil {
+			return nil, false, err
+		}
+		return result, true, nil
+	}
+
+	result := patch.Merge(current, object)
+	if err := checkReservedKeys(current, result); err != nil {
+		return nil, false, err
+	}
+	return result, true, nil
+}
+
+// checkReservedKeys fails if any reserved metadata key differs between before and after
+func checkReservedKeys(before, after map[string]interface{}) error {
+	for _, m := range []map[string]interface{}{before, after} {
+		for k := range m {
+			if strings.HasPrefix(k, reservedMetadataPrefix) && !patch.Equal(before[k], after[k]) {
+				return mcperrors.NewValidationError("metadata", fmt.Errorf("key %q is reserved", k))
+			}
+		}
+	}
+	return nil
+}
+
+// parseMetadataFilters reads the optional metadata_filters argument
+func parseMetadataFilters(params map[string]interface{}) ([]*metafilter.Filter, error) {
+	filters, err := metafilter.Parse(params["metadata_filters"])
+	if err != nil {
+		return nil, mcperrors.NewValidationError("metadata_filters", err)
+	}
+	return filters, nil
+}
+
+// filterByMetadata keeps the contents whose custom metadata matches filters
+// and whose file size is within the bounds of scope. The configured
+// MetadataQuerier evaluates them in the database; otherwise each content's
+// metadata is loaded and matched in memory.
+func (s *Server) filterByMetadata(ctx context.Context, contents []*simplecontent.Content, scope metafilter.Scope, filters []*metafilter.Filter) ([]*simplecontent.Content, error) {
+	if len(filters) == 0 && scope.MinSize == nil && scope.MaxSize == nil {
+		return contents, nil
+	}
+
+	var matching map[uuid.UUID]bool
+	if s.config.MetadataQuerier != nil {
+		var err error
+		matching, err = s.config.MetadataQuerier.MatchingIDs(ctx, scope, filters)
+		if err != nil {
+			return nil, mcperrors.NewInternalError(fmt.Errorf("metadata query failed: %w", err))
+		}
+	}
+
+	result := make([]*simplecontent.Content, 0, len(contents))
+	for _, content := range contents {
+		if matching != nil {
+			if matching[content.ID] {
+				result = append(result, content)
+			}
+			continue
+		}
+		metadata, err := s.loadContentMetadata(ctx, content.ID)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		if metafilter.Match(metadata.Metadata, filters) && sizeWithin(metadata.FileSize, scope.MinSize, scope.MaxSize) {
+			result = append(result, content)
+		}
+	}
+	return result, nil
+}
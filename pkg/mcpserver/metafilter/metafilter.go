@@ -0,0 +1,352 @@
+// Package metafilter filters content by its custom metadata.
+//
+// A Filter selects a value with a JSON path ($.customer.id, $.items[0].sku,
+// $["key.with.dots"]) and compares it with an operator. Match evaluates
+// filters in memory against the JSON-like documents produced by
+// encoding/json; SQL compiles them into conditions on a JSONB column, and
+// PostgresQuerier runs them against the simple-content PostgreSQL schema.
+package metafilter
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Op is a filter operator
+type Op string
+
+// Filter operators
+const (
+	OpEq     Op = "eq"     // Equal to Value
+	OpNe     Op = "ne"     // Missing or not equal to Value
+	OpIn     Op = "in"     // Equal to one of the values of Value (an array)
+	OpExists Op = "exists" // Present (Value true or omitted) or missing (Value false)
+	OpGt     Op = "gt"     // Greater than a number or date
+	OpGte    Op = "gte"    // Greater than or equal to a number or date
+	OpLt     Op = "lt"     // Less than a number or date
+	OpLte    Op = "lte"    // Less than or equal to a number or date
+)
+
+// Segment is a step of a path: an object key or an array index
+type Segment struct {
+	Key   string
+	Index int
+	IsKey bool
+}
+
+// Filter is a condition on a metadata value
+type Filter struct {
+	Path  string      `json:"path"`
+	Op    Op          `json:"op"`
+	Value interface{} `json:"value,omitempty"`
+
+	segments []Segment
+	number   float64   // Value of gt/gte/lt/lte on numbers
+	date     time.Time // Value of gt/gte/lt/lte on dates
+	isDate   bool
+}
+
+// Segments returns the parsed path
+func (f *Filter) Segments() []Segment {
+	return f.segments
+}
+
+// Parse decodes filters from a tool argument: an array of objects with
+// path, op and value. It returns nil for a missing argument.
+func Parse(v interface{}) ([]*Filter, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be an array of {path, op, value} objects")
+	}
+
+	filters := make([]*Filter, 0, len(raw))
+	for i, item := range raw {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("filter %d: must be an object with path, op and value", i)
+		}
+		path, _ := obj["path"].(string)
+		op, _ := obj["op"].(string)
+		filter, err := New(path, Op(op), obj["value"])
+		if err != nil {
+			return nil, fmt.Errorf("filter %d: %w", i, err)
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// New validates and builds a filter
+func New(path string, op Op, value interface{}) (*Filter, error) {
+	segments, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{Path: path, Op: op, Value: value, segments: segments}
+
+	switch op {
+	case OpEq, OpNe:
+		if value == nil {
+			return nil, fmt.Errorf("%s requires a value", op)
+		}
+	case OpIn:
+		if _, ok := value.([]interface{}); !ok {
+			return nil, fmt.Errorf("in requires an array value")
+		}
+	case OpExists:
+		if value == nil {
+			f.Value = true
+		} else if _, ok := value.(bool); !ok {
+			return nil, fmt.Errorf("exists takes true, false or no value")
+		}
+	case OpGt, OpGte, OpLt, OpLte:
+		if n, ok := toNumber(value); ok {
+			f.number = n
+		} else if s, ok := value.(string); ok {
+			if f.date, ok = parseDate(s); !ok {
+				return nil, fmt.Errorf("%s requires a number or a date (YYYY-MM-DD or RFC 3339), got %q", op, s)
+			}
+			f.isDate = true
+		} else {
+			return nil, fmt.Errorf("%s requires a number or a date (YYYY-MM-DD or RFC 3339)", op)
+		}
+	case "":
+		return nil, fmt.Errorf("op is required (eq, ne, in, exists, gt, gte, lt, lte)")
+	default:
+		return nil, fmt.Errorf("unknown op %q (eq, ne, in, exists, gt, gte, lt, lte)", op)
+	}
+	return f, nil
+}
+
+// ParsePath parses a JSON path made of keys and array indexes. The leading
+// "$" is optional: "$.a.b[0]", "a.b[0]" and `$["a"]["b"][0]` are equivalent.
+func ParsePath(path string) ([]Segment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	rest := strings.TrimPrefix(path, "$")
+	if rest != path && rest != "" && rest[0] != '.' && rest[0] != '[' {
+		return nil, fmt.Errorf("invalid path %q: expected '.' or '[' after '$'", path)
+	}
+	if rest == path && !strings.HasPrefix(rest, "[") {
+		rest = "." + rest
+	}
+
+	var segments []Segment
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path %q: empty key", path)
+			}
+			segments = append(segments, Segment{Key: rest[:end], IsKey: true})
+			rest = rest[end:]
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: missing ']'", path)
+			}
+			inner := rest[1:end]
+			if strings.HasPrefix(inner, `"`) || strings.HasPrefix(inner, "'") {
+				// Quoted keys may contain '.' and ']'
+				quote := inner[0]
+				closing := strings.IndexByte(rest[2:], quote)
+				if closing < 0 || len(rest) < closing+4 || rest[closing+3] != ']' {
+					return nil, fmt.Errorf("invalid path %q: unterminated quoted key", path)
+				}
+				segments = append(segments, Segment{Key: rest[2 : closing+2], IsKey: true})
+				rest = rest[closing+4:]
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path %q: array index must be a non-negative integer", path)
+			}
+			segments = append(segments, Segment{Index: index})
+			rest = rest[end+1:]
+
+		default:
+			return nil, fmt.Errorf("invalid path %q: unexpected %q", path, rest[0])
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid path %q: selects the whole document", path)
+	}
+	return segments, nil
+}
+
+// Match reports whether metadata satisfies all filters
+func Match(metadata map[string]interface{}, filters []*Filter) bool {
+	for _, f := range filters {
+		if !f.Match(metadata) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether metadata satisfies the filter
+func (f *Filter) Match(metadata map[string]interface{}) bool {
+	value, found := lookup(metadata, f.segments)
+
+	switch f.Op {
+	case OpEq:
+		return found && equal(value, f.Value)
+	case OpNe:
+		return !found || !equal(value, f.Value)
+	case OpIn:
+		if !found {
+			return false
+		}
+		for _, candidate := range f.Value.([]interface{}) {
+			if equal(value, candidate) {
+				return true
+			}
+		}
+		return false
+	case OpExists:
+		return found == f.Value.(bool)
+	}
+
+	if !found {
+		return false
+	}
+	var order int
+	if f.isDate {
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		t, ok := parseDate(s)
+		if !ok {
+			return false
+		}
+		order = t.Compare(f.date)
+	} else {
+		n, ok := toNumber(value)
+		if !ok {
+			return false
+		}
+		order = cmp.Compare(n, f.number)
+	}
+
+	switch f.Op {
+	case OpGt:
+		return order > 0
+	case OpGte:
+		return order >= 0
+	case OpLt:
+		return order < 0
+	case OpLte:
+		return order <= 0
+	}
+	return false
+}
+
+// lookup follows segments through a document
+func lookup(doc interface{}, segments []Segment) (interface{}, bool) {
+	current := doc
+	for _, segment := range segments {
+		if segment.IsKey {
+			obj, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if current, ok = obj[segment.Key]; !ok {
+				return nil, false
+			}
+			continue
+		}
+
+		arr := reflect.ValueOf(current)
+		if arr.Kind() != reflect.Slice || segment.Index >= arr.Len() {
+			return nil, false
+		}
+		current = arr.Index(segment.Index).Interface()
+	}
+	return current, true
+}
+
+// equal compares JSON values, treating all numeric types alike
+func equal(a, b interface{}) bool {
+	if x, ok := toNumber(a); ok {
+		y, ok := toNumber(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// normalize converts numbers in nested values to float64
+func normalize(v interface{}) interface{} {
+	if n, ok := toNumber(v); ok {
+		return n
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			result[k] = normalize(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalize(item)
+		}
+		return result
+	case []string:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = item
+		}
+		return result
+	}
+	return v
+}
+
+// toNumber converts numeric values to float64
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// parseDate parses an RFC 3339 timestamp or a YYYY-MM-DD date (UTC midnight)
+func parseDate(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,190 @@
+package metafilter
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/cursor"
+)
+
+func mustNew(t *testing.T, path string, op Op, value interface{}) *Filter {
+	t.Helper()
+	f, err := New(path, op, value)
+	if err != nil {
+		t.Fatalf("New(%q, %s, %v) failed: %v", path, op, value, err)
+	}
+	return f
+}
+
+func TestParsePath(t *testing.T) {
+	for path, want := range map[string]string{
+		"$.customer.id":      "customer,id",
+		"customer.id":        "customer,id",
+		"$.items[1].sku":     "items,[1],sku",
+		`$["key.with.dots"]`: "key.with.dots",
+		`['a']['b'][0]`:      "a,b,[0]",
+	} {
+		segments, err := ParsePath(path)
+		if err != nil {
+			t.Errorf("ParsePath(%q) failed: %v", path, err)
+			continue
+		}
+		parts := make([]string, len(segments))
+		for i, segment := range segments {
+			if segment.IsKey {
+				parts[i] = segment.Key
+			} else {
+				parts[i] = "[" + strconv.Itoa(segment.Index) + "]"
+			}
+		}
+		if got := strings.Join(parts, ","); got != want {
+			t.Errorf("ParsePath(%q) = %s, want %s", path, got, want)
+		}
+	}
+
+	for _, path := range []string{"", "$", "$x", "a..b", "a[", "a[-1]", `a["open]`} {
+		if _, err := ParsePath(path); err == nil {
+			t.Errorf("ParsePath(%q) succeeded, want an error", path)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	filters, err := Parse([]interface{}{
+		map[string]interface{}{"path": "$.project", "op": "eq", "value": "apollo"},
+		map[string]interface{}{"path": "$.reviewed", "op": "exists"},
+	})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(filters) != 2 || filters[1].Value != true {
+		t.Errorf("Unexpected filters: %+v", filters)
+	}
+
+	for _, raw := range []interface{}{
+		"project=apollo",
+		[]interface{}{"project"},
+		[]interface{}{map[string]interface{}{"path": "$.a", "op": "like", "value": "x"}},
+		[]interface{}{map[string]interface{}{"path": "$.a", "op": "eq"}},
+		[]interface{}{map[string]interface{}{"path": "$.a", "op": "in", "value": "x"}},
+		[]interface{}{map[string]interface{}{"path": "$.a", "op": "gt", "value": "soon"}},
+		[]interface{}{map[string]interface{}{"path": "$.a", "op": "exists", "value": "yes"}},
+	} {
+		if _, err := Parse(raw); err == nil {
+			t.Errorf("Parse(%v) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	metadata := map[string]interface{}{
+		"project":  "apollo",
+		"priority": float64(3),
+		"due":      "2025-06-30",
+		"approved": "2025-03-14T10:00:00Z",
+		"customer": map[string]interface{}{"id": "c-42", "tier": "gold"},
+		"items":    []interface{}{map[string]interface{}{"sku": "A1"}, map[string]interface{}{"sku": "B2"}},
+		"labels":   []string{"x", "y"},
+		"draft":    false,
+	}
+
+	tests := []struct {
+		path  string
+		op    Op
+		value interface{}
+		want  bool
+	}{
+		{"$.project", OpEq, "apollo", true},
+		{"$.project", OpEq, "gemini", false},
+		{"$.priority", OpEq, 3, true},
+		{"$.customer.id", OpEq, "c-42", true},
+		{"$.items[1].sku", OpEq, "B2", true},
+		{"$.items[2].sku", OpEq, "B2", false},
+		{"$.labels", OpEq, []interface{}{"x", "y"}, true},
+		{"$.draft", OpEq, false, true},
+		{"$.project", OpNe, "gemini", true},
+		{"$.missing", OpNe, "gemini", true},
+		{"$.customer.tier", OpIn, []interface{}{"gold", "platinum"}, true},
+		{"$.customer.tier", OpIn, []interface{}{"silver"}, false},
+		{"$.missing", OpIn, []interface{}{"silver"}, false},
+		{"$.customer", OpExists, true, true},
+		{"$.missing", OpExists, false, true},
+		{"$.project.name", OpExists, true, false},
+		{"$.priority", OpGt, 2, true},
+		{"$.priority", OpGte, 3.0, true},
+		{"$.priority", OpLt, 3, false},
+		{"$.project", OpGt, 2, false},
+		{"$.due", OpLt, "2025-07-01", true},
+		{"$.due", OpGte, "2025-07-01", false},
+		{"$.approved", OpGt, "2025-03-14", true},
+		{"$.approved", OpLte, "2025-03-14T09:00:00-02:00", true},
+		{"$.project", OpLt, "2025-07-01", false},
+	}
+	for _, test := range tests {
+		f := mustNew(t, test.path, test.op, test.value)
+		if got := f.Match(metadata); got != test.want {
+			t.Errorf("%s %s %v = %v, want %v", test.path, test.op, test.value, got, test.want)
+		}
+	}
+
+	if !Match(metadata, nil) || !Match(nil, []*Filter{mustNew(t, "$.a", OpExists, false)}) {
+		t.Error("Expected empty filters and missing metadata to match")
+	}
+}
+
+func TestSQL(t *testing.T) {
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	got := SQL("metadata", []*Filter{
+		mustNew(t, "$.customer.tier", OpIn, []interface{}{"gold", "platinum"}),
+		mustNew(t, "$.items[0].sku", OpNe, "A1"),
+		mustNew(t, "$.reviewed", OpExists, nil),
+		mustNew(t, "$.priority", OpGt, 2),
+	}, arg)
+	want := "(((metadata #> $1::text[]) IN (SELECT jsonb_array_elements($2::jsonb))) AND " +
+		"((metadata #> $3::text[]) IS DISTINCT FROM $4::jsonb) AND " +
+		"((metadata #> $5::text[]) IS NOT NULL) AND " +
+		"(CASE WHEN jsonb_typeof((metadata #> $6::text[])) = 'number' THEN ((metadata #> $6::text[]))::numeric > $7 ELSE FALSE END))"
+	if got != want {
+		t.Errorf("Unexpected SQL:\n got %s\nwant %s", got, want)
+	}
+	if strings.Join(args[0].([]string), ",") != "customer,tier" || args[1] != `["gold","platinum"]` ||
+		strings.Join(args[2].([]string), ",") != "items,0,sku" || args[3] != `"A1"` || args[6] != float64(2) {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+func TestKeysetSQL(t *testing.T) {
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	name := "report"
+	after := cursor.Position{Name: &name, CreatedAt: time.Unix(100, 0), ID: uuid.New()}
+	got := keysetSQL(cursor.Order{By: cursor.ByName, Ascending: true}, after, arg)
+	want := `(COALESCE(c.name, '') COLLATE "C" > $3 OR (COALESCE(c.name, '') COLLATE "C" = $3 AND ` +
+		"(c.created_at > $1 OR (c.created_at = $1 AND c.id > $2))))"
+	if got != want {
+		t.Errorf("Unexpected SQL:\n got %s\nwant %s", got, want)
+	}
+	if !args[0].(time.Time).Equal(after.CreatedAt) || args[1] != after.ID || args[2] != name {
+		t.Errorf("Unexpected args: %v", args)
+	}
+
+	// Newest first by creation time needs no sort key
+	args = nil
+	got = keysetSQL(cursor.Order{}, after, arg)
+	if want := "(c.created_at < $1 OR (c.created_at = $1 AND c.id > $2))"; got != want {
+		t.Errorf("Unexpected SQL:\n got %s\nwant %s", got, want)
+	}
+}
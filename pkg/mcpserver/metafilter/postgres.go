@@ -0,0 +1,353 @@
+package metafilter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/cursor"
+)
+
+// DBTX is satisfied by pgxpool.Pool, pgx.Conn and pgx.Tx
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...interface{}) pgx.Row
+}
+
+// Scope restricts a metadata query to an owner, a tenant and a file size
+// range; zero IDs and nil bounds don't filter
+type Scope struct {
+	OwnerID  uuid.UUID
+	TenantID uuid.UUID
+	MinSize  *int64 // Inclusive
+	MaxSize  *int64 // Inclusive
+}
+
+// Querier finds the content whose metadata matches filters
+type Querier interface {
+	// MatchingIDs returns the IDs of live content in scope matching all filters
+	MatchingIDs(ctx context.Context, scope Scope, filters []*Filter) (map[uuid.UUID]bool, error)
+}
+
+// Listing selects a page of live content in scope matching all filters,
+// sorted in Order
+type Listing struct {
+	Scope
+	Filters              []*Filter
+	Status               string     // Empty lists every status
+	CreatedAfter         *time.Time // Inclusive
+	CreatedBefore        *time.Time // Inclusive
+	UpdatedAfter         *time.Time // Inclusive
+	UpdatedBefore        *time.Time // Inclusive
+	ExcludeDocumentTypes []string
+	Order                cursor.Order     // By created_at, updated_at, name or size
+	After                *cursor.Position // Lists the content after this position instead of from Offset
+	Offset               int
+	Limit                int // All content when <= 0
+}
+
+// Item is a content of a listing with its file size
+type Item struct {
+	Content *simplecontent.Content
+	Size    int64
+}
+
+// Lister is implemented by queriers that sort and page content listings in
+// the database
+type Lister interface {
+	// List returns the page of the listing and whether more content follows it
+	List(ctx context.Context, listing *Listing) ([]Item, bool, error)
+	// Count returns the number of content in the listing, regardless of its
+	// order and paging
+	Count(ctx context.Context, listing *Listing) (int, error)
+}
+
+// PostgresQuerier evaluates filters with JSONB queries on the content_metadata
+// table of the simple-content PostgreSQL schema
+type PostgresQuerier struct {
+	db DBTX
+}
+
+// NewPostgresQuerier creates a querier on db
+func NewPostgresQuerier(db DBTX) *PostgresQuerier {
+	return &PostgresQuerier{db: db}
+}
+
+// MatchingIDs returns the IDs of live content in scope matching all filters
+func (q *PostgresQuerier) MatchingIDs(ctx context.Context, scope Scope, filters []*Filter) (map[uuid.UUID]bool, error) {
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	sql := `SELECT c.id FROM content c
+		LEFT JOIN content_metadata m ON m.content_id = c.id
+		WHERE ` + scopeSQL(scope, filters, arg)
+
+	rows, err := q.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metadata: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan content id: %w", err)
+		}
+		ids[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// List returns a page of the listing, sorted and paged in the query
+func (q *PostgresQuerier) List(ctx context.Context, listing *Listing) ([]Item, bool, error) {
+	key, ok := sortKeys[listing.Order.By]
+	if !ok {
+		return nil, false, fmt.Errorf("unsupported sort key: %s", listing.Order.By)
+	}
+
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	sql := `SELECT c.id, c.tenant_id, c.owner_id, COALESCE(c.owner_type, ''), COALESCE(c.name, ''),
+		COALESCE(c.description, ''), COALESCE(c.document_type, ''), c.status, COALESCE(c.derivation_type, ''),
+		c.created_at, c.updated_at, COALESCE(m.file_size, 0)
+		FROM content c
+		LEFT JOIN content_metadata m ON m.content_id = c.id
+		WHERE ` + listingSQL(listing, arg)
+	if listing.After != nil {
+		sql += " AND " + keysetSQL(listing.Order, *listing.After, arg)
+	}
+
+	direction := " DESC"
+	if listing.Order.Ascending {
+		direction = " ASC"
+	}
+	sql += " ORDER BY "
+	if key != "" {
+		sql += key + direction + ", "
+	}
+	sql += "c.created_at" + direction + ", c.id ASC"
+	if listing.Limit > 0 {
+		sql += " LIMIT " + arg(listing.Limit+1)
+	}
+	if listing.After == nil && listing.Offset > 0 {
+		sql += " OFFSET " + arg(listing.Offset)
+	}
+
+	rows, err := q.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list content: %w", err)
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		content := &simplecontent.Content{}
+		var size int64
+		if err := rows.Scan(&content.ID, &content.TenantID, &content.OwnerID, &content.OwnerType, &content.Name,
+			&content.Description, &content.DocumentType, &content.Status, &content.DerivationType,
+			&content.CreatedAt, &content.UpdatedAt, &size); err != nil {
+			return nil, false, fmt.Errorf("failed to scan content: %w", err)
+		}
+		items = append(items, Item{Content: content, Size: size})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	if listing.Limit > 0 && len(items) > listing.Limit {
+		return items[:listing.Limit], true, nil
+	}
+	return items, false, nil
+}
+
+// Count returns the number of content in the listing
+func (q *PostgresQuerier) Count(ctx context.Context, listing *Listing) (int, error) {
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	sql := `SELECT COUNT(*) FROM content c
+		LEFT JOIN content_metadata m ON m.content_id = c.id
+		WHERE ` + listingSQL(listing, arg)
+
+	var count int
+	if err := q.db.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count content: %w", err)
+	}
+	return count, nil
+}
+
+// sortKeys maps the sort keys of a listing to their SQL expression. Names
+// compare bytewise, like cursor.Order does. Sorting by created_at needs no
+// key besides the tie-breakers.
+var sortKeys = map[string]string{
+	"":                 "",
+	cursor.ByCreatedAt: "",
+	cursor.ByUpdatedAt: "c.updated_at",
+	cursor.ByName:      `COALESCE(c.name, '') COLLATE "C"`,
+	cursor.BySize:      "COALESCE(m.file_size, 0)",
+}
+
+// scopeSQL returns the condition selecting live content in scope matching
+// all filters
+func scopeSQL(scope Scope, filters []*Filter, arg func(interface{}) string) string {
+	sql := "c.deleted_at IS NULL"
+	if scope.OwnerID != uuid.Nil {
+		sql += " AND c.owner_id = " + arg(scope.OwnerID)
+	}
+	if scope.TenantID != uuid.Nil {
+		sql += " AND c.tenant_id = " + arg(scope.TenantID)
+	}
+	if scope.MinSize != nil {
+		sql += " AND COALESCE(m.file_size, 0) >= " + arg(*scope.MinSize)
+	}
+	if scope.MaxSize != nil {
+		sql += " AND COALESCE(m.file_size, 0) <= " + arg(*scope.MaxSize)
+	}
+	if len(filters) > 0 {
+		sql += " AND " + SQL("COALESCE(m.metadata, '{}'::jsonb)", filters, arg)
+	}
+	return sql
+}
+
+// listingSQL returns the condition selecting the content of a listing,
+// regardless of its order and paging
+func listingSQL(listing *Listing, arg func(interface{}) string) string {
+	sql := scopeSQL(listing.Scope, listing.Filters, arg)
+	if listing.Status != "" {
+		sql += " AND c.status = " + arg(listing.Status)
+	}
+	for _, bound := range []struct {
+		condition string
+		value     *time.Time
+	}{
+		{"c.created_at >= ", listing.CreatedAfter},
+		{"c.created_at <= ", listing.CreatedBefore},
+		{"c.updated_at >= ", listing.UpdatedAfter},
+		{"c.updated_at <= ", listing.UpdatedBefore},
+	} {
+		if bound.value != nil {
+			sql += " AND " + bound.condition + arg(*bound.value)
+		}
+	}
+	if len(listing.ExcludeDocumentTypes) > 0 {
+		sql += " AND COALESCE(c.document_type, '') <> ALL(" + arg(listing.ExcludeDocumentTypes) + "::text[])"
+	}
+	return sql
+}
+
+// keysetSQL returns the condition selecting the content after position in
+// order: later on the sort key, then on created_at, then a higher ID
+func keysetSQL(order cursor.Order, after cursor.Position, arg func(interface{}) string) string {
+	op := "<"
+	if order.Ascending {
+		op = ">"
+	}
+	createdAt := arg(after.CreatedAt)
+	sql := fmt.Sprintf("(c.created_at %[1]s %[2]s OR (c.created_at = %[2]s AND c.id > %[3]s))", op, createdAt, arg(after.ID))
+
+	var value interface{}
+	switch order.By {
+	case cursor.ByUpdatedAt:
+		value = derefOr(after.UpdatedAt)
+	case cursor.ByName:
+		value = derefOr(after.Name)
+	case cursor.BySize:
+		value = derefOr(after.Size)
+	default:
+		return sql
+	}
+	key := sortKeys[order.By]
+	placeholder := arg(value)
+	return fmt.Sprintf("(%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND %[4]s))", key, op, placeholder, sql)
+}
+
+// derefOr returns *p, or the zero value when p is nil
+func derefOr[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+// SQL turns filters into a condition on the JSONB expression column, with
+// the same semantics as Match. arg adds a query argument and returns its
+// placeholder.
+func SQL(column string, filters []*Filter, arg func(interface{}) string) string {
+	if len(filters) == 0 {
+		return "TRUE"
+	}
+	conditions := make([]string, len(filters))
+	for i, f := range filters {
+		conditions[i] = f.sql(column, arg)
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")"
+}
+
+// sql turns the filter into a SQL condition
+func (f *Filter) sql(column string, arg func(interface{}) string) string {
+	path := make([]string, len(f.segments))
+	for i, segment := range f.segments {
+		if segment.IsKey {
+			path[i] = segment.Key
+		} else {
+			path[i] = fmt.Sprint(segment.Index)
+		}
+	}
+	// #> returns NULL for missing keys and for indexes into non-arrays
+	value := "(" + column + " #> " + arg(path) + "::text[])"
+
+	switch f.Op {
+	case OpEq:
+		return "(" + value + " = " + arg(jsonArg(f.Value)) + "::jsonb)"
+	case OpNe:
+		return "(" + value + " IS DISTINCT FROM " + arg(jsonArg(f.Value)) + "::jsonb)"
+	case OpIn:
+		return "(" + value + " IN (SELECT jsonb_array_elements(" + arg(jsonArg(f.Value)) + "::jsonb)))"
+	case OpExists:
+		if f.Value.(bool) {
+			return "(" + value + " IS NOT NULL)"
+		}
+		return "(" + value + " IS NULL)"
+	}
+
+	op := map[Op]string{OpGt: ">", OpGte: ">=", OpLt: "<", OpLte: "<="}[f.Op]
+	if f.isDate {
+		// Only strings that look like dates are cast, so other values can't fail the query
+		return fmt.Sprintf(`(CASE WHEN jsonb_typeof(%[1]s) = 'string' AND (%[1]s #>> '{}') ~ '^\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2}))?$'
+			THEN (%[1]s #>> '{}')::timestamptz %[2]s %[3]s ELSE FALSE END)`, value, op, arg(f.date))
+	}
+	return fmt.Sprintf("(CASE WHEN jsonb_typeof(%[1]s) = 'number' THEN (%[1]s)::numeric %[2]s %[3]s ELSE FALSE END)",
+		value, op, arg(f.number))
+}
+
+// jsonArg encodes a filter value for a jsonb parameter
+func jsonArg(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "null"
+	}
+	return string(data)
+}
@@ -0,0 +1,196 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/admin"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/cursor"
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/search"
+)
+
+// parseCursor decodes the cursor argument of a listing tool. It returns nil
+// when no cursor is given.
+func (s *Server) parseCursor(tool string, params map[string]interface{}) (*cursor.Position, error) {
+	token := getStringOr(params, "cursor", "")
+	if token == "" {
+		return nil, nil
+	}
+	if getIntOr(params, "offset", 0) > 0 {
+		return nil, mcperrors.NewValidationError("cursor", errors.New("cursor cannot be combined with offset"))
+	}
+
+	position, err := s.cursors.Decode(cursor.Listing(tool, params), token)
+	if err != nil {
+		return nil, mcperrors.NewValidationError("cursor", err)
+	}
+	return &position, nil
+}
+
+// nextCursor returns the cursor continuing a listing tool in order after position
+func (s *Server) nextCursor(tool string, params map[string]interface{}, order cursor.Order, position cursor.Position) string {
+	return s.cursors.Encode(cursor.Listing(tool, params), order.Key(position))
+}
+
+// parseOrder reads the sort_by and order arguments of a listing tool. keys
+// are the sort keys the tool accepts, the first one being the default.
+// Listings are in descending order by default.
+func parseOrder(params map[string]interface{}, keys ...string) (cursor.Order, error) {
+	order := cursor.Order{By: getStringOr(params, "sort_by", keys[0])}
+	if !slices.Contains(keys, order.By) {
+		return order, mcperrors.NewValidationError("sort_by", fmt.Errorf("must be one of %s", strings.Join(keys, ", ")))
+	}
+
+	switch getStringOr(params, "order", "desc") {
+	case "asc":
+		order.Ascending = true
+	case "desc":
+	default:
+		return order, mcperrors.NewValidationError("order", errors.New("must be asc or desc"))
+	}
+	return order, nil
+}
+
+// contentPosition returns the position of content in listings, without its size
+func contentPosition(content *simplecontent.Content) cursor.Position {
+	name, updatedAt := content.Name, content.UpdatedAt
+	return cursor.Position{Name: &name, UpdatedAt: &updatedAt, CreatedAt: content.CreatedAt, ID: content.ID}
+}
+
+// contentPositions returns the position function of contents in a listing.
+// Sorting by size needs the size of each content, loaded from its metadata.
+func (s *Server) contentPositions(ctx context.Context, contents []*simplecontent.Content, order cursor.Order) (func(*simplecontent.Content) cursor.Position, error) {
+	if order.By != cursor.BySize {
+		return contentPosition, nil
+	}
+
+	sizes := make(map[uuid.UUID]int64, len(contents))
+	for _, content := range contents {
+		metadata, err := s.loadContentMetadata(ctx, content.ID)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		sizes[content.ID] = metadata.FileSize
+	}
+	return func(content *simplecontent.Content) cursor.Position {
+		position := contentPosition(content)
+		size := sizes[content.ID]
+		position.Size = &size
+		return position
+	}, nil
+}
+
+// listPage lists a page of content sorted and paged in the database by
+// lister. It returns the position function of the page, which carries the
+// sizes the listing loaded, and whether more content follows the page.
+func listPage(ctx context.Context, lister metafilter.Lister, listing *metafilter.Listing) ([]*simplecontent.Content, func(*simplecontent.Content) cursor.Position, bool, error) {
+	items, more, err := lister.List(ctx, listing)
+	if err != nil {
+		return nil, nil, false, mcperrors.NewInternalError(fmt.Errorf("content listing failed: %w", err))
+	}
+
+	contents := make([]*simplecontent.Content, len(items))
+	sizes := make(map[uuid.UUID]int64, len(items))
+	for i, item := range items {
+		contents[i] = item.Content
+		sizes[item.Content.ID] = item.Size
+	}
+	return contents, func(content *simplecontent.Content) cursor.Position {
+		position := contentPosition(content)
+		size := sizes[content.ID]
+		position.Size = &size
+		return position
+	}, more, nil
+}
+
+// searchHit returns a search hit at a cursor position, for query.After
+func searchHit(position *cursor.Position) *search.Hit {
+	hit := &search.Hit{ID: position.ID, CreatedAt: position.CreatedAt}
+	if position.Score != nil {
+		hit.Score = *position.Score
+	}
+	if position.UpdatedAt != nil {
+		hit.UpdatedAt = *position.UpdatedAt
+	}
+	if position.Name != nil {
+		hit.Name = *position.Name
+	}
+	if position.Size != nil {
+		hit.Size = *position.Size
+	}
+	return hit
+}
+
+// offsetPage returns the page of items starting at offset, at most limit of
+// them, and whether more items follow it
+func offsetPage[T any](items []T, offset, limit int) ([]T, bool) {
+	if offset > len(items) {
+		offset = len(items)
+	}
+	return cursor.Page(items[offset:], nil, cursor.Order{}, nil, limit)
+}
+
+// adminPage lists a page of content by creation time through the admin
+// service, skipping collections. Pages after a cursor start at its
+// created_at, past the content at or before its position. It reports
+// whether more content follows the page.
+func (s *Server) adminPage(ctx context.Context, filters admin.ContentFilters, order cursor.Order, after *cursor.Position, offset, limit int) ([]*simplecontent.Content, bool, error) {
+	sortBy, sortOrder := "created_at", "DESC"
+	if order.Ascending {
+		sortOrder = "ASC"
+	}
+	filters.SortBy = &sortBy
+	filters.SortOrder = &sortOrder
+	if after != nil {
+		// The time bounds are inclusive, so content created at the same time
+		// as the cursor is fetched again and skipped by position. They narrow
+		// the created_after or created_before filter of the listing.
+		createdAt := after.CreatedAt
+		if order.Ascending {
+			if filters.CreatedAfter == nil || createdAt.After(*filters.CreatedAfter) {
+				filters.CreatedAfter = &createdAt
+			}
+		} else if filters.CreatedBefore == nil || createdAt.Before(*filters.CreatedBefore) {
+			filters.CreatedBefore = &createdAt
+		}
+	}
+
+	batch := limit + 1
+	var contents []*simplecontent.Content
+	for {
+		pageOffset := offset
+		filters.Limit = &batch
+		filters.Offset = &pageOffset
+		resp, err := s.adminService.ListAllContents(ctx, admin.ListContentsRequest{Filters: filters})
+		if err != nil {
+			return nil, false, err
+		}
+		for _, content := range resp.Contents {
+			if !isCollection(content) && (after == nil || order.Before(*after, contentPosition(content))) {
+				contents = append(contents, content)
+			}
+		}
+
+		// The repository orders content created at the same time arbitrarily,
+		// so keep fetching until the page ends between two timestamps
+		if len(resp.Contents) < batch {
+			break
+		}
+		if len(contents) > limit && !contents[len(contents)-1].CreatedAt.Equal(contents[limit-1].CreatedAt) {
+			break
+		}
+		offset += batch
+	}
+
+	cursor.Sort(contents, contentPosition, order)
+	page, more := cursor.Page(contents, contentPosition, order, nil, limit)
+	return page, more, nil
+}
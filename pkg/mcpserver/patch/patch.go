@@ -0,0 +1,400 @@
+// Package patch implements JSON Merge Patch (RFC 7396), JSON Patch (RFC 6902)
+// and a structural diff for the JSON-like documents used as content metadata.
+//
+// Documents are the generic values produced by encoding/json:
+// map[string]interface{}, []interface{}, string, float64, bool and nil.
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operation is a single RFC 6902 JSON Patch operation
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Change describes a difference between two documents at a JSON Pointer path
+type Change struct {
+	Op    string      `json:"op"` // add, remove, replace
+	Path  string      `json:"path"`
+	Old   interface{} `json:"old,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Merge applies an RFC 7396 JSON Merge Patch to target and returns the result.
+// Keys set to null in the patch are removed; nested objects are merged
+// recursively; any other value replaces the target value. target is not modified.
+func Merge(target, patch map[string]interface{}) map[string]interface{} {
+	result, _ := mergeValue(target, patch).(map[string]interface{})
+	return result
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return Clone(patch)
+	}
+
+	result := map[string]interface{}{}
+	if targetObj, ok := target.(map[string]interface{}); ok {
+		for k, v := range targetObj {
+			result[k] = Clone(v)
+		}
+	}
+
+	for k, v := range patchObj {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		result[k] = mergeValue(result[k], v)
+	}
+	return result
+}
+
+// ParseOperations converts a decoded JSON array into patch operations
+func ParseOperations(raw interface{}) ([]Operation, error) {
+	if _, ok := raw.([]interface{}); !ok {
+		return nil, fmt.Errorf("json patch must be an array of operations")
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []Operation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("invalid json patch: %w", err)
+	}
+
+	// A null value is valid, so check for the member itself (RFC 6902 §4.1)
+	for i, op := range ops {
+		if op.Op != "add" && op.Op != "replace" && op.Op != "test" {
+			continue
+		}
+		if _, ok := raw.([]interface{})[i].(map[string]interface{})["value"]; !ok {
+			return nil, fmt.Errorf("operation %d (%s %s): missing value", i, op.Op, op.Path)
+		}
+	}
+	return ops, nil
+}
+
+// Apply applies RFC 6902 operations to doc and returns the patched document.
+// Operations are applied atomically: doc is not modified and nothing is
+// returned if any operation fails.
+func Apply(doc map[string]interface{}, ops []Operation) (map[string]interface{}, error) {
+	var root interface{} = Clone(doc)
+	if root == nil {
+		root = map[string]interface{}{}
+	}
+
+	for i, op := range ops {
+		var err error
+		root, err = applyOperation(root, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	result, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("patched document must be an object")
+	}
+	return result, nil
+}
+
+func applyOperation(root interface{}, op Operation) (interface{}, error) {
+	tokens, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		return add(root, tokens, Clone(op.Value))
+
+	case "remove":
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("cannot remove the document root")
+		}
+		return remove(root, tokens)
+
+	case "replace":
+		if len(tokens) == 0 {
+			return Clone(op.Value), nil
+		}
+		root, err := remove(root, tokens)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, tokens, Clone(op.Value))
+
+	case "move":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Path != op.From && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move a value into one of its children")
+		}
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if len(from) == 0 {
+			return add(root, tokens, value)
+		}
+		root, err = remove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, tokens, value)
+
+	case "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, tokens, Clone(value))
+
+	case "test":
+		value, err := get(root, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !Equal(value, op.Value) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return root, nil
+
+	default:
+		return nil, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// Diff returns the changes that turn before into after. Objects are compared
+// key by key; arrays and scalars are compared as whole values.
+func Diff(before, after interface{}) []Change {
+	changes := []Change{}
+	diffValue("", before, after, &changes)
+	return changes
+}
+
+func diffValue(path string, before, after interface{}, changes *[]Change) {
+	beforeObj, beforeIsObj := before.(map[string]interface{})
+	afterObj, afterIsObj := after.(map[string]interface{})
+
+	if !beforeIsObj || !afterIsObj {
+		if !Equal(before, after) {
+			*changes = append(*changes, Change{Op: "replace", Path: path, Old: before, Value: after})
+		}
+		return
+	}
+
+	keys := make([]string, 0, len(beforeObj)+len(afterObj))
+	for k := range beforeObj {
+		keys = append(keys, k)
+	}
+	for k := range afterObj {
+		if _, ok := beforeObj[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		childPath := path + "/" + escapeToken(k)
+		oldValue, inBefore := beforeObj[k]
+		newValue, inAfter := afterObj[k]
+		switch {
+		case !inBefore:
+			*changes = append(*changes, Change{Op: "add", Path: childPath, Value: newValue})
+		case !inAfter:
+			*changes = append(*changes, Change{Op: "remove", Path: childPath, Old: oldValue})
+		default:
+			diffValue(childPath, oldValue, newValue, changes)
+		}
+	}
+}
+
+// Equal reports whether two documents encode to the same JSON
+func Equal(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+// Clone returns a deep copy of a document
+func Clone(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if val == nil {
+			return val
+		}
+		result := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			result[k] = Clone(child)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for i, child := range val {
+			result[i] = Clone(child)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func escapeToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// get returns the value at tokens
+func get(node interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found: %s", token)
+			}
+			node = child
+		case []interface{}:
+			i, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot traverse into %T", node)
+		}
+	}
+	return node, nil
+}
+
+// add inserts value at tokens and returns the updated node
+func add(node interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return update(node, tokens, func(container interface{}, key string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[key] = value
+			return c, nil
+		case []interface{}:
+			if key == "-" {
+				return append(c, value), nil
+			}
+			i, err := arrayIndex(key, len(c))
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		default:
+			return nil, fmt.Errorf("cannot add to %T", container)
+		}
+	})
+}
+
+// remove deletes the value at tokens and returns the updated node
+func remove(node interface{}, tokens []string) (interface{}, error) {
+	return update(node, tokens, func(container interface{}, key string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			if _, ok := c[key]; !ok {
+				return nil, fmt.Errorf("path not found: %s", key)
+			}
+			delete(c, key)
+			return c, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove from %T", container)
+		}
+	})
+}
+
+// update walks to the parent of tokens and replaces it with the result of fn
+func update(node interface{}, tokens []string, fn func(container interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("path not found: %s", tokens[0])
+		}
+		updated, err := update(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = updated
+		return n, nil
+	case []interface{}:
+		i, err := arrayIndex(tokens[0], len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(n[i], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("cannot traverse into %T", node)
+	}
+}
+
+// arrayIndex parses an array index token, which must be in [0, max]
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max {
+		return 0, fmt.Errorf("array index %q out of range", token)
+	}
+	return i, nil
+}
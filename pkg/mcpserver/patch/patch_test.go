@@ -0,0 +1,147 @@
+package patch
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", s, err)
+	}
+	return v
+}
+
+func TestMerge(t *testing.T) {
+	// Examples from RFC 7396 Appendix A
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		target := decode(t, tt.target).(map[string]interface{})
+		before := Clone(target)
+
+		got := Merge(target, decode(t, tt.patch).(map[string]interface{}))
+		if !Equal(got, decode(t, tt.want)) {
+			t.Errorf("Merge(%s, %s) = %v, want %s", tt.target, tt.patch, got, tt.want)
+		}
+		if !Equal(target, before) {
+			t.Errorf("Merge modified its target: %v", target)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	// Examples from RFC 6902 Appendix A
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{"add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, false},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, false},
+		{"append array element", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"baz"}]`, `{"foo":["bar","baz"]}`, false},
+		{"remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, false},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, false},
+		{"replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, false},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, false},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, false},
+		{"copy value", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`, false},
+		{"test success", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`, false},
+		{"test failure", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ``, true},
+		{"add nested member to missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ``, true},
+		{"escaped pointer", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`, `{"~1":10}`, false},
+		{"remove missing", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, ``, true},
+		{"index out of range", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/5","value":"x"}]`, ``, true},
+		{"unknown op", `{}`, `[{"op":"frobnicate","path":"/a"}]`, ``, true},
+		{"root must stay an object", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":[1]}]`, ``, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decode(t, tt.doc).(map[string]interface{})
+			before := Clone(doc)
+
+			ops, err := ParseOperations(decode(t, tt.patch))
+			if err != nil {
+				t.Fatalf("ParseOperations failed: %v", err)
+			}
+
+			got, err := Apply(doc, ops)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", got)
+				}
+			} else if err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			} else if !Equal(got, decode(t, tt.want)) {
+				t.Errorf("Apply = %v, want %s", got, tt.want)
+			}
+
+			if !Equal(doc, before) {
+				t.Errorf("Apply modified its input: %v", doc)
+			}
+		})
+	}
+}
+
+func TestParseOperationsRejectsObject(t *testing.T) {
+	if _, err := ParseOperations(map[string]interface{}{"op": "add"}); err == nil {
+		t.Error("Expected an error for a non-array patch")
+	}
+}
+
+func TestParseOperationsRequiresValue(t *testing.T) {
+	for _, patch := range []string{
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"remove","path":"/b"},{"op":"replace","path":"/a"}]`,
+		`[{"op":"test","path":"/a"}]`,
+	} {
+		if _, err := ParseOperations(decode(t, patch)); err == nil {
+			t.Errorf("Expected an error for %s", patch)
+		}
+	}
+
+	// An explicit null is a value
+	if _, err := ParseOperations(decode(t, `[{"op":"add","path":"/a","value":null}]`)); err != nil {
+		t.Errorf("Expected a null value to be accepted: %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := decode(t, `{"a":1,"b":{"c":"x","d":true},"e":[1,2],"gone":"y"}`)
+	after := decode(t, `{"a":1,"b":{"c":"z","d":true},"e":[1,2,3],"new":"v"}`)
+
+	changes := Diff(before, after)
+	want := []Change{
+		{Op: "replace", Path: "/b/c", Old: "x", Value: "z"},
+		{Op: "replace", Path: "/e", Old: []interface{}{1.0, 2.0}, Value: []interface{}{1.0, 2.0, 3.0}},
+		{Op: "remove", Path: "/gone", Old: "y"},
+		{Op: "add", Path: "/new", Value: "v"},
+	}
+	if !Equal(changes, want) {
+		t.Errorf("Diff = %+v, want %+v", changes, want)
+	}
+
+	if changes := Diff(before, Clone(before)); len(changes) != 0 {
+		t.Errorf("Expected no changes for equal documents, got %+v", changes)
+	}
+}
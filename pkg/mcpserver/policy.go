@@ -0,0 +1,178 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/jobs"
+)
+
+// DerivationPolicy maps MIME types to the derivations expected for content
+// of that type. Keys are exact MIME types ("application/pdf"), type
+// wildcards ("image/*") or "*"; the most specific key wins. Each derivation
+// names a variant ("thumbnail_256") or a derivation type ("preview"), which
+// any variant of that type satisfies.
+type DerivationPolicy map[string][]string
+
+// Expected returns the derivations expected for content of the given MIME type
+func (p DerivationPolicy) Expected(mimeType string) []string {
+	mimeType = jobs.NormalizeMimeType(mimeType)
+	if expected, ok := p[mimeType]; ok {
+		return expected
+	}
+	if slash := strings.IndexByte(mimeType, '/'); slash > 0 {
+		if expected, ok := p[mimeType[:slash]+"/*"]; ok {
+			return expected
+		}
+	}
+	return p["*"]
+}
+
+// validate checks that keys look like MIME types and derivations are named
+func (p DerivationPolicy) validate() error {
+	for key, derivations := range p {
+		if key != "*" && !strings.Contains(key, "/") {
+			return fmt.Errorf("%q is not a MIME type", key)
+		}
+		for _, derivation := range derivations {
+			if strings.TrimSpace(derivation) == "" {
+				return fmt.Errorf("%s has an empty derivation", key)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseDerivationPolicy parses a policy written as
+// "image/*=thumbnail_256,thumbnail_1024;application/pdf=preview,text"
+func ParseDerivationPolicy(s string) (DerivationPolicy, error) {
+	policy := DerivationPolicy{}
+	for _, entry := range strings.Split(s, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		mimeType, list, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid derivation policy entry %q: expected type=derivation,...", entry)
+		}
+
+		var derivations []string
+		for _, derivation := range strings.Split(list, ",") {
+			if derivation = strings.TrimSpace(derivation); derivation != "" {
+				derivations = append(derivations, derivation)
+			}
+		}
+		policy[jobs.NormalizeMimeType(mimeType)] = derivations
+	}
+
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// derivationReport compares the derivations the policy expects for content
+// with its derived content and derivation jobs. Each expected derivation is
+// present (processed), pending (being derived or queued), failed (its latest
+// attempt failed) or missing.
+func (s *Server) derivationReport(ctx context.Context, content *simplecontent.Content, derivedList []*simplecontent.DerivedContent) (map[string]interface{}, error) {
+	expected := s.config.DerivationPolicy.Expected(content.DocumentType)
+
+	jobList, err := s.jobQueue.List(ctx, jobs.Filter{ContentID: content.ID})
+	if err != nil {
+		return nil, mcperrors.NewInternalError(err)
+	}
+
+	present, pending, failed, missing := []string{}, []string{}, []string{}, []string{}
+	for _, derivation := range expected {
+		state := "missing"
+		for _, derived := range derivedList {
+			if derived.Variant != derivation && derived.DerivationType != derivation {
+				continue
+			}
+			switch simplecontent.ContentStatus(derived.Status) {
+			case simplecontent.ContentStatusProcessed:
+				state = "present"
+			case simplecontent.ContentStatusFailed:
+				if state == "missing" {
+					state = "failed"
+				}
+			case simplecontent.ContentStatusDeleted:
+			default:
+				if state != "present" {
+					state = "pending"
+				}
+			}
+		}
+
+		if state != "present" && state != "pending" {
+			// Jobs are listed newest first; the latest job producing the
+			// derivation decides between pending and failed
+		latest:
+			for _, job := range jobList {
+				if !s.jobProduces(job, derivation) {
+					continue
+				}
+				switch {
+				case !job.Status.Done():
+					state = "pending"
+				case job.Status == jobs.StatusFailed:
+					state = "failed"
+				}
+				break latest
+			}
+		}
+
+		switch state {
+		case "present":
+			present = append(present, derivation)
+		case "pending":
+			pending = append(pending, derivation)
+		case "failed":
+			failed = append(failed, derivation)
+		default:
+			missing = append(missing, derivation)
+		}
+	}
+
+	completeness := 100.0
+	if len(expected) > 0 {
+		completeness = math.Round(float64(len(present))*1000/float64(len(expected))) / 10
+	}
+
+	return map[string]interface{}{
+		"expected":     append([]string{}, expected...),
+		"present":      present,
+		"pending":      pending,
+		"failed":       failed,
+		"missing":      missing,
+		"completeness": completeness,
+		"complete":     len(present) == len(expected),
+	}, nil
+}
+
+// jobProduces reports whether a job derives the given variant or derivation type
+func (s *Server) jobProduces(job *jobs.Job, derivation string) bool {
+	if job.DerivationType == derivation {
+		return true
+	}
+	processor := s.processors.Get(job.Processor)
+	if processor == nil {
+		return false
+	}
+	variants, err := processor.Variants(job)
+	if err != nil {
+		return false
+	}
+	for _, variant := range variants {
+		if variant == derivation {
+			return true
+		}
+	}
+	return false
+}
@@ -145,12 +145,14 @@ This returns all content tagged with both "invoice" and "2024".`
 
 Use the list_by_status tool with:
 - status: One of: created, uploading, uploaded, processing, processed, failed, archived
-- owner_id: Filter by owner (optional)
-- limit: Maximum results (default 100)
+- owner_id or tenant_id: Whose content to list
+- limit: Maximum results (default 100); pass next_cursor back as cursor for more
+- count_only: Only return the total
 
 Example:
 {
   "status": "failed",
+  "owner_id": "<owner-uuid>",
   "limit": 50
 }
 
@@ -166,12 +168,15 @@ This is useful for monitoring and finding content that needs attention.`
 2. By Status:
    - Use list_by_status with desired status
    - Useful for finding failed uploads or pending processing
-   - Example: {"status": "uploaded"}
+   - Example: {"status": "uploaded", "owner_id": "<owner-uuid>"}
 
 3. By Query:
    - Use search_content with query string
-   - Searches in name, description, and metadata fields
+   - Matches every term against name, tags and description
+   - Fields, phrases and operators narrow the search (see the schema://search-query resource)
+   - Results are ranked by relevance with a score and highlighted snippets
    - Example: {"query": "logo", "owner_id": "..."}
+   - Example: {"query": "tag:invoice mime:application/pdf created:>2025-01-01 -draft", "owner_id": "..."}
 
 4. List All (with filters):
    - Use list_content with owner_id, tenant_id, etc.
@@ -260,6 +265,8 @@ func (s *Server) handleStatusMonitoringPrompt(ctx context.Context, req *mcp.GetP
    - ready: Boolean indicating if content is ready to use
    - has_thumbnails: Boolean for thumbnail availability
    - has_previews: Boolean for preview availability
+   - derivations: Expected derivations for the MIME type and which are
+     present, pending, failed or missing, with a completeness percentage
 
 2. List Content by Status:
    Use list_by_status to find all content in a specific state:
@@ -275,16 +282,21 @@ func (s *Server) handleStatusMonitoringPrompt(ctx context.Context, req *mcp.GetP
    - "uploaded": Ready and available
    - "created": Awaiting processing
 
-3. Polling Pattern:
-   For async operations, poll get_content_status until ready=true:
-
-   while (!ready) {
-     result = get_content_status(content_id)
-     if (result.ready) break
-     if (result.status == "failed") handle_error()
-     wait(5 seconds)
+3. Waiting for Processing:
+   Instead of polling get_content_status, use wait_for_content:
+   {
+     "content_id": "550e8400-e29b-41d4-a716-446655440000",
+     "variants": ["thumbnail_256"],
+     "timeout_seconds": 60
    }
 
+   It returns the same status fields once the content is ready (or has
+   the given status / processed variants), plus:
+   - satisfied: Whether the condition was met
+   - timed_out: True if the timeout expired first
+   - missing_variants: Variants that are not processed yet
+   If the status is "failed", handle the error instead of waiting again.
+
 4. System Overview:
    Read the stats://system resource for aggregate statistics:
    - Total content count
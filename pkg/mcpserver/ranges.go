@@ -0,0 +1,188 @@
+package mcpserver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent/admin"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/search"
+)
+
+// relativeTime matches times relative to now, e.g. -7d or -12h
+var relativeTime = regexp.MustCompile(`^([+-])(\d+)([hdw])$`)
+
+// rangeFilter holds the created_after, created_before, updated_after,
+// updated_before, min_size and max_size arguments of a listing tool. Bounds
+// are inclusive; nil bounds don't filter.
+type rangeFilter struct {
+	createdAfter  *time.Time
+	createdBefore *time.Time
+	updatedAfter  *time.Time
+	updatedBefore *time.Time
+	minSize       *int64
+	maxSize       *int64
+}
+
+// parseRangeFilter reads the range arguments of a listing tool. Relative
+// times are resolved against now.
+func parseRangeFilter(params map[string]interface{}, now time.Time) (rangeFilter, error) {
+	var f rangeFilter
+	var err error
+	for _, bound := range []struct {
+		name   string
+		target **time.Time
+	}{
+		{"created_after", &f.createdAfter},
+		{"created_before", &f.createdBefore},
+		{"updated_after", &f.updatedAfter},
+		{"updated_before", &f.updatedBefore},
+	} {
+		if *bound.target, err = parseTimeBound(params, bound.name, now); err != nil {
+			return f, err
+		}
+	}
+	if f.minSize, err = parseSizeBound(params, "min_size"); err != nil {
+		return f, err
+	}
+	if f.maxSize, err = parseSizeBound(params, "max_size"); err != nil {
+		return f, err
+	}
+
+	if f.createdAfter != nil && f.createdBefore != nil && f.createdAfter.After(*f.createdBefore) {
+		return f, mcperrors.NewValidationError("created_after", errors.New("must not be later than created_before"))
+	}
+	if f.updatedAfter != nil && f.updatedBefore != nil && f.updatedAfter.After(*f.updatedBefore) {
+		return f, mcperrors.NewValidationError("updated_after", errors.New("must not be later than updated_before"))
+	}
+	if f.minSize != nil && f.maxSize != nil && *f.minSize > *f.maxSize {
+		return f, mcperrors.NewValidationError("min_size", errors.New("must not be greater than max_size"))
+	}
+	return f, nil
+}
+
+// parseTimeBound parses a time argument: an RFC 3339 timestamp, a date
+// (midnight UTC) or a time relative to now such as -7d, -12h or -2w
+func parseTimeBound(params map[string]interface{}, name string, now time.Time) (*time.Time, error) {
+	value := getStringOr(params, name, "")
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return &t, nil
+	}
+	if match := relativeTime.FindStringSubmatch(value); match != nil {
+		n, err := strconv.Atoi(match[2])
+		if err == nil {
+			if match[1] == "-" {
+				n = -n
+			}
+			var t time.Time
+			switch match[3] {
+			case "h":
+				t = now.Add(time.Duration(n) * time.Hour)
+			case "d":
+				t = now.AddDate(0, 0, n)
+			case "w":
+				t = now.AddDate(0, 0, 7*n)
+			}
+			t = t.UTC()
+			return &t, nil
+		}
+	}
+	return nil, mcperrors.NewValidationError(name, fmt.Errorf("invalid time %q: use RFC 3339, YYYY-MM-DD or a relative time such as -7d, -12h or -2w", value))
+}
+
+// parseSizeBound parses a size argument: a number of bytes or a string such
+// as 100KB or 1.5MB
+func parseSizeBound(params map[string]interface{}, name string) (*int64, error) {
+	var size int64
+	switch value := params[name].(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		if value < 0 {
+			return nil, mcperrors.NewValidationError(name, errors.New("cannot be negative"))
+		}
+		size = int64(value)
+	case string:
+		parsed, err := search.ParseSize(value)
+		if err != nil {
+			return nil, mcperrors.NewValidationError(name, err)
+		}
+		size = parsed
+	default:
+		return nil, mcperrors.NewValidationError(name, errors.New("must be a number of bytes or a size such as 5MB"))
+	}
+	return &size, nil
+}
+
+// hasSize reports whether f bounds the size
+func (f rangeFilter) hasSize() bool {
+	return f.minSize != nil || f.maxSize != nil
+}
+
+// matchesTimes reports whether creation and update times are within f
+func (f rangeFilter) matchesTimes(createdAt, updatedAt time.Time) bool {
+	return timeWithin(createdAt, f.createdAfter, f.createdBefore) && timeWithin(updatedAt, f.updatedAfter, f.updatedBefore)
+}
+
+// adminFilters sets the time bounds of f on admin content filters, which
+// apply them in the repository
+func (f rangeFilter) adminFilters(filters *admin.ContentFilters) {
+	filters.CreatedAfter = f.createdAfter
+	filters.CreatedBefore = f.createdBefore
+	filters.UpdatedAfter = f.updatedAfter
+	filters.UpdatedBefore = f.updatedBefore
+}
+
+// listingFilters sets the time bounds of f on a metafilter listing, which
+// applies them in the database
+func (f rangeFilter) listingFilters(listing *metafilter.Listing) {
+	listing.CreatedAfter = f.createdAfter
+	listing.CreatedBefore = f.createdBefore
+	listing.UpdatedAfter = f.updatedAfter
+	listing.UpdatedBefore = f.updatedBefore
+}
+
+// searchConditions returns the bounds of f as search query conditions
+func (f rangeFilter) searchConditions() []search.Expr {
+	var conditions []search.Expr
+	timeCondition := func(field string, op search.Op, t *time.Time) {
+		if t != nil {
+			conditions = append(conditions, &search.CompareExpr{Field: field, Op: op, Value: t.Format(time.RFC3339Nano), Time: *t})
+		}
+	}
+	timeCondition("created", search.OpGe, f.createdAfter)
+	timeCondition("created", search.OpLe, f.createdBefore)
+	timeCondition("updated", search.OpGe, f.updatedAfter)
+	timeCondition("updated", search.OpLe, f.updatedBefore)
+
+	sizeCondition := func(op search.Op, size *int64) {
+		if size != nil {
+			conditions = append(conditions, &search.CompareExpr{Field: "size", Op: op, Value: strconv.FormatInt(*size, 10), Size: *size})
+		}
+	}
+	sizeCondition(search.OpGe, f.minSize)
+	sizeCondition(search.OpLe, f.maxSize)
+	return conditions
+}
+
+// timeWithin reports whether t is within the inclusive bounds after and before
+func timeWithin(t time.Time, after, before *time.Time) bool {
+	return (after == nil || !t.Before(*after)) && (before == nil || !t.After(*before))
+}
+
+// sizeWithin reports whether size is within the inclusive bounds minSize and maxSize
+func sizeWithin(size int64, minSize, maxSize *int64) bool {
+	return (minSize == nil || size >= *minSize) && (maxSize == nil || size <= *maxSize)
+}
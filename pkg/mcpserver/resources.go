@@ -8,8 +8,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
-	"github.com/tendant/simple-content/pkg/simplecontent"
 	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/search"
+	"github.com/tendant/simple-content/pkg/simplecontent"
 )
 
 // registerResources registers all MCP resources with the server
@@ -26,6 +27,12 @@ func (s *Server) registerResources() error {
 			Description: "Content metadata by ID",
 			MIMEType:    "application/json",
 		},
+		{
+			URITemplate: "collection://{id}",
+			Name:        "collection",
+			Description: "Collection with its content and nested collections",
+			MIMEType:    "application/json",
+		},
 	}
 
 	for _, template := range templates {
@@ -44,6 +51,12 @@ func (s *Server) registerResources() error {
 			Description: "JSON schema for Content entity",
 			MIMEType:    "application/schema+json",
 		},
+		{
+			URI:         searchSyntaxURI,
+			Name:        "search-query-syntax",
+			Description: "Query language of the search_content tool",
+			MIMEType:    "text/markdown",
+		},
 		{
 			URI:         "stats://system",
 			Name:        "system-stats",
@@ -68,6 +81,8 @@ func (s *Server) getResourceTemplateHandler(name string) mcp.ResourceHandler {
 	switch name {
 	case "content":
 		return s.handleContentResource
+	case "collection":
+		return s.handleCollectionResource
 	default:
 		return nil
 	}
@@ -78,6 +93,8 @@ func (s *Server) getResourceHandler(name string) mcp.ResourceHandler {
 	switch name {
 	case "content-schema":
 		return s.handleContentSchemaResource
+	case "search-query-syntax":
+		return s.handleSearchSyntaxResource
 	case "system-stats":
 		return s.handleSystemStatsResource
 	default:
@@ -107,6 +124,10 @@ func (s *Server) handleContentResource(ctx context.Context, req *mcp.ReadResourc
 		return nil, mcperrors.NewValidationError("id", err)
 	}
 
+	if err := s.checkExpired(ctx, contentID); err != nil {
+		return nil, err
+	}
+
 	// Check for /details suffix
 	if len(parts) == 2 && parts[1] == "details" {
 		return s.handleContentDetailsResource(ctx, contentID, uri)
@@ -145,6 +166,52 @@ func (s *Server) handleContentResource(ctx context.Context, req *mcp.ReadResourc
 	}, nil
 }
 
+// handleCollectionResource handles collection://{id}
+func (s *Server) handleCollectionResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+
+	if !strings.HasPrefix(uri, "collection://") {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	collectionID, err := uuid.Parse(strings.TrimPrefix(uri, "collection://"))
+	if err != nil {
+		return nil, mcperrors.NewValidationError("id", err)
+	}
+
+	collection, metadata, err := s.getCollection(ctx, collectionID)
+	if err != nil {
+		if mcperrors.IsNotFound(err) {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		return nil, err
+	}
+
+	listing, err := s.listCollectionMembers(ctx, collectionID, metadata.Metadata, false, map[uuid.UUID]bool{collectionID: true})
+	if err != nil {
+		return nil, err
+	}
+
+	data := collectionSummary(collection, metadata.Metadata)
+	data["collections"] = listing.collections
+	data["items"] = listing.items
+
+	jsonData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal collection: %w", err)
+	}
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{
+				URI:      uri,
+				MIMEType: "application/json",
+				Text:     string(jsonData),
+			},
+		},
+	}, nil
+}
+
 // handleContentDetailsResource handles content://{id}/details
 func (s *Server) handleContentDetailsResource(ctx context.Context, contentID uuid.UUID, uri string) (*mcp.ReadResourceResult, error) {
 	// Get content details
@@ -228,6 +295,15 @@ func (s *Server) handleContentSchemaResource(ctx context.Context, req *mcp.ReadR
 		"required": []string{"id", "owner_id", "name", "status"},
 	}
 
+	// Document the lifecycle enforced by transition_content_status
+	transitions := make(map[string][]string, len(statusTransitions))
+	for from, targets := range statusTransitions {
+		for _, to := range targets {
+			transitions[string(from)] = append(transitions[string(from)], string(to))
+		}
+	}
+	schema["x-status-transitions"] = transitions
+
 	jsonData, err := json.MarshalIndent(schema, "", "  ")
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal schema: %w", err)
@@ -244,6 +320,19 @@ func (s *Server) handleContentSchemaResource(ctx context.Context, req *mcp.ReadR
 	}, nil
 }
 
+// handleSearchSyntaxResource returns the search_content query grammar
+func (s *Server) handleSearchSyntaxResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{
+				URI:      req.Params.URI,
+				MIMEType: "text/markdown",
+				Text:     search.Grammar,
+			},
+		},
+	}, nil
+}
+
 // handleSystemStatsResource returns system statistics
 func (s *Server) handleSystemStatsResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
 	// Get counts by status
@@ -0,0 +1,365 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/admin"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/extract"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/search"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/semantic"
+)
+
+// searchSyntaxURI is the resource documenting the search_content query language
+const searchSyntaxURI = "schema://search-query"
+
+// maxBodyIndexSize is the number of leading bytes of a content body indexed for body search
+const maxBodyIndexSize = 10 << 20
+
+// searchDocument builds the search index document of a content
+func searchDocument(content *simplecontent.Content, metadata *simplecontent.ContentMetadata) *search.Document {
+	return &search.Document{
+		ID:           content.ID,
+		OwnerID:      content.OwnerID,
+		TenantID:     content.TenantID,
+		Name:         content.Name,
+		Description:  content.Description,
+		Tags:         metadata.Tags,
+		DocumentType: content.DocumentType,
+		Status:       string(content.Status),
+		Size:         metadata.FileSize,
+		Metadata:     metadata.Metadata,
+		CreatedAt:    content.CreatedAt,
+		UpdatedAt:    content.UpdatedAt,
+	}
+}
+
+// indexContent brings the search index entry of a content up to date, and
+// its name and description vectors when semantic search is enabled.
+// Deleted content and collections are removed from the index. Failures are
+// logged rather than returned: the content change itself has succeeded.
+func (s *Server) indexContent(ctx context.Context, contentID uuid.UUID) {
+	if err := s.reindexContent(ctx, contentID); err != nil {
+		log.Printf("Failed to index content %s: %v", contentID, err)
+	}
+	s.embedContent(ctx, contentID, semantic.FieldMetadata)
+}
+
+// reindexContent indexes or removes one content
+func (s *Server) reindexContent(ctx context.Context, contentID uuid.UUID) error {
+	content, err := s.service.GetContent(ctx, contentID)
+	if err != nil {
+		if mcperrors.IsNotFound(err) {
+			return s.searchIndex.Remove(ctx, contentID)
+		}
+		return err
+	}
+	if content.DeletedAt != nil || simplecontent.ContentStatus(content.Status) == simplecontent.ContentStatusDeleted || isCollection(content) {
+		return s.searchIndex.Remove(ctx, contentID)
+	}
+
+	metadata, err := s.loadContentMetadata(ctx, contentID)
+	if err != nil {
+		return err
+	}
+	return s.searchIndex.Index(ctx, searchDocument(content, metadata))
+}
+
+// unindexContent removes deleted contents from the search and vector indexes
+func (s *Server) unindexContent(ctx context.Context, ids ...uuid.UUID) {
+	for _, id := range ids {
+		if err := s.searchIndex.Remove(ctx, id); err != nil {
+			log.Printf("Failed to remove content %s from search index: %v", id, err)
+		}
+		if s.vectorIndex != nil {
+			if err := s.vectorIndex.Remove(ctx, id); err != nil {
+				log.Printf("Failed to remove content %s from vector index: %v", id, err)
+			}
+		}
+	}
+}
+
+// RebuildSearchIndex indexes all live content, e.g. to fill an in-memory
+// index after a restart, and embeds it when semantic search is enabled. It requires an AdminService to enumerate content
+// and returns the number of contents indexed.
+func (s *Server) RebuildSearchIndex(ctx context.Context) (int, error) {
+	if s.adminService == nil {
+		return 0, fmt.Errorf("search index rebuild requires an admin service")
+	}
+
+	indexed := 0
+	limit := sweepPageSize
+	for offset := 0; ; offset += limit {
+		pageOffset := offset
+		resp, err := s.adminService.ListAllContents(ctx, admin.ListContentsRequest{
+			Filters: admin.ContentFilters{
+				Limit:  &limit,
+				Offset: &pageOffset,
+			},
+		})
+		if err != nil {
+			return indexed, err
+		}
+
+		for _, content := range resp.Contents {
+			if content.DeletedAt != nil || isCollection(content) {
+				continue
+			}
+			metadata, err := s.loadContentMetadata(ctx, content.ID)
+			if err != nil {
+				return indexed, err
+			}
+			if err := s.searchIndex.Index(ctx, searchDocument(content, metadata)); err != nil {
+				return indexed, err
+			}
+			s.embedContent(ctx, content.ID, semantic.FieldMetadata)
+			s.indexBody(ctx, content.ID)
+			indexed++
+		}
+
+		if len(resp.Contents) < limit {
+			break
+		}
+	}
+
+	s.indexPopulated.Store(true)
+	return indexed, nil
+}
+
+// populatedIndex returns the index to answer a search from. Until the search
+// index is populated by RebuildSearchIndex, the contents in the scope of the
+// query (the collection members when given) are listed from the service and
+// indexed into a throwaway index, so content stored before the server started
+// is not missed. Bodies are indexed too when withBodies is set.
+func (s *Server) populatedIndex(ctx context.Context, query *search.Query, members []*simplecontent.Content, withBodies bool) (search.Index, error) {
+	if s.indexPopulated.Load() {
+		return s.searchIndex, nil
+	}
+
+	contents := members
+	if query.IDs == nil {
+		var err error
+		switch {
+		case s.adminService != nil:
+			filters := admin.ContentFilters{}
+			if query.OwnerID != uuid.Nil {
+				filters.OwnerID = &query.OwnerID
+			}
+			if query.TenantID != uuid.Nil {
+				filters.TenantID = &query.TenantID
+			}
+			contents, err = s.listAllContents(ctx, filters)
+		case query.OwnerID != uuid.Nil:
+			contents, err = s.service.ListContent(ctx, simplecontent.ListContentRequest{
+				OwnerID:  query.OwnerID,
+				TenantID: query.TenantID,
+			})
+		default:
+			return nil, mcperrors.NewValidationError("owner_id", fmt.Errorf("owner_id is required: the search index is not populated and rebuilding it requires an admin service"))
+		}
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+	}
+
+	index := search.NewMemoryIndex()
+	for _, content := range contents {
+		if content.DeletedAt != nil || simplecontent.ContentStatus(content.Status) == simplecontent.ContentStatusDeleted || isCollection(content) {
+			continue
+		}
+		metadata, err := s.loadContentMetadata(ctx, content.ID)
+		if err != nil {
+			return nil, s.mapError(err)
+		}
+		if err := index.Index(ctx, searchDocument(content, metadata)); err != nil {
+			return nil, mcperrors.NewInternalError(err)
+		}
+
+		// Extracted text is indexed as the body of the content it was extracted from
+		if !withBodies || content.DerivationType == extract.DerivationType {
+			continue
+		}
+		text, sourceID, err := s.bodyText(ctx, content)
+		if err != nil {
+			log.Printf("Failed to index body of content %s: %v", content.ID, err)
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		if err := index.IndexBody(ctx, &search.Body{
+			ContentID: content.ID,
+			SourceID:  sourceID,
+			Passages:  search.Chunk(text, search.DefaultPassageSize),
+		}); err != nil {
+			return nil, mcperrors.NewInternalError(err)
+		}
+	}
+	return index, nil
+}
+
+// searchBodies answers search_content with scope=body: passages of content
+// text matching the query, with their byte offsets in the text
+func (s *Server) searchBodies(ctx context.Context, bodies search.BodyIndex, query *search.Query) (*mcp.CallToolResult, error) {
+	result, err := bodies.SearchBody(ctx, query)
+	if err != nil {
+		return nil, mcperrors.NewInternalError(fmt.Errorf("search failed: %w", err))
+	}
+
+	// Format results from the current content records
+	contents := make(map[uuid.UUID]*simplecontent.Content)
+	items := make([]map[string]interface{}, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		content, ok := contents[hit.ContentID]
+		if !ok {
+			content, err = s.service.GetContent(ctx, hit.ContentID)
+			if err != nil && !mcperrors.IsNotFound(err) {
+				return nil, s.mapError(err)
+			}
+			if err != nil {
+				// Deleted behind the index's back
+				s.unindexContent(ctx, hit.ContentID)
+				content = nil
+			}
+			contents[hit.ContentID] = content
+		}
+		if content == nil {
+			continue
+		}
+
+		item := map[string]interface{}{
+			"content_id":    content.ID.String(),
+			"name":          content.Name,
+			"document_type": content.DocumentType,
+			"start":         hit.Start,
+			"end":           hit.End,
+			"score":         hit.Score,
+			"highlight":     hit.Highlight,
+		}
+		if hit.SourceID != hit.ContentID {
+			item["source_id"] = hit.SourceID.String()
+		}
+		items = append(items, item)
+	}
+
+	return newTextResult(formatJSON(map[string]interface{}{
+		"items":  items,
+		"total":  result.Total,
+		"limit":  query.Limit,
+		"offset": query.Offset,
+	})), nil
+}
+
+// bodyIndex returns the search index as a body index when body search is enabled
+func (s *Server) bodyIndex() (search.BodyIndex, bool) {
+	if !s.config.IndexBodies {
+		return nil, false
+	}
+	bodies, ok := s.searchIndex.(search.BodyIndex)
+	return bodies, ok
+}
+
+// indexBody brings the indexed body of a content up to date when body
+// search is enabled, and its text vectors when semantic search is enabled.
+// Like indexContent, it logs failures.
+func (s *Server) indexBody(ctx context.Context, contentID uuid.UUID) {
+	if err := s.reindexBody(ctx, contentID); err != nil {
+		log.Printf("Failed to index body of content %s: %v", contentID, err)
+	}
+	s.embedContent(ctx, contentID, semantic.FieldText)
+}
+
+// reindexBody indexes or removes the body of one content
+func (s *Server) reindexBody(ctx context.Context, contentID uuid.UUID) error {
+	bodies, ok := s.bodyIndex()
+	if !ok {
+		return nil
+	}
+
+	content, err := s.service.GetContent(ctx, contentID)
+	if err != nil {
+		if mcperrors.IsNotFound(err) {
+			return bodies.RemoveBody(ctx, contentID)
+		}
+		return err
+	}
+	if content.DeletedAt != nil || simplecontent.ContentStatus(content.Status) == simplecontent.ContentStatusDeleted || isCollection(content) {
+		return bodies.RemoveBody(ctx, contentID)
+	}
+	// Extracted text is indexed as the body of the content it was extracted from
+	if content.DerivationType == extract.DerivationType {
+		return nil
+	}
+
+	text, sourceID, err := s.bodyText(ctx, content)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		return bodies.RemoveBody(ctx, contentID)
+	}
+	return bodies.IndexBody(ctx, &search.Body{
+		ContentID: contentID,
+		SourceID:  sourceID,
+		Passages:  search.Chunk(text, search.DefaultPassageSize),
+	})
+}
+
+// bodyText returns the indexable text of a content and the content it was
+// read from: the content itself when it is text-like, otherwise its
+// extracted text variant. It returns an empty text when there is none.
+func (s *Server) bodyText(ctx context.Context, content *simplecontent.Content) (string, uuid.UUID, error) {
+	sourceID := content.ID
+	if !isTextLike(content.DocumentType) {
+		textID, err := s.extractedTextID(ctx, content.ID)
+		if err != nil || textID == uuid.Nil {
+			return "", uuid.Nil, err
+		}
+		sourceID = textID
+	}
+
+	data, err := s.readContent(ctx, sourceID)
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+	if len(data) > maxBodyIndexSize {
+		cut := maxBodyIndexSize
+		for cut > 0 && !utf8.RuneStart(data[cut]) {
+			cut--
+		}
+		data = data[:cut]
+	}
+	// Offsets must refer to the stored bytes, so other charsets are only
+	// searchable through their extracted text
+	if !utf8.Valid(data) {
+		return "", uuid.Nil, nil
+	}
+	return string(data), sourceID, nil
+}
+
+// isTextLike reports whether content of the given MIME type is indexed as
+// text: text/*, JSON and Markdown
+func isTextLike(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(mimeType))
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/markdown",
+		mediaType == "application/x-markdown":
+		return true
+	}
+	return false
+}
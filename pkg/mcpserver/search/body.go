@@ -0,0 +1,115 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// DefaultPassageSize is the passage length Chunk aims for, in bytes
+const DefaultPassageSize = 1000
+
+// bodyField names the passage text in analyzed fields. Text conditions
+// without a field match it instead of name, tags and description.
+const bodyField = "body"
+
+// Passage is a chunk of a content body. Start and End are byte offsets of
+// Text in the body.
+type Passage struct {
+	Start int64
+	End   int64
+	Text  string
+}
+
+// Body is the text of a content, split into passages. SourceID is the
+// content the text was read from: the content itself, or a derived content
+// holding its extracted text.
+type Body struct {
+	ContentID uuid.UUID
+	SourceID  uuid.UUID
+	Passages  []Passage
+}
+
+// PassageHit is a passage matching a query. Highlight is a snippet of the
+// passage with the query terms wrapped in <mark></mark>.
+type PassageHit struct {
+	ContentID uuid.UUID
+	SourceID  uuid.UUID
+	Start     int64
+	End       int64
+	Score     float64
+	Highlight string
+}
+
+// BodyResult is a page of passage hits, most relevant first, and the total
+// number of matching passages
+type BodyResult struct {
+	Hits  []*PassageHit
+	Total int
+}
+
+// BodyIndex is implemented by indexes that can also search content bodies.
+// Bodies are matched together with the Document of the same content, which
+// must be indexed for its passages to be found: query filters apply to the
+// document, while text conditions without a field apply to each passage.
+type BodyIndex interface {
+	// IndexBody replaces the passages of a content
+	IndexBody(ctx context.Context, body *Body) error
+	// RemoveBody deletes the passages of a content; Remove deletes them too
+	RemoveBody(ctx context.Context, contentID uuid.UUID) error
+	// SearchBody returns the passages matching query
+	SearchBody(ctx context.Context, query *Query) (*BodyResult, error)
+}
+
+// Chunk splits text into passages of about size bytes (DefaultPassageSize
+// when size <= 0), preferring to break at paragraphs, then at whitespace.
+// Whitespace between passages is dropped; offsets refer to text.
+func Chunk(text string, size int) []Passage {
+	if size <= 0 {
+		size = DefaultPassageSize
+	}
+
+	var passages []Passage
+	start := 0
+	for {
+		for start < len(text) && isSpace(text[start]) {
+			start++
+		}
+		if start >= len(text) {
+			return passages
+		}
+
+		end := start + size
+		if end >= len(text) {
+			end = len(text)
+		} else {
+			// Break in the second half of the window when possible
+			window := text[start+size/2 : end]
+			if cut := strings.LastIndex(window, "\n\n"); cut >= 0 {
+				end = start + size/2 + cut
+			} else if cut := strings.LastIndexAny(window, " \t\n"); cut >= 0 {
+				end = start + size/2 + cut
+			} else {
+				for end > start && !utf8.RuneStart(text[end]) {
+					end--
+				}
+			}
+		}
+
+		passage := strings.TrimRightFunc(text[start:end], unicode.IsSpace)
+		passages = append(passages, Passage{
+			Start: int64(start),
+			End:   int64(start + len(passage)),
+			Text:  passage,
+		})
+		start = end
+	}
+}
+
+// isSpace reports whether c is ASCII whitespace
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,387 @@
+package search
+
+import (
+	"context"
+	"maps"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// prefixWeight scales matches of index terms that merely start with a
+	// query term ("rep" matching "report")
+	prefixWeight = 0.5
+)
+
+// fieldWeights boosts matches in names over tags over descriptions
+var fieldWeights = map[string]float64{
+	"name":        3,
+	"tags":        2,
+	"description": 1,
+}
+
+// memoryDocument is an indexed document and its terms per field
+type memoryDocument struct {
+	doc     *Document
+	fields  map[string][]string       // field -> terms in order
+	terms   map[string]map[string]int // field -> term -> frequency
+	lengths map[string]int            // field -> number of terms
+}
+
+// memoryPassage is an indexed passage and its terms
+type memoryPassage struct {
+	passage Passage
+	terms   []string       // Terms in order
+	counts  map[string]int // Term -> frequency
+}
+
+// memoryBody holds the passages of a content body
+type memoryBody struct {
+	sourceID uuid.UUID
+	passages []*memoryPassage
+}
+
+// MemoryIndex is an in-process inverted index ranking matches with BM25
+// over name, tags and description, and over passages of content bodies.
+// It is lost when the process exits.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[uuid.UUID]*memoryDocument
+	postings map[string]map[uuid.UUID]bool // term -> documents containing it
+	lengths  map[string]int                // field -> total number of terms
+
+	bodies        map[uuid.UUID]*memoryBody
+	passageTerms  map[string]int // term -> number of passages containing it
+	passageCount  int
+	passageLength int // Total number of passage terms
+}
+
+// NewMemoryIndex creates an empty in-memory index
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:         make(map[uuid.UUID]*memoryDocument),
+		postings:     make(map[string]map[uuid.UUID]bool),
+		lengths:      make(map[string]int),
+		bodies:       make(map[uuid.UUID]*memoryBody),
+		passageTerms: make(map[string]int),
+	}
+}
+
+// Index adds a document or replaces the document with the same ID
+func (x *MemoryIndex) Index(ctx context.Context, doc *Document) error {
+	copied := *doc
+	copied.Tags = append([]string(nil), doc.Tags...)
+	copied.Metadata = maps.Clone(doc.Metadata)
+
+	entry := &memoryDocument{
+		doc:     &copied,
+		fields:  analyze(&copied),
+		terms:   make(map[string]map[string]int),
+		lengths: make(map[string]int),
+	}
+	for field, terms := range entry.fields {
+		frequencies := make(map[string]int)
+		for _, term := range terms {
+			frequencies[term]++
+			entry.lengths[field]++
+		}
+		entry.terms[field] = frequencies
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(doc.ID)
+	x.docs[doc.ID] = entry
+	for field, frequencies := range entry.terms {
+		x.lengths[field] += entry.lengths[field]
+		for term := range frequencies {
+			if x.postings[term] == nil {
+				x.postings[term] = make(map[uuid.UUID]bool)
+			}
+			x.postings[term][doc.ID] = true
+		}
+	}
+	return nil
+}
+
+// Remove deletes a document and its body
+func (x *MemoryIndex) Remove(ctx context.Context, id uuid.UUID) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+	x.removeBody(id)
+	return nil
+}
+
+// remove deletes a document; the caller holds the write lock
+func (x *MemoryIndex) remove(id uuid.UUID) {
+	entry, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	delete(x.docs, id)
+	for field, frequencies := range entry.terms {
+		x.lengths[field] -= entry.lengths[field]
+		for term := range frequencies {
+			delete(x.postings[term], id)
+			if len(x.postings[term]) == 0 {
+				delete(x.postings, term)
+			}
+		}
+	}
+}
+
+// Search returns the documents matching query
+func (x *MemoryIndex) Search(ctx context.Context, query *Query) (*Result, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	plan := query.Plan
+	if plan == nil {
+		plan = &Plan{}
+	}
+
+	var hits []*Hit
+	for id, entry := range x.docs {
+		if !query.matches(entry.doc) || !plan.match(entry.doc, entry.fields) {
+			continue
+		}
+		hits = append(hits, &Hit{
+			ID:        id,
+			Score:     math.Round(x.score(entry, plan.Terms)*1000) / 1000,
+			Name:      entry.doc.Name,
+			Size:      entry.doc.Size,
+			CreatedAt: entry.doc.CreatedAt,
+			UpdatedAt: entry.doc.UpdatedAt,
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		return query.sortsBefore(hits[i], hits[j])
+	})
+
+	result := &Result{Hits: []*Hit{}, Total: len(hits)}
+	if query.After != nil {
+		start := 0
+		for start < len(hits) && !query.sortsBefore(query.After, hits[start]) {
+			start++
+		}
+		hits = hits[start:]
+	}
+	if query.Offset >= len(hits) {
+		return result, nil
+	}
+	hits = hits[query.Offset:]
+	if query.Limit > 0 && query.Limit < len(hits) {
+		hits = hits[:query.Limit]
+	}
+
+	for _, hit := range hits {
+		if len(plan.Terms) > 0 {
+			hit.Highlights = highlights(x.docs[hit.ID].doc, plan.Terms)
+		}
+	}
+	result.Hits = hits
+	return result, nil
+}
+
+// score computes the BM25 score of a document for the query terms. Whether
+// the document matches at all is decided by the query plan.
+func (x *MemoryIndex) score(entry *memoryDocument, terms []string) float64 {
+	total := 0.0
+	count := float64(len(x.docs))
+	for _, queryTerm := range terms {
+		for field, frequencies := range entry.terms {
+			averageLength := float64(x.lengths[field]) / count
+			if averageLength == 0 {
+				continue
+			}
+			norm := 1 - bm25B + bm25B*float64(entry.lengths[field])/averageLength
+
+			for term, tf := range frequencies {
+				if !matchesTerm(term, queryTerm) {
+					continue
+				}
+
+				df := float64(len(x.postings[term]))
+				idf := math.Log(1 + (count-df+0.5)/(df+0.5))
+				weight := fieldWeights[field]
+				if term != Stem(queryTerm) {
+					weight *= prefixWeight
+				}
+				total += weight * idf * float64(tf) * (bm25K1 + 1) / (float64(tf) + bm25K1*norm)
+			}
+		}
+	}
+	return total
+}
+
+// highlights returns snippets of the document fields matching the terms
+func highlights(doc *Document, terms []string) map[string]string {
+	result := make(map[string]string)
+	for field, text := range map[string]string{
+		"name":        doc.Name,
+		"description": doc.Description,
+		"tags":        strings.Join(doc.Tags, ", "),
+	} {
+		if snippet := Highlight(text, terms); snippet != "" {
+			result[field] = snippet
+		}
+	}
+	return result
+}
+
+// IndexBody replaces the passages of a content
+func (x *MemoryIndex) IndexBody(ctx context.Context, body *Body) error {
+	entry := &memoryBody{sourceID: body.SourceID}
+	for _, passage := range body.Passages {
+		terms := Terms(passage.Text)
+		counts := make(map[string]int)
+		for _, term := range terms {
+			counts[term]++
+		}
+		entry.passages = append(entry.passages, &memoryPassage{passage: passage, terms: terms, counts: counts})
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.removeBody(body.ContentID)
+	x.bodies[body.ContentID] = entry
+	for _, p := range entry.passages {
+		x.passageCount++
+		x.passageLength += len(p.terms)
+		for term := range p.counts {
+			x.passageTerms[term]++
+		}
+	}
+	return nil
+}
+
+// RemoveBody deletes the passages of a content
+func (x *MemoryIndex) RemoveBody(ctx context.Context, contentID uuid.UUID) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.removeBody(contentID)
+	return nil
+}
+
+// removeBody deletes the passages of a content; the caller holds the write lock
+func (x *MemoryIndex) removeBody(contentID uuid.UUID) {
+	entry, ok := x.bodies[contentID]
+	if !ok {
+		return
+	}
+	delete(x.bodies, contentID)
+	for _, p := range entry.passages {
+		x.passageCount--
+		x.passageLength -= len(p.terms)
+		for term := range p.counts {
+			if x.passageTerms[term]--; x.passageTerms[term] == 0 {
+				delete(x.passageTerms, term)
+			}
+		}
+	}
+}
+
+// SearchBody returns the passages matching query
+func (x *MemoryIndex) SearchBody(ctx context.Context, query *Query) (*BodyResult, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	plan := query.Plan
+	if plan == nil {
+		plan = &Plan{}
+	}
+
+	var hits []*PassageHit
+	for id, body := range x.bodies {
+		doc, ok := x.docs[id]
+		if !ok || !query.matches(doc.doc) {
+			continue
+		}
+		for _, p := range body.passages {
+			fields := maps.Clone(doc.fields)
+			fields[bodyField] = p.terms
+			if !plan.match(doc.doc, fields) {
+				continue
+			}
+			hits = append(hits, &PassageHit{
+				ContentID: id,
+				SourceID:  body.sourceID,
+				Start:     p.passage.Start,
+				End:       p.passage.End,
+				Score:     x.passageScore(p, plan.Terms),
+			})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].ContentID != hits[j].ContentID {
+			return hits[i].ContentID.String() < hits[j].ContentID.String()
+		}
+		return hits[i].Start < hits[j].Start
+	})
+
+	result := &BodyResult{Hits: []*PassageHit{}, Total: len(hits)}
+	if query.Offset >= len(hits) {
+		return result, nil
+	}
+	hits = hits[query.Offset:]
+	if query.Limit > 0 && query.Limit < len(hits) {
+		hits = hits[:query.Limit]
+	}
+
+	for _, hit := range hits {
+		hit.Score = math.Round(hit.Score*1000) / 1000
+		for _, p := range x.bodies[hit.ContentID].passages {
+			if p.passage.Start == hit.Start {
+				hit.Highlight = Highlight(p.passage.Text, plan.Terms)
+				break
+			}
+		}
+	}
+	result.Hits = hits
+	return result, nil
+}
+
+// passageScore computes the BM25 score of a passage for the query terms
+func (x *MemoryIndex) passageScore(p *memoryPassage, terms []string) float64 {
+	if x.passageLength == 0 {
+		return 0
+	}
+	count := float64(x.passageCount)
+	norm := 1 - bm25B + bm25B*float64(len(p.terms))/(float64(x.passageLength)/count)
+
+	total := 0.0
+	for _, queryTerm := range terms {
+		for term, tf := range p.counts {
+			if !matchesTerm(term, queryTerm) {
+				continue
+			}
+
+			df := float64(x.passageTerms[term])
+			idf := math.Log(1 + (count-df+0.5)/(df+0.5))
+			weight := 1.0
+			if term != Stem(queryTerm) {
+				weight *= prefixWeight
+			}
+			total += weight * idf * float64(tf) * (bm25K1 + 1) / (float64(tf) + bm25K1*norm)
+		}
+	}
+	return total
+}
@@ -0,0 +1,482 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+)
+
+// DBTX is satisfied by pgxpool.Pool, pgx.Conn and pgx.Tx
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...interface{}) pgx.Row
+}
+
+// postgresSchema creates the search tables; it is safe to run repeatedly.
+// The document tsvector weights names (A) over tags (B) over descriptions
+// (C); passages of content bodies have their own table.
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS mcp_search_documents (
+    id UUID PRIMARY KEY,
+    owner_id UUID NOT NULL,
+    tenant_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    tags TEXT[] NOT NULL,
+    document_type VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    search_vector TSVECTOR NOT NULL
+);
+ALTER TABLE mcp_search_documents ADD COLUMN IF NOT EXISTS size BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE mcp_search_documents ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS idx_mcp_search_vector ON mcp_search_documents USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_mcp_search_owner ON mcp_search_documents(owner_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_mcp_search_tags ON mcp_search_documents USING GIN(tags);
+CREATE TABLE IF NOT EXISTS mcp_search_passages (
+    content_id UUID NOT NULL,
+    source_id UUID NOT NULL,
+    start_offset BIGINT NOT NULL,
+    end_offset BIGINT NOT NULL,
+    body TEXT NOT NULL,
+    body_vector TSVECTOR NOT NULL,
+    PRIMARY KEY (content_id, start_offset)
+);
+CREATE INDEX IF NOT EXISTS idx_mcp_search_passages_vector ON mcp_search_passages USING GIN(body_vector);
+`
+
+// headlineOptions configures ts_headline snippets
+const headlineOptions = `StartSel=` + HighlightStart + `, StopSel=` + HighlightEnd +
+	`, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`
+
+// PostgresIndex is an Index and BodyIndex stored in PostgreSQL and ranked
+// with its full-text search (ts_rank_cd over an english tsvector)
+type PostgresIndex struct {
+	db DBTX
+}
+
+// NewPostgresIndex creates an index on db. Call EnsureSchema to create its table.
+func NewPostgresIndex(db DBTX) *PostgresIndex {
+	return &PostgresIndex{db: db}
+}
+
+// EnsureSchema creates the search table and indexes if they don't exist
+func (x *PostgresIndex) EnsureSchema(ctx context.Context) error {
+	if _, err := x.db.Exec(ctx, postgresSchema); err != nil {
+		return fmt.Errorf("failed to create search schema: %w", err)
+	}
+	return nil
+}
+
+// Index adds a document or replaces the document with the same ID
+func (x *PostgresIndex) Index(ctx context.Context, doc *Document) error {
+	tags := doc.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	metadata := doc.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	_, err := x.db.Exec(ctx, `
+		INSERT INTO mcp_search_documents (id, owner_id, tenant_id, name, description, tags,
+			document_type, status, size, metadata, created_at, updated_at, search_vector)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12,
+			setweight(to_tsvector('english', $4), 'A') ||
+			setweight(to_tsvector('english', $13), 'B') ||
+			setweight(to_tsvector('english', $5), 'C'))
+		ON CONFLICT (id) DO UPDATE SET owner_id = EXCLUDED.owner_id, tenant_id = EXCLUDED.tenant_id,
+			name = EXCLUDED.name, description = EXCLUDED.description, tags = EXCLUDED.tags,
+			document_type = EXCLUDED.document_type, status = EXCLUDED.status, size = EXCLUDED.size,
+			metadata = EXCLUDED.metadata, created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at,
+			search_vector = EXCLUDED.search_vector`,
+		doc.ID, doc.OwnerID, doc.TenantID, doc.Name, doc.Description, tags,
+		doc.DocumentType, doc.Status, doc.Size, metadata, doc.CreatedAt, doc.UpdatedAt, strings.Join(tags, " "))
+	if err != nil {
+		return fmt.Errorf("failed to index document: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes a document and its body
+func (x *PostgresIndex) Remove(ctx context.Context, id uuid.UUID) error {
+	if _, err := x.db.Exec(ctx, `DELETE FROM mcp_search_documents WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to remove document: %w", err)
+	}
+	return x.RemoveBody(ctx, id)
+}
+
+// Search returns the documents matching query
+func (x *PostgresIndex) Search(ctx context.Context, query *Query) (*Result, error) {
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	conditions := queryConditions(query, "search_vector", arg)
+
+	var terms []string
+	if query.Plan != nil {
+		terms = query.Plan.Terms
+	}
+	conditionArgs := len(args) // Arguments used by conditions
+
+	// Rank and highlight by any of the query terms. Ranks are rounded like
+	// the scores of MemoryIndex, so that hits keep their order in cursors.
+	tsquery := strings.Join(mapWords(terms, prefixTerm), " | ")
+	selectRank := `0::float8`
+	selectHighlights := `'', '', ''`
+	if tsquery != "" {
+		q := "to_tsquery('english', " + arg(tsquery) + ")"
+		selectRank = fmt.Sprintf("round(ts_rank_cd(search_vector, %s)::numeric, 3)::float8", q)
+
+		options := arg(headlineOptions)
+		selectHighlights = fmt.Sprintf(`ts_headline('english', name, %[1]s, %[2]s),
+			ts_headline('english', description, %[1]s, %[2]s),
+			ts_headline('english', array_to_string(tags, ', '), %[1]s, %[2]s)`, q, options)
+	}
+
+	keys := sortKeys(query, selectRank)
+	direction := " DESC"
+	if query.Ascending {
+		direction = " ASC"
+	}
+	orderBy := ""
+	for _, key := range keys {
+		orderBy += key.expr + direction + ", "
+	}
+	orderBy += "id"
+
+	where := conditions
+	if query.After != nil {
+		where = append(where[:len(where):len(where)], keysetCondition(keys, query.After.ID, query.Ascending, arg))
+	}
+
+	sql := `SELECT id, name, size, created_at, updated_at, ` + selectRank + ` AS rank, ` + selectHighlights + `, COUNT(*) OVER()
+		FROM mcp_search_documents`
+	if len(where) > 0 {
+		sql += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	sql += ` ORDER BY ` + orderBy
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		sql += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if query.Offset > 0 {
+		args = append(args, query.Offset)
+		sql += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := x.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+	defer rows.Close()
+
+	result := &Result{Hits: []*Hit{}}
+	for rows.Next() {
+		var hit Hit
+		var name, description, tags string
+		var total int64
+		if err := rows.Scan(&hit.ID, &hit.Name, &hit.Size, &hit.CreatedAt, &hit.UpdatedAt, &hit.Score, &name, &description, &tags, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan search hit: %w", err)
+		}
+		hit.Score = math.Round(hit.Score*1000) / 1000
+		if tsquery != "" {
+			hit.Highlights = make(map[string]string)
+			for field, snippet := range map[string]string{"name": name, "description": description, "tags": tags} {
+				// ts_headline returns the start of the text when a field doesn't match
+				if strings.Contains(snippet, HighlightStart) {
+					hit.Highlights[field] = snippet
+				}
+			}
+		}
+		result.Total = int(total)
+		result.Hits = append(result.Hits, &hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The window count is missing when the page is past the last hit, and
+	// only covers the hits after a cursor
+	if query.After != nil || (len(result.Hits) == 0 && query.Offset > 0) {
+		count := `SELECT COUNT(*) FROM mcp_search_documents`
+		if len(conditions) > 0 {
+			count += ` WHERE ` + strings.Join(conditions, " AND ")
+		}
+		if err := x.db.QueryRow(ctx, count, args[:conditionArgs]...).Scan(&result.Total); err != nil {
+			return nil, fmt.Errorf("failed to count documents: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// sortKey is an ORDER BY expression and, for keyset pagination, its value
+// at the cursor
+type sortKey struct {
+	expr  string
+	value interface{}
+}
+
+// sortKeys returns the ORDER BY expressions of query before id: its sort key
+// (rank being the relevance expression), then created_at. Names compare
+// bytewise, like strings.Compare. Values are taken from query.After.
+func sortKeys(query *Query, rank string) []sortKey {
+	after := query.After
+	if after == nil {
+		after = &Hit{}
+	}
+	createdAt := sortKey{"created_at", after.CreatedAt}
+	switch query.SortBy {
+	case SortCreatedAt:
+		return []sortKey{createdAt}
+	case SortUpdatedAt:
+		return []sortKey{{"updated_at", after.UpdatedAt}, createdAt}
+	case SortName:
+		return []sortKey{{`name COLLATE "C"`, after.Name}, createdAt}
+	case SortSize:
+		return []sortKey{{"size", after.Size}, createdAt}
+	}
+	return []sortKey{{rank, after.Score}, createdAt}
+}
+
+// keysetCondition selects the rows sorted after the cursor row with the
+// values of keys and id, in the given direction. Ties on all keys are broken
+// by ascending id.
+func keysetCondition(keys []sortKey, id uuid.UUID, ascending bool, arg func(interface{}) string) string {
+	if len(keys) == 0 {
+		return "id > " + arg(id)
+	}
+	op := " < "
+	if ascending {
+		op = " > "
+	}
+	value := arg(keys[0].value)
+	return "(" + keys[0].expr + op + value + " OR (" + keys[0].expr + " = " + value + " AND " +
+		keysetCondition(keys[1:], id, ascending, arg) + "))"
+}
+
+// IndexBody replaces the passages of a content
+func (x *PostgresIndex) IndexBody(ctx context.Context, body *Body) error {
+	if err := x.RemoveBody(ctx, body.ContentID); err != nil {
+		return err
+	}
+	if len(body.Passages) == 0 {
+		return nil
+	}
+
+	starts := make([]int64, len(body.Passages))
+	ends := make([]int64, len(body.Passages))
+	texts := make([]string, len(body.Passages))
+	for i, passage := range body.Passages {
+		starts[i], ends[i], texts[i] = passage.Start, passage.End, passage.Text
+	}
+	_, err := x.db.Exec(ctx, `
+		INSERT INTO mcp_search_passages (content_id, source_id, start_offset, end_offset, body, body_vector)
+		SELECT $1, $2, p.start_offset, p.end_offset, p.body, to_tsvector('english', p.body)
+		FROM unnest($3::bigint[], $4::bigint[], $5::text[]) AS p(start_offset, end_offset, body)`,
+		body.ContentID, body.SourceID, starts, ends, texts)
+	if err != nil {
+		return fmt.Errorf("failed to index body: %w", err)
+	}
+	return nil
+}
+
+// RemoveBody deletes the passages of a content
+func (x *PostgresIndex) RemoveBody(ctx context.Context, contentID uuid.UUID) error {
+	if _, err := x.db.Exec(ctx, `DELETE FROM mcp_search_passages WHERE content_id = $1`, contentID); err != nil {
+		return fmt.Errorf("failed to remove body: %w", err)
+	}
+	return nil
+}
+
+// SearchBody returns the passages matching query. Passages are joined with
+// their documents, whose columns the query filters refer to unqualified.
+func (x *PostgresIndex) SearchBody(ctx context.Context, query *Query) (*BodyResult, error) {
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	conditions := queryConditions(query, "p.body_vector", arg)
+	conditionArgs := len(args)
+
+	var terms []string
+	if query.Plan != nil {
+		terms = query.Plan.Terms
+	}
+	selectRank, selectHighlight := `0::float8`, `''`
+	if tsquery := strings.Join(mapWords(terms, prefixTerm), " | "); tsquery != "" {
+		q := "to_tsquery('english', " + arg(tsquery) + ")"
+		selectRank = fmt.Sprintf("ts_rank_cd(p.body_vector, %s)::float8", q)
+		selectHighlight = fmt.Sprintf("ts_headline('english', p.body, %s, %s)", q, arg(headlineOptions))
+	}
+
+	from := ` FROM mcp_search_passages p JOIN mcp_search_documents ON mcp_search_documents.id = p.content_id`
+	where := ""
+	if len(conditions) > 0 {
+		where = ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	sql := `SELECT p.content_id, p.source_id, p.start_offset, p.end_offset, ` + selectRank + ` AS rank, ` +
+		selectHighlight + `, COUNT(*) OVER()` + from + where + ` ORDER BY rank DESC, p.content_id, p.start_offset`
+	if query.Limit > 0 {
+		sql += " LIMIT " + arg(query.Limit)
+	}
+	if query.Offset > 0 {
+		sql += " OFFSET " + arg(query.Offset)
+	}
+
+	rows, err := x.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search passages: %w", err)
+	}
+	defer rows.Close()
+
+	result := &BodyResult{Hits: []*PassageHit{}}
+	for rows.Next() {
+		var hit PassageHit
+		var total int64
+		if err := rows.Scan(&hit.ContentID, &hit.SourceID, &hit.Start, &hit.End, &hit.Score, &hit.Highlight, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan passage hit: %w", err)
+		}
+		hit.Score = math.Round(hit.Score*1000) / 1000
+		result.Total = int(total)
+		result.Hits = append(result.Hits, &hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The window count is missing when the page is past the last hit
+	if len(result.Hits) == 0 && query.Offset > 0 {
+		if err := x.db.QueryRow(ctx, `SELECT COUNT(*)`+from+where, args[:conditionArgs]...).Scan(&result.Total); err != nil {
+			return nil, fmt.Errorf("failed to count passages: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// queryConditions returns the SQL conditions of the query filters and plan.
+// Text conditions without a field match the tsvector column vector.
+func queryConditions(query *Query, vector string, arg func(interface{}) string) []string {
+	var conditions []string
+	addCondition := func(condition string, value interface{}) {
+		conditions = append(conditions, strings.Replace(condition, "$%d", arg(value), 1))
+	}
+
+	if query.OwnerID != uuid.Nil {
+		addCondition("owner_id = $%d", query.OwnerID)
+	}
+	if query.TenantID != uuid.Nil {
+		addCondition("tenant_id = $%d", query.TenantID)
+	}
+	if query.IDs != nil {
+		addCondition("id = ANY($%d)", query.IDs)
+	}
+	if len(query.Statuses) > 0 {
+		addCondition("status = ANY($%d)", query.Statuses)
+	}
+	if len(query.Tags) > 0 {
+		addCondition("tags @> $%d", query.Tags)
+	}
+	if len(query.Metadata) > 0 {
+		conditions = append(conditions, metafilter.SQL("metadata", query.Metadata, arg))
+	}
+	if query.Plan != nil && query.Plan.Expr != nil {
+		conditions = append(conditions, compileSQL(query.Plan.Expr, vector, arg))
+	}
+	return conditions
+}
+
+// compileSQL turns a query expression into a SQL condition. Text without a
+// field matches the tsvector column vector. arg adds a query argument and
+// returns its placeholder.
+func compileSQL(expr Expr, vector string, arg func(interface{}) string) string {
+	switch e := expr.(type) {
+	case *AndExpr:
+		return "(" + compileOperands(e.Operands, " AND ", vector, arg) + ")"
+	case *OrExpr:
+		return "(" + compileOperands(e.Operands, " OR ", vector, arg) + ")"
+	case *NotExpr:
+		return "NOT " + compileSQL(e.Operand, vector, arg)
+	case *TextExpr:
+		sep := " & "
+		if e.Phrase {
+			sep = " <-> "
+		}
+		q := "to_tsquery('english', " + arg(strings.Join(mapWords(e.Words, prefixTerm), sep)) + ")"
+		if e.Field != "" {
+			// Field names come from the parser, never from the query text
+			return "(to_tsvector('english', " + e.Field + ") @@ " + q + ")"
+		}
+		return "(" + vector + " @@ " + q + ")"
+	case *CompareExpr:
+		return compileComparison(e, arg)
+	}
+	return "FALSE"
+}
+
+// compileOperands compiles operands joined by sep
+func compileOperands(operands []Expr, sep, vector string, arg func(interface{}) string) string {
+	parts := make([]string, len(operands))
+	for i, operand := range operands {
+		parts[i] = compileSQL(operand, vector, arg)
+	}
+	return strings.Join(parts, sep)
+}
+
+// compileComparison turns a field comparison into a SQL condition
+func compileComparison(e *CompareExpr, arg func(interface{}) string) string {
+	switch e.Field {
+	case "tag":
+		return "EXISTS (SELECT 1 FROM unnest(tags) AS tag WHERE lower(tag) = lower(" + arg(e.Value) + "))"
+	case "mime":
+		if prefix, ok := strings.CutSuffix(e.Value, "*"); ok {
+			escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix)
+			return "lower(document_type) LIKE " + arg(escaped+"%")
+		}
+		return "lower(document_type) = " + arg(e.Value)
+	case "status":
+		return "lower(status) = lower(" + arg(e.Value) + ")"
+	case "created", "updated":
+		column := e.Field + "_at"
+		if e.Op == OpEq && !e.Until.IsZero() {
+			return "(" + column + " >= " + arg(e.Time) + " AND " + column + " < " + arg(e.Until) + ")"
+		}
+		return column + " " + string(e.Op) + " " + arg(e.Time)
+	case "size":
+		return "size " + string(e.Op) + " " + arg(e.Size)
+	}
+	return "FALSE"
+}
+
+// prefixTerm turns a query word into a tsquery lexeme, matching longer
+// words for words of 3+ letters. Words only hold letters and digits, so
+// they need no escaping.
+func prefixTerm(word string) string {
+	if len(word) >= 3 {
+		return word + ":*"
+	}
+	return word
+}
+
+// mapWords applies fn to each word
+func mapWords(words []string, fn func(string) string) []string {
+	result := make([]string, len(words))
+	for i, word := range words {
+		result[i] = fn(word)
+	}
+	return result
+}
@@ -0,0 +1,767 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Grammar documents the query language accepted by Compile
+const Grammar = `# search_content query syntax
+
+A query is a list of conditions. Adjacent conditions must all match.
+
+Text
+- report: the word in name, tags or description. Other forms ("reports",
+  "reporting") and longer words starting with it ("reporter") match too.
+- "quarterly report": the words next to each other, in that order
+- name:report, description:report: the word in that field only
+- With scope=body, words and phrases without a field match passages of
+  the content text instead; the other conditions still apply to the
+  content itself
+
+Fields
+- tag:invoice: content tagged "invoice" (case-insensitive)
+- mime:application/pdf: the MIME type; mime:image/* matches any image
+- status:uploaded: the content status
+- created:2025-01-01: created that day (UTC); updated: works the same
+- created:>2025-01-01: created after midnight UTC of that day; the
+  operators are >, >=, < and <=, and RFC 3339 timestamps are accepted
+  (created:>=2025-01-01T12:00:00Z)
+- size:<5MB: file size; units B, KB, MB and GB (1024-based), default B
+
+Operators
+- a AND b, or just a b: both
+- a OR b: either
+- NOT a, or -a: not a
+- ( ... ): grouping
+
+NOT binds tighter than AND, which binds tighter than OR. AND, OR and NOT
+must be upper case. Field values containing spaces are quoted:
+tag:"tax return". Common words (the, of, and, ...) are ignored in text.
+
+Example:
+tag:invoice AND mime:application/pdf AND created:>2025-01-01 AND size:<5MB AND "quarterly report" -draft
+`
+
+// SyntaxError reports an invalid query and where it went wrong
+type SyntaxError struct {
+	Pos     int // 1-based character position in the query
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Message)
+}
+
+// Expr is a node of a compiled query
+type Expr interface {
+	String() string
+}
+
+// AndExpr matches when all operands match
+type AndExpr struct {
+	Operands []Expr
+}
+
+// OrExpr matches when any operand matches
+type OrExpr struct {
+	Operands []Expr
+}
+
+// NotExpr matches when its operand doesn't
+type NotExpr struct {
+	Operand Expr
+}
+
+// TextExpr matches words in name, tags and description, or in Field only.
+// Words are lowercased query terms; a phrase requires them to be adjacent.
+type TextExpr struct {
+	Field  string // "", "name" or "description"
+	Words  []string
+	Phrase bool
+}
+
+// Op is a comparison operator
+type Op string
+
+// Comparison operators
+const (
+	OpEq Op = "="
+	OpGt Op = ">"
+	OpGe Op = ">="
+	OpLt Op = "<"
+	OpLe Op = "<="
+)
+
+// CompareExpr compares a document attribute with a value. Time is set for
+// created and updated, with Until marking the end of a whole-day match;
+// Size is set for size.
+type CompareExpr struct {
+	Field string // tag, mime, status, created, updated or size
+	Op    Op
+	Value string
+	Time  time.Time
+	Until time.Time
+	Size  int64
+}
+
+func (e *AndExpr) String() string { return joinExprs(e.Operands, " AND ") }
+func (e *OrExpr) String() string  { return joinExprs(e.Operands, " OR ") }
+func (e *NotExpr) String() string { return "NOT " + e.Operand.String() }
+
+func (e *TextExpr) String() string {
+	text := strings.Join(e.Words, " ")
+	if e.Phrase {
+		text = strconv.Quote(text)
+	}
+	if e.Field != "" {
+		return e.Field + ":" + text
+	}
+	return text
+}
+
+func (e *CompareExpr) String() string {
+	op := string(e.Op)
+	if e.Op == OpEq {
+		op = ""
+	}
+	return e.Field + ":" + op + e.Value
+}
+
+// joinExprs formats operands, parenthesizing nested groups
+func joinExprs(operands []Expr, sep string) string {
+	parts := make([]string, len(operands))
+	for i, operand := range operands {
+		parts[i] = operand.String()
+		switch operand.(type) {
+		case *AndExpr, *OrExpr:
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// Plan is a compiled query. MemoryIndex evaluates it, PostgresIndex turns it
+// into SQL, and Match evaluates it against any Document, e.g. one built
+// from a repository record.
+type Plan struct {
+	Expr  Expr     // nil matches every document
+	Terms []string // Words of text conditions that aren't negated, for ranking and highlighting
+}
+
+// Compile parses a query. An empty query matches every document.
+func Compile(query string) (*Plan, error) {
+	p := &parser{query: query}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		if tok.kind == tokRParen {
+			return nil, p.errorAt(tok, "unexpected ')' without a matching '('")
+		}
+		return nil, p.errorAt(tok, fmt.Sprintf("unexpected %s", tok))
+	}
+
+	plan := &Plan{Expr: expr}
+	seen := map[string]bool{}
+	collectTerms(expr, false, func(word string) {
+		if !seen[word] {
+			seen[word] = true
+			plan.Terms = append(plan.Terms, word)
+		}
+	})
+	return plan, nil
+}
+
+// String formats the compiled query with explicit operators
+func (p *Plan) String() string {
+	if p.Expr == nil {
+		return ""
+	}
+	return p.Expr.String()
+}
+
+// And returns a plan that also requires conditions, e.g. the range filters
+// of a tool. Conditions don't add ranking terms.
+func (p *Plan) And(conditions ...Expr) *Plan {
+	if len(conditions) == 0 {
+		return p
+	}
+	operands := append([]Expr{p.Expr}, conditions...)
+	return &Plan{
+		Expr:  combine(operands, func(operands []Expr) Expr { return &AndExpr{Operands: operands} }),
+		Terms: p.Terms,
+	}
+}
+
+// collectTerms calls fn with the words of text conditions outside NOT
+func collectTerms(expr Expr, negated bool, fn func(string)) {
+	switch e := expr.(type) {
+	case *AndExpr:
+		for _, operand := range e.Operands {
+			collectTerms(operand, negated, fn)
+		}
+	case *OrExpr:
+		for _, operand := range e.Operands {
+			collectTerms(operand, negated, fn)
+		}
+	case *NotExpr:
+		collectTerms(e.Operand, !negated, fn)
+	case *TextExpr:
+		if !negated {
+			for _, word := range e.Words {
+				fn(word)
+			}
+		}
+	}
+}
+
+// Match reports whether doc satisfies the plan
+func (p *Plan) Match(doc *Document) bool {
+	return p.match(doc, analyze(doc))
+}
+
+// match evaluates the plan with the analyzed fields of doc
+func (p *Plan) match(doc *Document, fields map[string][]string) bool {
+	return p.Expr == nil || evaluate(p.Expr, doc, fields)
+}
+
+// analyze returns the stemmed terms of the text fields of doc, in order
+func analyze(doc *Document) map[string][]string {
+	return map[string][]string{
+		"name":        Terms(doc.Name),
+		"description": Terms(doc.Description),
+		"tags":        Terms(strings.Join(doc.Tags, " ")),
+	}
+}
+
+// evaluate reports whether doc satisfies expr
+func evaluate(expr Expr, doc *Document, fields map[string][]string) bool {
+	switch e := expr.(type) {
+	case *AndExpr:
+		for _, operand := range e.Operands {
+			if !evaluate(operand, doc, fields) {
+				return false
+			}
+		}
+		return true
+	case *OrExpr:
+		for _, operand := range e.Operands {
+			if evaluate(operand, doc, fields) {
+				return true
+			}
+		}
+		return false
+	case *NotExpr:
+		return !evaluate(e.Operand, doc, fields)
+	case *TextExpr:
+		return matchText(e, fields)
+	case *CompareExpr:
+		return compare(e, doc)
+	}
+	return false
+}
+
+// matchText reports whether the analyzed fields contain the words of e.
+// When fields hold a passage, words without a field are looked up in it.
+func matchText(e *TextExpr, fields map[string][]string) bool {
+	names := []string{"name", "tags", "description"}
+	if e.Field != "" {
+		names = []string{e.Field}
+	} else if _, ok := fields[bodyField]; ok {
+		names = []string{bodyField}
+	}
+
+	if e.Phrase {
+		for _, name := range names {
+			if containsPhrase(fields[name], e.Words) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, word := range e.Words {
+		found := false
+		for _, name := range names {
+			for _, term := range fields[name] {
+				if matchesTerm(term, word) {
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// containsPhrase reports whether terms contain words consecutively
+func containsPhrase(terms, words []string) bool {
+	for start := 0; start+len(words) <= len(terms); start++ {
+		matched := true
+		for i, word := range words {
+			if !matchesTerm(terms[start+i], word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// compare evaluates a comparison against doc
+func compare(e *CompareExpr, doc *Document) bool {
+	switch e.Field {
+	case "tag":
+		for _, tag := range doc.Tags {
+			if strings.EqualFold(tag, e.Value) {
+				return true
+			}
+		}
+		return false
+	case "mime":
+		if prefix, ok := strings.CutSuffix(e.Value, "*"); ok {
+			return strings.HasPrefix(strings.ToLower(doc.DocumentType), prefix)
+		}
+		return strings.EqualFold(doc.DocumentType, e.Value)
+	case "status":
+		return strings.EqualFold(doc.Status, e.Value)
+	case "created":
+		return compareTime(e, doc.CreatedAt)
+	case "updated":
+		return compareTime(e, doc.UpdatedAt)
+	case "size":
+		return compareOrdered(e.Op, doc.Size, e.Size)
+	}
+	return false
+}
+
+// compareTime compares t with the time of e
+func compareTime(e *CompareExpr, t time.Time) bool {
+	if e.Op == OpEq && !e.Until.IsZero() {
+		return !t.Before(e.Time) && t.Before(e.Until)
+	}
+	return compareOrdered(e.Op, t.UnixNano(), e.Time.UnixNano())
+}
+
+// compareOrdered applies op to a and b
+func compareOrdered(op Op, a, b int64) bool {
+	switch op {
+	case OpGt:
+		return a > b
+	case OpGe:
+		return a >= b
+	case OpLt:
+		return a < b
+	case OpLe:
+		return a <= b
+	}
+	return a == b
+}
+
+// Token kinds
+const (
+	tokEOF = iota
+	tokWord
+	tokPhrase
+	tokField
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+// token is a lexical element of a query
+type token struct {
+	kind  int
+	text  string // Word or phrase text, or field value
+	field string // Field name of tokField
+	pos   int    // 1-based character position
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokAnd:
+		return "AND"
+	case tokOr:
+		return "OR"
+	case tokNot:
+		return "NOT"
+	case tokLParen:
+		return "'('"
+	case tokRParen:
+		return "')'"
+	case tokPhrase:
+		return strconv.Quote(t.text)
+	case tokField:
+		return fmt.Sprintf("%q", t.field+":"+t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// fields lists the field names accepted before ':'
+var fields = []string{"name", "description", "tag", "mime", "status", "created", "updated", "size"}
+
+// parser is a recursive descent parser over the tokens of a query
+type parser struct {
+	query  string
+	tokens []token
+	next   int
+}
+
+// errorAt returns a syntax error at the position of tok
+func (p *parser) errorAt(tok token, message string) error {
+	return &SyntaxError{Pos: tok.pos, Message: message}
+}
+
+// lex splits the query into tokens
+func (p *parser) lex() error {
+	q := p.query
+	pos := func(i int) int { return utf8.RuneCountInString(q[:i]) + 1 }
+
+	for i := 0; i < len(q); {
+		r, size := utf8.DecodeRuneInString(q[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			p.tokens = append(p.tokens, token{kind: tokLParen, pos: pos(i)})
+			i++
+		case r == ')':
+			p.tokens = append(p.tokens, token{kind: tokRParen, pos: pos(i)})
+			i++
+		case r == '-' && i+1 < len(q) && !unicode.IsSpace(rune(q[i+1])) && q[i+1] != ')':
+			p.tokens = append(p.tokens, token{kind: tokNot, pos: pos(i)})
+			i++
+		case r == '"':
+			text, end, err := p.phrase(i)
+			if err != nil {
+				return err
+			}
+			p.tokens = append(p.tokens, token{kind: tokPhrase, text: text, pos: pos(i)})
+			i = end
+		default:
+			start := i
+			for i < len(q) {
+				r, size := utf8.DecodeRuneInString(q[i:])
+				if unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' {
+					break
+				}
+				i += size
+			}
+			word := q[start:i]
+			tok := token{kind: tokWord, text: word, pos: pos(start)}
+
+			switch word {
+			case "AND":
+				tok.kind = tokAnd
+			case "OR":
+				tok.kind = tokOr
+			case "NOT":
+				tok.kind = tokNot
+			}
+
+			if name, value, ok := strings.Cut(word, ":"); ok && isFieldName(name) {
+				tok.kind = tokField
+				tok.field = strings.ToLower(name)
+				tok.text = value
+				// field:"quoted value"
+				if value == "" && i < len(q) && q[i] == '"' {
+					text, end, err := p.phrase(i)
+					if err != nil {
+						return err
+					}
+					tok.text = text
+					i = end
+				}
+			}
+			p.tokens = append(p.tokens, tok)
+		}
+	}
+	p.tokens = append(p.tokens, token{kind: tokEOF, pos: pos(len(q))})
+	return nil
+}
+
+// phrase reads a quoted string starting at the quote at i and returns its
+// text and the index after the closing quote
+func (p *parser) phrase(i int) (string, int, error) {
+	end := strings.IndexByte(p.query[i+1:], '"')
+	if end < 0 {
+		return "", 0, &SyntaxError{
+			Pos:     utf8.RuneCountInString(p.query[:i]) + 1,
+			Message: `unterminated phrase: add a closing '"'`,
+		}
+	}
+	return p.query[i+1 : i+1+end], i + end + 2, nil
+}
+
+// isFieldName reports whether name looks like a field prefix ("tag" in
+// "tag:x"); unknown names are rejected later with a list of valid fields
+func isFieldName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+// startsOperand reports whether tok can begin an operand
+func startsOperand(tok token) bool {
+	switch tok.kind {
+	case tokWord, tokPhrase, tokField, tokNot, tokLParen:
+		return true
+	}
+	return false
+}
+
+// parseOr parses: and ("OR" and)*
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	operands := []Expr{left}
+	for p.peek().kind == tokOr {
+		op := p.advance()
+		if !startsOperand(p.peek()) {
+			return nil, p.errorAt(op, fmt.Sprintf("OR must be followed by a term, found %s", p.peek()))
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, right)
+	}
+	return combine(operands, func(operands []Expr) Expr { return &OrExpr{Operands: operands} }), nil
+}
+
+// parseAnd parses: unary (["AND"] unary)*
+func (p *parser) parseAnd() (Expr, error) {
+	if tok := p.peek(); !startsOperand(tok) {
+		if tok.kind == tokAnd || tok.kind == tokOr {
+			return nil, p.errorAt(tok, fmt.Sprintf("%s must be preceded by a term", tok))
+		}
+		if tok.kind == tokRParen {
+			return nil, p.errorAt(tok, "empty group or unexpected ')'")
+		}
+		if p.next > 0 {
+			return nil, p.errorAt(tok, fmt.Sprintf("expected a term, found %s", tok))
+		}
+		return nil, nil // Empty query
+	}
+
+	var operands []Expr
+	for {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+
+		tok := p.peek()
+		if tok.kind == tokAnd {
+			p.advance()
+			if !startsOperand(p.peek()) {
+				return nil, p.errorAt(tok, fmt.Sprintf("AND must be followed by a term, found %s", p.peek()))
+			}
+			continue
+		}
+		if !startsOperand(tok) {
+			break
+		}
+	}
+	return combine(operands, func(operands []Expr) Expr { return &AndExpr{Operands: operands} }), nil
+}
+
+// parseUnary parses: ("NOT" | "-") unary | "(" or ")" | term
+func (p *parser) parseUnary() (Expr, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokNot:
+		if !startsOperand(p.peek()) {
+			return nil, p.errorAt(tok, fmt.Sprintf("NOT must be followed by a term, found %s", p.peek()))
+		}
+		operand, err := p.parseUnary()
+		if err != nil || operand == nil {
+			return nil, err
+		}
+		return &NotExpr{Operand: operand}, nil
+
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, p.errorAt(tok, fmt.Sprintf("missing ')' to close this '(', found %s", p.peek()))
+		}
+		p.advance()
+		return expr, nil
+
+	case tokWord:
+		return textExpr("", tok.text, false), nil
+
+	case tokPhrase:
+		return textExpr("", tok.text, true), nil
+
+	case tokField:
+		return p.fieldExpr(tok)
+	}
+	return nil, p.errorAt(tok, fmt.Sprintf("expected a term, found %s", tok))
+}
+
+// textExpr builds a text condition; it returns nil when text has no
+// searchable words
+func textExpr(field, text string, phrase bool) Expr {
+	var words []string
+	for _, tok := range Tokenize(text) {
+		if !stopwords[tok.Term] {
+			words = append(words, tok.Term)
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	return &TextExpr{Field: field, Words: words, Phrase: phrase && len(words) > 1}
+}
+
+// fieldExpr builds the condition of a field:value token
+func (p *parser) fieldExpr(tok token) (Expr, error) {
+	value := tok.text
+	if value == "" {
+		return nil, p.errorAt(tok, fmt.Sprintf("missing value after %s:", tok.field))
+	}
+
+	switch tok.field {
+	case "name", "description":
+		expr := textExpr(tok.field, value, strings.ContainsAny(value, " \t"))
+		if expr == nil {
+			return nil, p.errorAt(tok, fmt.Sprintf("%s:%s has no searchable words", tok.field, value))
+		}
+		return expr, nil
+
+	case "tag", "status":
+		return &CompareExpr{Field: tok.field, Op: OpEq, Value: value}, nil
+
+	case "mime":
+		value = strings.ToLower(value)
+		if star := strings.IndexByte(value, '*'); star >= 0 && star != len(value)-1 {
+			return nil, p.errorAt(tok, "mime wildcards are only allowed at the end, e.g. mime:image/*")
+		}
+		return &CompareExpr{Field: "mime", Op: OpEq, Value: value}, nil
+
+	case "created", "updated":
+		op, value := splitOp(value)
+		expr := &CompareExpr{Field: tok.field, Op: op, Value: value}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			expr.Time = t
+		} else if t, err := time.Parse("2006-01-02", value); err == nil {
+			expr.Time = t
+			if op == OpEq {
+				expr.Until = t.AddDate(0, 0, 1)
+			}
+		} else {
+			return nil, p.errorAt(tok, fmt.Sprintf("invalid date %q in %s: use YYYY-MM-DD or RFC 3339, e.g. %s:>2025-01-01", value, tok.field, tok.field))
+		}
+		return expr, nil
+
+	case "size":
+		op, value := splitOp(value)
+		size, err := ParseSize(value)
+		if err != nil {
+			return nil, p.errorAt(tok, fmt.Sprintf("invalid size %q: use a number with an optional unit B, KB, MB or GB, e.g. size:<5MB", value))
+		}
+		return &CompareExpr{Field: "size", Op: op, Value: value, Size: size}, nil
+	}
+
+	return nil, p.errorAt(tok, fmt.Sprintf("unknown field %q (fields: %s); quote the term to search for it as text", tok.field, strings.Join(fields, ", ")))
+}
+
+// splitOp separates a leading comparison operator from a value
+func splitOp(value string) (Op, string) {
+	for _, op := range []Op{OpGe, OpLe, OpGt, OpLt, OpEq} {
+		if rest, ok := strings.CutPrefix(value, string(op)); ok {
+			return op, rest
+		}
+	}
+	return OpEq, value
+}
+
+// sizeUnits are the multipliers of size suffixes, longest first
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+}
+
+// ParseSize parses a byte count such as 512, 100KB or 1.5MB
+func ParseSize(value string) (int64, error) {
+	upper := strings.ToUpper(value)
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if number, ok := strings.CutSuffix(upper, unit.suffix); ok {
+			upper, multiplier = number, unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(upper, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// combine builds a group from operands, dropping empty ones
+func combine(operands []Expr, group func([]Expr) Expr) Expr {
+	var kept []Expr
+	for _, operand := range operands {
+		if operand != nil {
+			kept = append(kept, operand)
+		}
+	}
+	switch len(kept) {
+	case 0:
+		return nil
+	case 1:
+		return kept[0]
+	}
+	return group(kept)
+}
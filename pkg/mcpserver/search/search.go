@@ -0,0 +1,150 @@
+// Package search indexes content for search_content.
+//
+// An Index stores a Document per content (name, description, tags and the
+// fields search results are filtered on) and answers ranked queries. The
+// server keeps it current as content is uploaded, updated and deleted.
+// Query strings are compiled into a Plan (see Grammar for the syntax).
+// Indexes implementing BodyIndex also search the text of content bodies,
+// split into passages by Chunk. MemoryIndex is an in-process inverted
+// index ranked with BM25; PostgresIndex uses PostgreSQL full-text search.
+package search
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+)
+
+// Document is the searchable representation of a content
+type Document struct {
+	ID           uuid.UUID
+	OwnerID      uuid.UUID
+	TenantID     uuid.UUID
+	Name         string
+	Description  string
+	Tags         []string
+	DocumentType string
+	Status       string
+	Size         int64
+	Metadata     map[string]interface{} // Custom metadata, for metadata filters
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Query selects and ranks documents. Plan is a compiled query string;
+// documents are ranked by its terms. Empty fields don't filter.
+type Query struct {
+	Plan      *Plan
+	OwnerID   uuid.UUID
+	TenantID  uuid.UUID
+	IDs       []uuid.UUID          // Restricts results to these documents when not nil
+	Statuses  []string             // Any of these statuses
+	Tags      []string             // All of these tags
+	Metadata  []*metafilter.Filter // Conditions on the custom metadata
+	SortBy    string               // One of the Sort keys (SortRelevance when empty, not used by SearchBody)
+	Ascending bool                 // Sort in ascending order instead of descending
+	After     *Hit                 // Only hits sorted after this one, for keyset pagination (not used by SearchBody)
+	Limit     int
+	Offset    int
+}
+
+// Sort keys of Query.SortBy. Ties are broken by creation time in the same
+// direction, then by ID.
+const (
+	SortRelevance = "relevance"
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	SortName      = "name"
+	SortSize      = "size"
+)
+
+// Hit is a document matching a query. Highlights holds snippets of the
+// matching fields with the query terms wrapped in <mark></mark>. Scores are
+// rounded to 3 decimals before hits are sorted. The other fields without a
+// JSON name are the sort keys of the document.
+type Hit struct {
+	ID         uuid.UUID         `json:"id"`
+	Score      float64           `json:"score"`
+	Name       string            `json:"-"`
+	Size       int64             `json:"-"`
+	CreatedAt  time.Time         `json:"-"`
+	UpdatedAt  time.Time         `json:"-"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// Result is a page of hits in the query order (most relevant first by
+// default), and the total number of matching documents
+type Result struct {
+	Hits  []*Hit
+	Total int
+}
+
+// Index is a search index of content
+type Index interface {
+	// Index adds a document or replaces the document with the same ID
+	Index(ctx context.Context, doc *Document) error
+	// Remove deletes a document; removing an unknown ID is not an error
+	Remove(ctx context.Context, id uuid.UUID) error
+	// Search returns the documents matching query
+	Search(ctx context.Context, query *Query) (*Result, error)
+}
+
+// HighlightStart and HighlightEnd surround query terms in highlights
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// sortsBefore reports whether hit a comes before hit b in the order of q
+func (q *Query) sortsBefore(a, b *Hit) bool {
+	c := 0
+	switch q.SortBy {
+	case SortCreatedAt:
+	case SortUpdatedAt:
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	case SortName:
+		c = strings.Compare(a.Name, b.Name)
+	case SortSize:
+		c = cmp.Compare(a.Size, b.Size)
+	default:
+		c = cmp.Compare(a.Score, b.Score)
+	}
+	if c == 0 {
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if !q.Ascending {
+		c = -c
+	}
+	if c == 0 {
+		return a.ID.String() < b.ID.String()
+	}
+	return c < 0
+}
+
+// matches reports whether doc passes the non-text filters of q
+func (q *Query) matches(doc *Document) bool {
+	if q.OwnerID != uuid.Nil && doc.OwnerID != q.OwnerID {
+		return false
+	}
+	if q.TenantID != uuid.Nil && doc.TenantID != q.TenantID {
+		return false
+	}
+	if q.IDs != nil && !slices.Contains(q.IDs, doc.ID) {
+		return false
+	}
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, doc.Status) {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !slices.Contains(doc.Tags, tag) {
+			return false
+		}
+	}
+	return metafilter.Match(doc.Metadata, q.Metadata)
+}
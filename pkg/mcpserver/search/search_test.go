@@ -0,0 +1,383 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+)
+
+func mustCompile(t *testing.T, query string) *Plan {
+	t.Helper()
+	plan, err := Compile(query)
+	if err != nil {
+		t.Fatalf("Compile(%q) failed: %v", query, err)
+	}
+	return plan
+}
+
+func TestStem(t *testing.T) {
+	for word, want := range map[string]string{
+		"reports":   "report",
+		"reporting": "report",
+		"reported":  "report",
+		"stories":   "story",
+		"boxes":     "box",
+		"running":   "run",
+		"status":    "status",
+		"quickly":   "quick",
+		"cat":       "cat",
+	} {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got := Highlight("Quarterly Reports for the board", []string{"report"})
+	if got != "Quarterly <mark>Reports</mark> for the board" {
+		t.Errorf("Unexpected highlight: %q", got)
+	}
+	if got := Highlight("Quarterly results", []string{"report"}); got != "" {
+		t.Errorf("Expected no highlight, got %q", got)
+	}
+
+	// Long texts are cut to a window around the first match
+	long := strings.Repeat("filler ", 60) + "invoice " + strings.Repeat("filler ", 60)
+	got = Highlight(long, []string{"invoice"})
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "<mark>invoice</mark>") {
+		t.Errorf("Unexpected snippet: %q", got)
+	}
+}
+
+func TestMemoryIndex(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndex()
+	owner := uuid.New()
+	now := time.Now()
+
+	docs := []*Document{
+		{ID: uuid.New(), OwnerID: owner, Name: "Annual report", Description: "Figures for 2024", Tags: []string{"finance"}, Status: "uploaded", CreatedAt: now},
+		{ID: uuid.New(), OwnerID: owner, Name: "Team photo", Description: "Reporting lines of the team", Tags: []string{"people"}, Status: "uploaded", CreatedAt: now.Add(time.Second)},
+		{ID: uuid.New(), OwnerID: owner, Name: "Budget", Description: "Draft budget", Tags: []string{"finance", "draft"}, Metadata: map[string]interface{}{"year": float64(2025)}, Status: "archived", CreatedAt: now.Add(2 * time.Second)},
+		{ID: uuid.New(), OwnerID: uuid.New(), Name: "Other report", Status: "uploaded", CreatedAt: now},
+	}
+	for _, doc := range docs {
+		if err := index.Index(ctx, doc); err != nil {
+			t.Fatalf("Index failed: %v", err)
+		}
+	}
+
+	// Name matches outrank description matches; word forms match
+	result, err := index.Search(ctx, &Query{Plan: mustCompile(t, "reports"), OwnerID: owner})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if result.Total != 2 || result.Hits[0].ID != docs[0].ID || result.Hits[1].ID != docs[1].ID {
+		t.Fatalf("Unexpected hits: %+v", result.Hits)
+	}
+	if result.Hits[0].Score <= result.Hits[1].Score {
+		t.Errorf("Expected the name match to score higher: %+v", result.Hits)
+	}
+	if result.Hits[0].Highlights["name"] != "Annual <mark>report</mark>" {
+		t.Errorf("Unexpected highlights: %v", result.Hits[0].Highlights)
+	}
+
+	// Every term must match; prefixes match
+	result, _ = index.Search(ctx, &Query{Plan: mustCompile(t, "ann rep"), OwnerID: owner})
+	if result.Total != 1 || result.Hits[0].ID != docs[0].ID {
+		t.Errorf("Expected only the annual report, got %+v", result.Hits)
+	}
+
+	// Filters without text return newest first
+	result, _ = index.Search(ctx, &Query{Tags: []string{"finance"}})
+	if result.Total != 2 || result.Hits[0].ID != docs[2].ID {
+		t.Errorf("Expected finance documents newest first, got %+v", result.Hits)
+	}
+	result, _ = index.Search(ctx, &Query{OwnerID: owner, Statuses: []string{"archived"}})
+	if result.Total != 1 || result.Hits[0].ID != docs[2].ID {
+		t.Errorf("Expected the archived document, got %+v", result.Hits)
+	}
+	result, _ = index.Search(ctx, &Query{Plan: mustCompile(t, "report"), IDs: []uuid.UUID{docs[3].ID}})
+	if result.Total != 1 || result.Hits[0].ID != docs[3].ID {
+		t.Errorf("Expected the restricted document, got %+v", result.Hits)
+	}
+
+	filter, _ := metafilter.New("$.year", metafilter.OpGte, 2025)
+	result, _ = index.Search(ctx, &Query{Tags: []string{"finance"}, Metadata: []*metafilter.Filter{filter}})
+	if result.Total != 1 || result.Hits[0].ID != docs[2].ID {
+		t.Errorf("Expected the document with matching metadata, got %+v", result.Hits)
+	}
+
+	// Pages keep the total
+	result, _ = index.Search(ctx, &Query{OwnerID: owner, Limit: 2, Offset: 2})
+	if result.Total != 3 || len(result.Hits) != 1 || result.Hits[0].ID != docs[0].ID {
+		t.Errorf("Unexpected page: total %d, hits %+v", result.Total, result.Hits)
+	}
+
+	// Keyset pages continue after the last hit and keep the total
+	first, _ := index.Search(ctx, &Query{OwnerID: owner, Limit: 2})
+	result, _ = index.Search(ctx, &Query{OwnerID: owner, After: first.Hits[1], Limit: 2})
+	if result.Total != 3 || len(result.Hits) != 1 || result.Hits[0].ID != docs[0].ID {
+		t.Errorf("Unexpected page after %v: total %d, hits %+v", first.Hits[1].ID, result.Total, result.Hits)
+	}
+
+	// Other sort keys, with keyset pages in their order
+	docs[0].Size, docs[1].Size, docs[2].Size = 300, 100, 200
+	for _, doc := range docs[:3] {
+		index.Index(ctx, doc)
+	}
+	result, _ = index.Search(ctx, &Query{OwnerID: owner, SortBy: SortName, Ascending: true})
+	if result.Hits[0].ID != docs[0].ID || result.Hits[1].ID != docs[2].ID {
+		t.Errorf("Expected names in ascending order, got %+v", result.Hits)
+	}
+	first, _ = index.Search(ctx, &Query{OwnerID: owner, SortBy: SortSize, Limit: 1})
+	result, _ = index.Search(ctx, &Query{OwnerID: owner, SortBy: SortSize, After: first.Hits[0]})
+	if first.Hits[0].ID != docs[0].ID || len(result.Hits) != 2 || result.Hits[0].ID != docs[2].ID {
+		t.Errorf("Expected the largest documents first, got %+v then %+v", first.Hits, result.Hits)
+	}
+
+	// Reindexing replaces the old terms; removed documents are gone
+	renamed := *docs[0]
+	renamed.Name = "Annual summary"
+	if err := index.Index(ctx, &renamed); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+	if err := index.Remove(ctx, docs[1].ID); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	result, _ = index.Search(ctx, &Query{Plan: mustCompile(t, "report"), OwnerID: owner})
+	if result.Total != 0 {
+		t.Errorf("Expected no matches, got %+v", result.Hits)
+	}
+	result, _ = index.Search(ctx, &Query{Plan: mustCompile(t, "summary")})
+	if result.Total != 1 || result.Hits[0].ID != docs[0].ID {
+		t.Errorf("Expected the renamed document, got %+v", result.Hits)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	for query, want := range map[string]string{
+		"":                               "",
+		"quarterly report":               "quarterly AND report",
+		`"Quarterly Report" -draft`:      `"quarterly report" AND NOT draft`,
+		"x OR y z":                       "x OR (y AND z)",
+		"(x OR y) z":                     "(x OR y) AND z",
+		"NOT tag:x OR status:failed":     "NOT tag:x OR status:failed",
+		`tag:"tax return" mime:Image/*`:  "tag:tax return AND mime:image/*",
+		"the report":                     "report",
+		"created:>=2025-01-01 size:<5MB": "created:>=2025-01-01 AND size:<5MB",
+	} {
+		if got := mustCompile(t, query).String(); got != want {
+			t.Errorf("Compile(%q) = %q, want %q", query, got, want)
+		}
+	}
+
+	plan := mustCompile(t, `report -draft "annual budget" tag:x`)
+	if strings.Join(plan.Terms, ",") != "report,annual,budget" {
+		t.Errorf("Unexpected terms: %v", plan.Terms)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for query, want := range map[string]string{
+		`"open phrase`:        `position 1: unterminated phrase`,
+		"(a OR b":             `position 1: missing ')'`,
+		"a)":                  `position 2: unexpected ')'`,
+		"a AND":               `position 3: AND must be followed by a term`,
+		"OR a":                `position 1: OR must be preceded by a term`,
+		"owner:me":            `position 1: unknown field "owner"`,
+		"x created:yesterday": `position 3: invalid date "yesterday"`,
+		"size:<5parsecs":      `position 1: invalid size "5parsecs"`,
+		"size:big":            `invalid size "big"`,
+		"mime:*/pdf":          `wildcards are only allowed at the end`,
+		"tag:":                `missing value after tag:`,
+	} {
+		_, err := Compile(query)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || !strings.Contains(err.Error(), want) {
+			t.Errorf("Compile(%q) error = %v, want %q", query, err, want)
+		}
+	}
+}
+
+func TestPlanMatch(t *testing.T) {
+	created := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	doc := &Document{
+		Name:         "Quarterly report Q1",
+		Description:  "Revenue and costs",
+		Tags:         []string{"Invoice", "finance"},
+		DocumentType: "application/pdf",
+		Status:       "uploaded",
+		Size:         3 << 20,
+		CreatedAt:    created,
+		UpdatedAt:    created,
+	}
+
+	for query, want := range map[string]bool{
+		"tag:invoice AND mime:application/pdf AND created:>2025-01-01 AND size:<5MB AND \"quarterly report\" -draft": true,
+		`"report quarterly"`:             false,
+		"name:revenue":                   false,
+		"description:revenue":            true,
+		"mime:application/*":             true,
+		"mime:image/*":                   false,
+		"created:2025-03-14":             true,
+		"created:2025-03-15":             false,
+		"created:<2025-03-14T10:00:00Z":  false,
+		"updated:<=2025-03-14T10:00:00Z": true,
+		"size:>=3MB size:<=3145728":      true,
+		"status:failed OR tag:finance":   true,
+		"NOT (tag:finance OR draft)":     false,
+		"reports cost":                   true,
+	} {
+		if got := mustCompile(t, query).Match(doc); got != want {
+			t.Errorf("Match(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestPlanAnd(t *testing.T) {
+	doc := &Document{Name: "Quarterly report", Size: 100}
+	plan := mustCompile(t, "report OR invoice")
+	small := plan.And(&CompareExpr{Field: "size", Op: OpLe, Value: "50", Size: 50})
+	if small.Match(doc) || !plan.Match(doc) {
+		t.Error("Expected the condition to apply to the plan only")
+	}
+	if got := small.String(); got != "(report OR invoice) AND size:<=50" {
+		t.Errorf("Unexpected plan %s", got)
+	}
+	if len(small.Terms) != 2 || mustCompile(t, "").And() == nil {
+		t.Errorf("Expected the terms to be kept, got %v", small.Terms)
+	}
+}
+
+func TestCompileSQL(t *testing.T) {
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	plan := mustCompile(t, `"annual report" tag:x (mime:image/* OR size:>1KB) -draft`)
+	got := compileSQL(plan.Expr, "search_vector", arg)
+	want := "((search_vector @@ to_tsquery('english', $1)) AND " +
+		"EXISTS (SELECT 1 FROM unnest(tags) AS tag WHERE lower(tag) = lower($2)) AND " +
+		"(lower(document_type) LIKE $3 OR size > $4) AND NOT (search_vector @@ to_tsquery('english', $5)))"
+	if got != want {
+		t.Errorf("Unexpected SQL:\n got %s\nwant %s", got, want)
+	}
+	if args[0] != "annual:* <-> report:*" || args[2] != "image/%" || args[3] != int64(1024) || args[4] != "draft:*" {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	after := &Hit{ID: uuid.New(), Size: 2048, CreatedAt: time.Now()}
+	got := keysetCondition(sortKeys(&Query{SortBy: SortSize, After: after}, "rank"), after.ID, true, arg)
+	want := "(size > $1 OR (size = $1 AND (created_at > $2 OR (created_at = $2 AND id > $3))))"
+	if got != want {
+		t.Errorf("Unexpected SQL:\n got %s\nwant %s", got, want)
+	}
+	if args[0] != int64(2048) || args[2] != after.ID {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	text := strings.Repeat("word ", 30) + "\n\n" + strings.Repeat("more ", 30)
+	passages := Chunk(text, 200)
+	if len(passages) != 2 {
+		t.Fatalf("Expected 2 passages, got %+v", passages)
+	}
+	for _, p := range passages {
+		if text[p.Start:p.End] != p.Text || p.Text != strings.TrimSpace(p.Text) {
+			t.Errorf("Passage text doesn't match its offsets: %+v", p)
+		}
+	}
+	if !strings.HasPrefix(passages[1].Text, "more") {
+		t.Errorf("Expected a break at the paragraph, got %+v", passages)
+	}
+
+	// Words without spaces are cut at rune boundaries
+	passages = Chunk(strings.Repeat("é", 300), 101)
+	for _, p := range passages {
+		if !utf8.ValidString(p.Text) {
+			t.Errorf("Passage cut inside a rune: %+v", p)
+		}
+	}
+	if len(Chunk(" \n ", 100)) != 0 {
+		t.Error("Expected no passages for blank text")
+	}
+}
+
+func TestMemoryIndexBody(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndex()
+	owner := uuid.New()
+
+	contract := &Document{ID: uuid.New(), OwnerID: owner, Name: "Supply agreement", Tags: []string{"legal"}, Status: "uploaded"}
+	memo := &Document{ID: uuid.New(), OwnerID: owner, Name: "Indemnification memo", Status: "uploaded"}
+	for _, doc := range []*Document{contract, memo} {
+		if err := index.Index(ctx, doc); err != nil {
+			t.Fatalf("Index failed: %v", err)
+		}
+	}
+
+	text := "1. Delivery terms apply.\n\n" + strings.Repeat("Filler text. ", 20) + "\n\n7. The supplier shall indemnify the buyer against all claims."
+	sourceID := uuid.New()
+	if err := index.IndexBody(ctx, &Body{ContentID: contract.ID, SourceID: sourceID, Passages: Chunk(text, 120)}); err != nil {
+		t.Fatalf("IndexBody failed: %v", err)
+	}
+	if err := index.IndexBody(ctx, &Body{ContentID: memo.ID, SourceID: memo.ID, Passages: Chunk("Notes on liability caps.", 0)}); err != nil {
+		t.Fatalf("IndexBody failed: %v", err)
+	}
+
+	// The body matches, not the title that mentions the word
+	result, err := index.SearchBody(ctx, &Query{Plan: mustCompile(t, "indemnify"), OwnerID: owner})
+	if err != nil {
+		t.Fatalf("SearchBody failed: %v", err)
+	}
+	if result.Total != 1 {
+		t.Fatalf("Expected 1 passage, got %+v", result.Hits)
+	}
+	hit := result.Hits[0]
+	if hit.ContentID != contract.ID || hit.SourceID != sourceID || !strings.Contains(text[hit.Start:hit.End], "shall indemnify the buyer") {
+		t.Errorf("Unexpected hit: %+v", hit)
+	}
+	if !strings.Contains(hit.Highlight, "<mark>indemnify</mark>") {
+		t.Errorf("Unexpected highlight: %q", hit.Highlight)
+	}
+
+	// Document filters and fields still apply
+	result, _ = index.SearchBody(ctx, &Query{Plan: mustCompile(t, `"supplier shall" tag:legal name:agreement`)})
+	if result.Total != 1 {
+		t.Errorf("Expected the contract passage, got %+v", result.Hits)
+	}
+	result, _ = index.SearchBody(ctx, &Query{Plan: mustCompile(t, "supplier"), Tags: []string{"other"}})
+	if result.Total != 0 {
+		t.Errorf("Expected no passages, got %+v", result.Hits)
+	}
+
+	// Removing the document removes its body
+	if err := index.Remove(ctx, contract.ID); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	result, _ = index.SearchBody(ctx, &Query{Plan: mustCompile(t, "supplier")})
+	if result.Total != 0 || index.passageCount != 1 {
+		t.Errorf("Expected the body to be gone, got %+v", result.Hits)
+	}
+}
@@ -0,0 +1,169 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a term of a text and its byte offsets in the text
+type Token struct {
+	Term  string // Lowercased, unstemmed
+	Start int
+	End   int
+}
+
+// Tokenize splits text into runs of letters and digits
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+		switch {
+		case word && start < 0:
+			start = i
+		case !word && start >= 0:
+			tokens = append(tokens, Token{Term: strings.ToLower(text[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Term: strings.ToLower(text[start:]), Start: start, End: len(text)})
+	}
+	return tokens
+}
+
+// stopwords are not indexed; queries made only of stopwords keep them
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "in": true, "is": true, "it": true, "of": true,
+	"on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "with": true,
+}
+
+// Terms returns the stemmed index terms of text, without stopwords
+func Terms(text string) []string {
+	var terms []string
+	for _, token := range Tokenize(text) {
+		if !stopwords[token.Term] {
+			terms = append(terms, Stem(token.Term))
+		}
+	}
+	return terms
+}
+
+// Stem reduces an English word to a crude stem so that plurals and common
+// inflections ("reports", "reporting", "reported") match each other. It
+// is applied alike to indexed and query terms, so it only has to be
+// consistent, not linguistically exact.
+func Stem(word string) string {
+	if utf8.RuneCountInString(word) <= 3 {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "xes"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		word = word[:len(word)-1]
+	}
+
+	for _, suffix := range []string{"ing", "ed"} {
+		if stem, ok := strings.CutSuffix(word, suffix); ok && len(stem) >= 3 && hasVowel(stem) {
+			word = undouble(stem)
+			break
+		}
+	}
+
+	if stem, ok := strings.CutSuffix(word, "ly"); ok && len(stem) >= 4 {
+		word = stem
+	}
+	return word
+}
+
+// hasVowel reports whether s contains a vowel
+func hasVowel(s string) bool {
+	return strings.ContainsAny(s, "aeiouy")
+}
+
+// undouble removes a doubled final consonant ("runn" -> "run")
+func undouble(s string) string {
+	n := len(s)
+	if n >= 2 && s[n-1] == s[n-2] && !strings.ContainsRune("aeiouls", rune(s[n-1])) {
+		return s[:n-1]
+	}
+	return s
+}
+
+// matchesTerm reports whether an index term satisfies a query term: the
+// stems are equal, or the term starts with a query term of 3+ letters
+func matchesTerm(indexTerm, queryTerm string) bool {
+	if indexTerm == Stem(queryTerm) {
+		return true
+	}
+	return len(queryTerm) >= 3 && strings.HasPrefix(indexTerm, queryTerm)
+}
+
+// snippetLength is the maximum length of a highlight snippet in bytes
+const snippetLength = 200
+
+// Highlight returns text with the words matching query terms (Plan.Terms)
+// wrapped in HighlightStart/HighlightEnd, shortened to a window around the
+// first match. It returns an empty string when nothing matches.
+func Highlight(text string, terms []string) string {
+	var matches []Token
+	for _, token := range Tokenize(text) {
+		stem := Stem(token.Term)
+		for _, term := range terms {
+			if matchesTerm(stem, term) || matchesTerm(token.Term, term) {
+				matches = append(matches, token)
+				break
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+
+	// Window of at most snippetLength bytes starting a little before the first match
+	start, end := 0, len(text)
+	if len(text) > snippetLength {
+		start = max(0, matches[0].Start-snippetLength/4)
+		for start > 0 && !utf8.RuneStart(text[start]) {
+			start--
+		}
+		// Don't start in the middle of a word
+		if i := strings.IndexAny(text[start:matches[0].Start], " \t\n"); start > 0 && i >= 0 {
+			start += i + 1
+		}
+		end = min(len(text), start+snippetLength)
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end++
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m.Start < start || m.End > end {
+			continue
+		}
+		b.WriteString(text[pos:m.Start])
+		b.WriteString(HighlightStart)
+		b.WriteString(text[m.Start:m.End])
+		b.WriteString(HighlightEnd)
+		pos = m.End
+	}
+	b.WriteString(text[pos:end])
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,171 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/extract"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/search"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/semantic"
+)
+
+// maxEmbeddedPassages is the number of leading passages of a content text
+// that are embedded
+const maxEmbeddedPassages = 256
+
+// embedContent brings the vectors of one field of a content up to date when
+// semantic search is enabled: semantic.FieldMetadata for its name and
+// description, semantic.FieldText for passages of its text. Like
+// indexContent, it logs failures.
+func (s *Server) embedContent(ctx context.Context, contentID uuid.UUID, field string) {
+	if err := s.reembedContent(ctx, contentID, field); err != nil {
+		log.Printf("Failed to embed %s of content %s: %v", field, contentID, err)
+	}
+}
+
+// reembedContent embeds or removes one field of a content
+func (s *Server) reembedContent(ctx context.Context, contentID uuid.UUID, field string) error {
+	if s.vectorIndex == nil {
+		return nil
+	}
+
+	content, err := s.service.GetContent(ctx, contentID)
+	if err != nil {
+		if mcperrors.IsNotFound(err) {
+			return s.vectorIndex.Remove(ctx, contentID)
+		}
+		return err
+	}
+	// Extracted text is embedded as the text of the content it was extracted from
+	if content.DeletedAt != nil || simplecontent.ContentStatus(content.Status) == simplecontent.ContentStatusDeleted ||
+		isCollection(content) || content.DerivationType == extract.DerivationType {
+		return s.vectorIndex.Remove(ctx, contentID)
+	}
+
+	var passages []search.Passage
+	if field == semantic.FieldText {
+		text, _, err := s.bodyText(ctx, content)
+		if err != nil {
+			return err
+		}
+		passages = search.Chunk(text, search.DefaultPassageSize)
+		if len(passages) > maxEmbeddedPassages {
+			passages = passages[:maxEmbeddedPassages]
+		}
+	} else if text := strings.TrimSpace(content.Name + "\n" + content.Description); text != "" {
+		passages = []search.Passage{{Text: text}}
+	}
+
+	doc := &semantic.Document{
+		ContentID: content.ID,
+		OwnerID:   content.OwnerID,
+		TenantID:  content.TenantID,
+		Field:     field,
+		Entries:   make([]semantic.Entry, len(passages)),
+	}
+	if len(passages) > 0 {
+		texts := make([]string, len(passages))
+		for i, passage := range passages {
+			texts[i] = passage.Text
+		}
+		vectors, err := s.config.Embedder.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to embed: %w", err)
+		}
+		if len(vectors) != len(texts) {
+			return fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(texts))
+		}
+		for i, passage := range passages {
+			doc.Entries[i] = semantic.Entry{Start: passage.Start, End: passage.End, Vector: vectors[i]}
+		}
+	}
+	return s.vectorIndex.Index(ctx, doc)
+}
+
+// handleSemanticSearch returns the contents whose name, description or text
+// is nearest in meaning to the query, by cosine similarity of embeddings
+func (s *Server) handleSemanticSearch(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return nil, mcperrors.NewValidationError("arguments", err)
+	}
+	if s.vectorIndex == nil {
+		return nil, mcperrors.NewValidationError("query", fmt.Errorf("semantic search is not enabled on this server"))
+	}
+
+	queryText := strings.TrimSpace(getStringOr(params, "query", ""))
+	if queryText == "" {
+		return nil, mcperrors.NewValidationError("query", fmt.Errorf("query is required"))
+	}
+
+	k := getIntOr(params, "k", 10)
+	if k <= 0 || k > s.config.MaxPageSize {
+		return nil, mcperrors.NewValidationError("k", fmt.Errorf("must be between 1 and %d", s.config.MaxPageSize))
+	}
+
+	query := &semantic.Query{K: k}
+	if minScore, ok := params["min_score"].(float64); ok {
+		query.MinScore = minScore
+	}
+	var err error
+	query.OwnerID, query.TenantID, err = s.parseScope(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.RequireOwnerID && query.OwnerID == uuid.Nil && query.TenantID == uuid.Nil {
+		return nil, mcperrors.NewValidationError("owner_id", fmt.Errorf("owner_id or tenant_id is required"))
+	}
+
+	vectors, err := s.config.Embedder.Embed(ctx, []string{queryText})
+	if err != nil || len(vectors) != 1 {
+		return nil, mcperrors.NewInternalError(fmt.Errorf("failed to embed query: %v", err))
+	}
+	query.Vector = vectors[0]
+
+	hits, err := s.vectorIndex.Search(ctx, query)
+	if err != nil {
+		return nil, mcperrors.NewInternalError(fmt.Errorf("semantic search failed: %w", err))
+	}
+
+	// Format results from the current content records
+	items := make([]map[string]interface{}, 0, len(hits))
+	for _, hit := range hits {
+		content, err := s.service.GetContent(ctx, hit.ContentID)
+		if err != nil {
+			if mcperrors.IsNotFound(err) {
+				// Deleted behind the index's back
+				s.unindexContent(ctx, hit.ContentID)
+				continue
+			}
+			return nil, s.mapError(err)
+		}
+
+		item := map[string]interface{}{
+			"id":            content.ID.String(),
+			"name":          content.Name,
+			"description":   content.Description,
+			"document_type": content.DocumentType,
+			"status":        string(content.Status),
+			"score":         hit.Score,
+			"matched":       hit.Field,
+		}
+		if hit.Field == semantic.FieldText {
+			item["start"] = hit.Start
+			item["end"] = hit.End
+		}
+		items = append(items, item)
+	}
+
+	return newTextResult(formatJSON(map[string]interface{}{
+		"items": items,
+		"k":     k,
+	})), nil
+}
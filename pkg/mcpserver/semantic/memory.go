@@ -0,0 +1,126 @@
+package semantic
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// memoryContent holds the normalized vectors of a content per field
+type memoryContent struct {
+	ownerID  uuid.UUID
+	tenantID uuid.UUID
+	fields   map[string][]Entry
+}
+
+// MemoryIndex is an in-process Index comparing the query vector with every
+// stored vector. It is lost when the process exits.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	contents map[uuid.UUID]*memoryContent
+}
+
+// NewMemoryIndex creates an empty index
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{contents: make(map[uuid.UUID]*memoryContent)}
+}
+
+// Index replaces the vectors of a field of a content with those of doc
+func (x *MemoryIndex) Index(ctx context.Context, doc *Document) error {
+	entries := make([]Entry, 0, len(doc.Entries))
+	for _, entry := range doc.Entries {
+		entry.Vector = slices.Clone(entry.Vector)
+		if Normalize(entry.Vector) {
+			entries = append(entries, entry)
+		}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	content, ok := x.contents[doc.ContentID]
+	if !ok {
+		content = &memoryContent{fields: make(map[string][]Entry)}
+		x.contents[doc.ContentID] = content
+	}
+	content.ownerID, content.tenantID = doc.OwnerID, doc.TenantID
+	if len(entries) == 0 {
+		delete(content.fields, doc.Field)
+	} else {
+		content.fields[doc.Field] = entries
+	}
+	if len(content.fields) == 0 {
+		delete(x.contents, doc.ContentID)
+	}
+	return nil
+}
+
+// Remove drops all vectors of a content
+func (x *MemoryIndex) Remove(ctx context.Context, contentID uuid.UUID) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.contents, contentID)
+	return nil
+}
+
+// Search returns the K contents nearest to the query vector
+func (x *MemoryIndex) Search(ctx context.Context, query *Query) ([]*Hit, error) {
+	vector := slices.Clone(query.Vector)
+	if !Normalize(vector) {
+		return []*Hit{}, nil
+	}
+	var ids map[uuid.UUID]bool
+	if query.IDs != nil {
+		ids = make(map[uuid.UUID]bool, len(query.IDs))
+		for _, id := range query.IDs {
+			ids[id] = true
+		}
+	}
+
+	x.mu.RLock()
+	hits := []*Hit{}
+	for id, content := range x.contents {
+		if (query.OwnerID != uuid.Nil && content.ownerID != query.OwnerID) ||
+			(query.TenantID != uuid.Nil && content.tenantID != query.TenantID) ||
+			(ids != nil && !ids[id]) {
+			continue
+		}
+
+		var best *Hit
+		for field, entries := range content.fields {
+			for _, entry := range entries {
+				score := roundScore(dot(vector, entry.Vector))
+				if best == nil || score > best.Score || (score == best.Score && field < best.Field) {
+					best = &Hit{ContentID: id, Score: score, Field: field, Start: entry.Start, End: entry.End}
+				}
+			}
+		}
+		if best != nil && best.Score >= query.MinScore {
+			hits = append(hits, best)
+		}
+	}
+	x.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ContentID.String() < hits[j].ContentID.String()
+	})
+	if query.K > 0 && len(hits) > query.K {
+		hits = hits[:query.K]
+	}
+	return hits, nil
+}
+
+// dot returns the dot product of two vectors, their cosine similarity when
+// both are normalized
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range min(len(a), len(b)) {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
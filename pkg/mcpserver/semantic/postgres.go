@@ -0,0 +1,177 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DBTX is satisfied by pgxpool.Pool, pgx.Conn and pgx.Tx
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...interface{}) pgx.Row
+}
+
+// postgresSchema creates the vector table; it is safe to run repeatedly.
+// The embedding column has the dimensions of the embedder, substituted for %d.
+const postgresSchema = `
+CREATE EXTENSION IF NOT EXISTS vector;
+CREATE TABLE IF NOT EXISTS mcp_vectors (
+    content_id UUID NOT NULL,
+    owner_id UUID NOT NULL,
+    tenant_id UUID NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    start_offset BIGINT NOT NULL,
+    end_offset BIGINT NOT NULL,
+    embedding vector(%d) NOT NULL,
+    PRIMARY KEY (content_id, field, start_offset)
+);
+CREATE INDEX IF NOT EXISTS idx_mcp_vectors_embedding ON mcp_vectors USING hnsw (embedding vector_cosine_ops);
+CREATE INDEX IF NOT EXISTS idx_mcp_vectors_owner ON mcp_vectors(owner_id);
+`
+
+// PostgresIndex is an Index stored in PostgreSQL with the pgvector
+// extension, ranked by cosine distance
+type PostgresIndex struct {
+	db         DBTX
+	dimensions int
+}
+
+// NewPostgresIndex creates an index of vectors with dimensions values on db.
+// Call EnsureSchema to create its table.
+func NewPostgresIndex(db DBTX, dimensions int) *PostgresIndex {
+	return &PostgresIndex{db: db, dimensions: dimensions}
+}
+
+// EnsureSchema creates the pgvector extension, the vector table and its
+// indexes if they don't exist
+func (x *PostgresIndex) EnsureSchema(ctx context.Context) error {
+	if _, err := x.db.Exec(ctx, fmt.Sprintf(postgresSchema, x.dimensions)); err != nil {
+		return fmt.Errorf("failed to create vector schema: %w", err)
+	}
+	return nil
+}
+
+// Index replaces the vectors of a field of a content with those of doc
+func (x *PostgresIndex) Index(ctx context.Context, doc *Document) error {
+	if _, err := x.db.Exec(ctx, `DELETE FROM mcp_vectors WHERE content_id = $1 AND field = $2`, doc.ContentID, doc.Field); err != nil {
+		return fmt.Errorf("failed to remove vectors: %w", err)
+	}
+
+	var starts, ends []int64
+	var vectors []string
+	for _, entry := range doc.Entries {
+		if len(entry.Vector) != x.dimensions {
+			return fmt.Errorf("vector has %d dimensions, index has %d", len(entry.Vector), x.dimensions)
+		}
+		// Zero vectors have no cosine distance
+		if vector := vectorLiteral(entry.Vector); vector != "" {
+			starts, ends, vectors = append(starts, entry.Start), append(ends, entry.End), append(vectors, vector)
+		}
+	}
+	if len(vectors) == 0 {
+		return nil
+	}
+
+	_, err := x.db.Exec(ctx, `
+		INSERT INTO mcp_vectors (content_id, owner_id, tenant_id, field, start_offset, end_offset, embedding)
+		SELECT $1, $2, $3, $4, v.start_offset, v.end_offset, v.embedding::vector
+		FROM unnest($5::bigint[], $6::bigint[], $7::text[]) AS v(start_offset, end_offset, embedding)`,
+		doc.ContentID, doc.OwnerID, doc.TenantID, doc.Field, starts, ends, vectors)
+	if err != nil {
+		return fmt.Errorf("failed to index vectors: %w", err)
+	}
+	return nil
+}
+
+// Remove drops all vectors of a content
+func (x *PostgresIndex) Remove(ctx context.Context, contentID uuid.UUID) error {
+	if _, err := x.db.Exec(ctx, `DELETE FROM mcp_vectors WHERE content_id = $1`, contentID); err != nil {
+		return fmt.Errorf("failed to remove vectors: %w", err)
+	}
+	return nil
+}
+
+// Search returns the K contents nearest to the query vector, each with its
+// nearest entry
+func (x *PostgresIndex) Search(ctx context.Context, query *Query) ([]*Hit, error) {
+	vector := vectorLiteral(query.Vector)
+	if vector == "" {
+		return []*Hit{}, nil
+	}
+
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	distance := "embedding <=> " + arg(vector) + "::vector"
+	conditions := []string{"TRUE"}
+	if query.OwnerID != uuid.Nil {
+		conditions = append(conditions, "owner_id = "+arg(query.OwnerID))
+	}
+	if query.TenantID != uuid.Nil {
+		conditions = append(conditions, "tenant_id = "+arg(query.TenantID))
+	}
+	if query.IDs != nil {
+		conditions = append(conditions, "content_id = ANY("+arg(query.IDs)+")")
+	}
+	limit := "ALL"
+	if query.K > 0 {
+		limit = arg(query.K)
+	}
+
+	rows, err := x.db.Query(ctx, `
+		SELECT content_id, score, field, start_offset, end_offset FROM (
+			SELECT content_id, field, start_offset, end_offset,
+				ROUND((1 - (`+distance+`))::numeric, 3)::float8 AS score,
+				row_number() OVER (PARTITION BY content_id ORDER BY `+distance+`, field, start_offset) AS nearest
+			FROM mcp_vectors
+			WHERE `+strings.Join(conditions, " AND ")+`
+		) entries
+		WHERE nearest = 1 AND score >= `+arg(query.MinScore)+`
+		ORDER BY score DESC, content_id
+		LIMIT `+limit, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search vectors: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []*Hit{}
+	for rows.Next() {
+		hit := &Hit{}
+		if err := rows.Scan(&hit.ContentID, &hit.Score, &hit.Field, &hit.Start, &hit.End); err != nil {
+			return nil, fmt.Errorf("failed to scan vector hit: %w", err)
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// vectorLiteral formats a vector in pgvector's text format, or returns an
+// empty string for a zero vector
+func vectorLiteral(vector []float32) string {
+	var b strings.Builder
+	zero := true
+	b.WriteByte('[')
+	for i, v := range vector {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if v != 0 {
+			zero = false
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	if zero {
+		return ""
+	}
+	return b.String()
+}
@@ -0,0 +1,166 @@
+// Package semantic indexes content embeddings for semantic_search.
+//
+// An Embedder turns texts into vectors whose cosine similarity reflects how
+// close the texts are in meaning. HashEmbedder is a deterministic local
+// embedder hashing words and character n-grams, so semantic search works
+// offline; embedders backed by a model plug in through the same interface.
+// An Index stores the vectors of each content per field (its metadata, i.e.
+// name and description, and passages of its text) and returns the contents
+// nearest to a query vector. MemoryIndex compares vectors by brute force;
+// PostgresIndex uses the pgvector extension.
+package semantic
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Fields of a content that are embedded
+const (
+	FieldMetadata = "metadata" // Name and description
+	FieldText     = "text"     // Passages of the content text or its extracted text
+)
+
+// DefaultDimensions is the number of dimensions of a HashEmbedder by default
+const DefaultDimensions = 256
+
+// Embedder computes embeddings
+type Embedder interface {
+	// Embed returns one vector of Dimensions values per text
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Dimensions is the length of the vectors
+	Dimensions() int
+}
+
+// HashEmbedder embeds texts with the hashing trick: each word and each
+// character trigram of a word is hashed to a dimension and a sign. Texts
+// sharing words or word stems get similar vectors. It needs no model and
+// always returns the same vector for the same text.
+type HashEmbedder struct {
+	dimensions int
+}
+
+// NewHashEmbedder creates an embedder of vectors with dimensions values
+// (DefaultDimensions if not positive)
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = DefaultDimensions
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+// Dimensions is the length of the vectors
+func (e *HashEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+// Embed returns the normalized vector of each text. Texts without words
+// get a zero vector.
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, e.dimensions)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, word := range words {
+			e.add(vector, "w:"+word, 1)
+			// Trigrams of the word padded with its boundaries, so "lease"
+			// and "leases" share most of theirs
+			runes := []rune("^" + word + "$")
+			for j := 0; j+3 <= len(runes); j++ {
+				e.add(vector, "g:"+string(runes[j:j+3]), 0.5)
+			}
+		}
+		Normalize(vector)
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// add adds weight to the dimension feature hashes to, with the hashed sign
+func (e *HashEmbedder) add(vector []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vector[sum%uint64(e.dimensions)] += weight
+}
+
+// Normalize scales vector to unit length in place. It leaves zero vectors
+// unchanged and reports whether vector was non-zero.
+func Normalize(vector []float32) bool {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return false
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+	return true
+}
+
+// Entry is the vector of a field, or of a passage of the text field between
+// the byte offsets Start and End
+type Entry struct {
+	Start  int64
+	End    int64
+	Vector []float32
+}
+
+// Document holds the vectors of one field of a content
+type Document struct {
+	ContentID uuid.UUID
+	OwnerID   uuid.UUID
+	TenantID  uuid.UUID
+	Field     string // FieldMetadata or FieldText
+	Entries   []Entry
+}
+
+// Query selects the contents nearest to Vector. Empty fields don't filter.
+type Query struct {
+	Vector   []float32
+	OwnerID  uuid.UUID
+	TenantID uuid.UUID
+	IDs      []uuid.UUID // Restricts results to these contents when not nil
+	K        int         // Number of contents returned
+	MinScore float64     // Least cosine similarity of a hit
+}
+
+// Hit is a content near a query: its best matching entry and its cosine
+// similarity, rounded to 3 decimals
+type Hit struct {
+	ContentID uuid.UUID
+	Score     float64
+	Field     string
+	Start     int64
+	End       int64
+}
+
+// Index stores content vectors
+type Index interface {
+	// Index replaces the vectors of a field of a content with those of doc
+	Index(ctx context.Context, doc *Document) error
+	// Remove drops all vectors of a content
+	Remove(ctx context.Context, contentID uuid.UUID) error
+	// Search returns the K contents nearest to the query vector, by
+	// decreasing score then ID
+	Search(ctx context.Context, query *Query) ([]*Hit, error)
+}
+
+// roundScore rounds a similarity to 3 decimals, so equal-looking scores
+// sort by ID
+func roundScore(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
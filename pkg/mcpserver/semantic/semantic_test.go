@@ -0,0 +1,109 @@
+package semantic
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHashEmbedder(t *testing.T) {
+	ctx := context.Background()
+	e := NewHashEmbedder(0)
+	if e.Dimensions() != DefaultDimensions {
+		t.Fatalf("Expected %d dimensions, got %d", DefaultDimensions, e.Dimensions())
+	}
+
+	vectors, err := e.Embed(ctx, []string{
+		"Office lease agreement",
+		"Contract for leasing offices",
+		"Quarterly sales report",
+		"  ",
+		"Office lease agreement",
+	})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(vectors) != 5 || len(vectors[0]) != DefaultDimensions {
+		t.Fatalf("Unexpected vectors: %d of %d", len(vectors), len(vectors[0]))
+	}
+
+	if norm := math.Sqrt(dot(vectors[0], vectors[0])); math.Abs(norm-1) > 1e-5 {
+		t.Errorf("Expected a unit vector, got norm %f", norm)
+	}
+	if near, far := dot(vectors[0], vectors[1]), dot(vectors[0], vectors[2]); near <= far {
+		t.Errorf("Expected related texts to be closer: %f <= %f", near, far)
+	}
+	if Normalize(vectors[3]) {
+		t.Error("Expected a zero vector for a text without words")
+	}
+	if dot(vectors[0], vectors[4]) < 0.9999 {
+		t.Error("Expected the same vector for the same text")
+	}
+}
+
+func TestMemoryIndex(t *testing.T) {
+	ctx := context.Background()
+	x := NewMemoryIndex()
+	owner := uuid.New()
+	lease, report, other := uuid.New(), uuid.New(), uuid.New()
+
+	index := func(id, ownerID uuid.UUID, field string, entries ...Entry) {
+		t.Helper()
+		if err := x.Index(ctx, &Document{ContentID: id, OwnerID: ownerID, Field: field, Entries: entries}); err != nil {
+			t.Fatalf("Index failed: %v", err)
+		}
+	}
+	index(lease, owner, FieldMetadata, Entry{Vector: []float32{1, 0, 0}})
+	index(lease, owner, FieldText, Entry{Start: 0, End: 10, Vector: []float32{0, 1, 0}}, Entry{Start: 10, End: 20, Vector: []float32{0, 3, 3}})
+	index(report, owner, FieldMetadata, Entry{Vector: []float32{0, 0, 2}})
+	index(other, uuid.New(), FieldMetadata, Entry{Vector: []float32{1, 0, 0}})
+
+	hits, err := x.Search(ctx, &Query{Vector: []float32{0, 1, 1}, OwnerID: owner, K: 10})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(hits) != 2 || hits[0].ContentID != lease || hits[1].ContentID != report {
+		t.Fatalf("Unexpected hits: %+v", hits)
+	}
+	if hits[0].Score != 1 || hits[0].Field != FieldText || hits[0].Start != 10 || hits[0].End != 20 {
+		t.Errorf("Expected the best passage of lease, got %+v", hits[0])
+	}
+	if hits[1].Score != 0.707 {
+		t.Errorf("Expected a rounded cosine similarity, got %f", hits[1].Score)
+	}
+
+	hits, _ = x.Search(ctx, &Query{Vector: []float32{0, 1, 1}, OwnerID: owner, K: 10, MinScore: 0.8})
+	if len(hits) != 1 {
+		t.Errorf("Expected min_score to drop the report, got %+v", hits)
+	}
+	hits, _ = x.Search(ctx, &Query{Vector: []float32{1, 0, 0}, K: 1})
+	if len(hits) != 1 || hits[0].Score != 1 {
+		t.Errorf("Expected K to limit hits, got %+v", hits)
+	}
+
+	// Reindexing a field replaces its vectors only
+	index(lease, owner, FieldText)
+	hits, _ = x.Search(ctx, &Query{Vector: []float32{1, 0, 0}, IDs: []uuid.UUID{lease}})
+	if len(hits) != 1 || hits[0].Field != FieldMetadata {
+		t.Errorf("Expected the metadata vector to remain, got %+v", hits)
+	}
+
+	if err := x.Remove(ctx, lease); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	hits, _ = x.Search(ctx, &Query{Vector: []float32{1, 0, 0}, IDs: []uuid.UUID{lease}})
+	if len(hits) != 0 {
+		t.Errorf("Expected no hits after Remove, got %+v", hits)
+	}
+}
+
+func TestVectorLiteral(t *testing.T) {
+	if got := vectorLiteral([]float32{0.5, -1, 0}); got != "[0.5,-1,0]" {
+		t.Errorf("Unexpected literal %s", got)
+	}
+	if got := vectorLiteral([]float32{0, 0}); got != "" {
+		t.Errorf("Expected no literal for a zero vector, got %s", got)
+	}
+}
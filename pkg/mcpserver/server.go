@@ -5,18 +5,28 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/tendant/simple-content/pkg/simplecontent"
 	"github.com/tendant/simple-content/pkg/simplecontent/admin"
 
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/auth"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/cursor"
 	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/extract"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/jobs"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/search"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/semantic"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/thumbnail"
 )
 
 // Server wraps a simple-content Service and exposes it via MCP
@@ -25,6 +35,19 @@ type Server struct {
 	adminService admin.AdminService // Optional: for admin operations
 	mcpServer    *mcp.Server
 	config       Config
+
+	background sync.WaitGroup // Tracks background tasks started by Serve
+	locks      contentLocks   // Serializes read-modify-write sequences per content
+
+	jobQueue   jobs.Queue     // Pending and finished derivation jobs
+	jobPool    *jobs.Pool     // Runs jobs from jobQueue
+	processors *jobs.Registry // Processors available to jobs
+
+	searchIndex    search.Index   // Index queried by search_content
+	indexPopulated atomic.Bool    // Whether searchIndex holds all live content (see populatedIndex)
+	vectorIndex    semantic.Index // Index queried by semantic_search (nil when disabled)
+
+	cursors *cursor.Signer // Signs and verifies pagination cursors
 }
 
 // New creates a new MCP server
@@ -38,7 +61,41 @@ func New(config Config) (*Server, error) {
 		service:      config.Service,
 		adminService: config.AdminService,
 		config:       config,
+		jobQueue:     config.JobQueue,
+		searchIndex:  config.SearchIndex,
+		cursors:      cursor.NewSigner(config.CursorSecret),
+	}
+
+	if s.searchIndex == nil {
+		s.searchIndex = search.NewMemoryIndex()
+	}
+	// A configured index persists across restarts; it is trusted unless a
+	// startup rebuild is about to refill it. The in-memory default only knows
+	// content indexed by this process until a rebuild completes.
+	s.indexPopulated.Store(config.SearchIndex != nil && !(config.RebuildIndexOnStartup && config.AdminService != nil))
+	if config.Embedder != nil {
+		s.vectorIndex = config.VectorIndex
+		if s.vectorIndex == nil {
+			s.vectorIndex = semantic.NewMemoryIndex()
+		}
+	}
+
+	// Derivation jobs
+	if s.jobQueue == nil {
+		s.jobQueue = jobs.NewMemoryQueue()
+	}
+	thumbnails := thumbnail.NewProcessor(config.ThumbnailSizes, config.ThumbnailMaxPixels)
+	if config.ThumbnailMaxSize > 0 {
+		thumbnails.MaxSize = config.ThumbnailMaxSize
+	}
+	s.processors = jobs.NewRegistry(thumbnails, extract.NewProcessor())
+	for _, processor := range config.Processors {
+		s.processors.Register(processor)
 	}
+	s.jobPool = jobs.NewPool(s.jobQueue, s.runJob, jobs.PoolOptions{
+		Workers:      config.JobWorkers,
+		RetryBackoff: config.JobRetryBackoff,
+	})
 
 	// Create MCP server
 	impl := &mcp.Implementation{
@@ -68,8 +125,17 @@ func New(config Config) (*Server, error) {
 	return s, nil
 }
 
-// Serve starts the MCP server with the configured transport
+// Serve starts the MCP server with the configured transport.
+// Background tasks are stopped and awaited before Serve returns.
 func (s *Server) Serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		s.background.Wait()
+	}()
+
+	s.startBackground(ctx)
+
 	switch s.config.Mode {
 	case TransportStdio:
 		return s.serveStdio(ctx)
@@ -82,6 +148,53 @@ func (s *Server) Serve(ctx context.Context) error {
 	}
 }
 
+// startBackground launches periodic maintenance tasks bound to ctx
+func (s *Server) startBackground(ctx context.Context) {
+	if s.config.RebuildIndexOnStartup {
+		if s.adminService == nil {
+			log.Println("Search index rebuild disabled: admin service not configured")
+		} else {
+			s.goBackground(func() {
+				indexed, err := s.RebuildSearchIndex(ctx)
+				if err != nil && ctx.Err() == nil {
+					log.Printf("Search index rebuild failed: %v", err)
+					return
+				}
+				log.Printf("Search index rebuilt: %d content(s) indexed", indexed)
+			})
+		}
+	}
+
+	if s.config.OrphanSweepInterval > 0 {
+		if s.adminService == nil {
+			log.Println("Orphan sweeper disabled: admin service not configured")
+		} else {
+			s.goBackground(func() { s.runOrphanSweeper(ctx, s.config.OrphanSweepInterval) })
+		}
+	}
+
+	if s.config.ExpirySweepInterval > 0 {
+		if s.adminService == nil {
+			log.Println("Expiry sweeper disabled: admin service not configured")
+		} else {
+			s.goBackground(func() { s.runExpirySweeper(ctx, s.config.ExpirySweepInterval) })
+		}
+	}
+
+	if s.config.JobWorkers > 0 {
+		s.goBackground(func() { s.jobPool.Run(ctx) })
+	}
+}
+
+// goBackground runs fn in a goroutine tracked by the server
+func (s *Server) goBackground(fn func()) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		fn()
+	}()
+}
+
 func (s *Server) serveStdio(ctx context.Context) error {
 	transport := &mcp.StdioTransport{}
 
@@ -320,7 +433,7 @@ func extractAPIKeyFromHeader(r *http.Request) string {
 }
 
 // decodeData handles both base64 and URL data sources
-func (s *Server) decodeData(data interface{}) (io.Reader, error) {
+func (s *Server) decodeData(data interface{}) (*bytes.Reader, error) {
 	dataStr, ok := data.(string)
 	if !ok {
 		return nil, mcperrors.NewValidationError("data", fmt.Errorf("must be a string"))
@@ -341,7 +454,7 @@ func (s *Server) decodeData(data interface{}) (io.Reader, error) {
 }
 
 // downloadFromURL fetches data from a URL
-func (s *Server) downloadFromURL(url string) (io.Reader, error) {
+func (s *Server) downloadFromURL(url string) (*bytes.Reader, error) {
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, mcperrors.NewInternalError(fmt.Errorf("failed to download from URL: %w", err))
@@ -475,6 +588,48 @@ func newTextResult(text string) *mcp.CallToolResult {
 	}
 }
 
+// authorizeAccess checks that the caller may act on content of the given
+// owner and tenant. It is a no-op when authentication is disabled.
+func (s *Server) authorizeAccess(ctx context.Context, ownerID, tenantID uuid.UUID) error {
+	if !s.config.AuthEnabled {
+		return nil
+	}
+
+	if err := auth.EnforceAccess(ctx, ownerID, tenantID); err != nil {
+		if errors.Is(err, auth.ErrUnauthorized) {
+			return mcperrors.NewUnauthorizedError(err.Error())
+		}
+		return mcperrors.NewForbiddenError(fmt.Sprintf("no access to owner %s", ownerID))
+	}
+	return nil
+}
+
+// parseScope reads the optional owner_id and tenant_id arguments scoping a
+// listing and checks that the caller may list that scope. Listings without
+// an owner span every owner, so with authentication they need an admin key.
+// Listings of a collection_id are authorized member by member instead.
+func (s *Server) parseScope(ctx context.Context, params map[string]interface{}) (uuid.UUID, uuid.UUID, error) {
+	var ids [2]uuid.UUID
+	for i, name := range []string{"owner_id", "tenant_id"} {
+		if params[name] == nil || params[name] == "" {
+			continue
+		}
+		id, err := parseUUID(params[name])
+		if err != nil {
+			return uuid.Nil, uuid.Nil, mcperrors.NewValidationError(name, err)
+		}
+		ids[i] = id
+	}
+
+	if _, inCollection, _ := parseCollectionFilter(params); inCollection {
+		return ids[0], ids[1], nil
+	}
+	if err := s.authorizeAccess(ctx, ids[0], ids[1]); err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return ids[0], ids[1], nil
+}
+
 // mapError maps simple-content errors to MCP errors
 func (s *Server) mapError(err error) error {
 	return mcperrors.MapError(err)
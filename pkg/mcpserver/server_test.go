@@ -1,16 +1,29 @@
 package mcpserver
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"image/png"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/auth"
+	mcperrors "github.com/tendant/simple-content-mcp/pkg/mcpserver/errors"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/jobs"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/metafilter"
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/semantic"
 	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/admin"
 	memoryrepo "github.com/tendant/simple-content/pkg/simplecontent/repo/memory"
 	memorystorage "github.com/tendant/simple-content/pkg/simplecontent/storage/memory"
 )
@@ -44,6 +57,76 @@ func createTestServer(t *testing.T) *Server {
 	return server
 }
 
+// callTool invokes a tool handler with args and decodes the JSON result
+func callTool(t *testing.T, handler mcp.ToolHandler, args map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		t.Fatalf("Failed to marshal args: %v", err)
+	}
+
+	result, err := handler(context.Background(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Arguments: argsJSON},
+	})
+	if err != nil {
+		t.Fatalf("Tool call failed: %v", err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &data); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	return data
+}
+
+// uploadTestContent uploads content through the upload_content tool and returns its ID
+func uploadTestContent(t *testing.T, server *Server, args map[string]interface{}) uuid.UUID {
+	t.Helper()
+
+	if args["data"] == nil {
+		args["data"] = base64.StdEncoding.EncodeToString([]byte("test data"))
+	}
+
+	data := callTool(t, server.handleUploadContent, args)
+	id, err := uuid.Parse(data["id"].(string))
+	if err != nil {
+		t.Fatalf("Invalid content ID: %v", err)
+	}
+	return id
+}
+
+// processJobs runs the queued derivation jobs
+func processJobs(t *testing.T, server *Server) {
+	t.Helper()
+
+	if _, err := server.ProcessJobs(context.Background()); err != nil {
+		t.Fatalf("Failed to process jobs: %v", err)
+	}
+}
+
+// uploadTestDerived attaches derived content to parentID through the service
+func uploadTestDerived(t *testing.T, server *Server, parentID uuid.UUID, variant string) uuid.UUID {
+	t.Helper()
+
+	parent, err := server.service.GetContent(context.Background(), parentID)
+	if err != nil {
+		t.Fatalf("Failed to get parent: %v", err)
+	}
+
+	derived, err := server.service.UploadDerivedContent(context.Background(), simplecontent.UploadDerivedContentRequest{
+		ParentID: parentID,
+		OwnerID:  parent.OwnerID,
+		TenantID: parent.TenantID,
+		Variant:  variant,
+		Reader:   strings.NewReader(variant + " data"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to upload derived content: %v", err)
+	}
+	return derived.ID
+}
+
 func TestServerCreation(t *testing.T) {
 	service := createTestService(t)
 	config := DefaultConfig(service)
@@ -322,6 +405,107 @@ func TestListContentTool(t *testing.T) {
 	}
 }
 
+// fakeLister lists fixed items and records the listing it was asked for
+type fakeLister struct {
+	items   []metafilter.Item
+	listing *metafilter.Listing
+}
+
+func (l *fakeLister) MatchingIDs(ctx context.Context, scope metafilter.Scope, filters []*metafilter.Filter) (map[uuid.UUID]bool, error) {
+	return nil, errors.New("not used by listings")
+}
+
+func (l *fakeLister) List(ctx context.Context, listing *metafilter.Listing) ([]metafilter.Item, bool, error) {
+	l.listing = listing
+	return l.items, true, nil
+}
+
+func (l *fakeLister) Count(ctx context.Context, listing *metafilter.Listing) (int, error) {
+	l.listing = listing
+	return len(l.items), nil
+}
+
+func TestListContentLister(t *testing.T) {
+	ownerID := uuid.New()
+	content := &simplecontent.Content{ID: uuid.New(), OwnerID: ownerID, Name: "big.bin", Status: "uploaded", CreatedAt: time.Now()}
+	lister := &fakeLister{items: []metafilter.Item{{Content: content, Size: 4096}}}
+
+	config := DefaultConfig(createTestService(t))
+	config.MetadataQuerier = lister
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	// The database sorts, filters and pages; the result is passed through
+	args := map[string]interface{}{
+		"owner_id":         ownerID.String(),
+		"status":           "uploaded",
+		"min_size":         "1KB",
+		"metadata_filters": []interface{}{map[string]interface{}{"path": "$.project", "op": "eq", "value": "apollo"}},
+		"sort_by":          "size",
+		"order":            "asc",
+		"limit":            1,
+	}
+	data := callTool(t, server.handleListContent, args)
+	items := data["items"].([]interface{})
+	if len(items) != 1 || items[0].(map[string]interface{})["id"] != content.ID.String() {
+		t.Fatalf("Expected the listed content, got %v", items)
+	}
+	if _, ok := data["total"]; ok {
+		t.Error("Expected no total for a listing paged in the database")
+	}
+
+	listing := lister.listing
+	if listing.OwnerID != ownerID || listing.Status != "uploaded" || listing.MinSize == nil || *listing.MinSize != 1024 ||
+		len(listing.Filters) != 1 || listing.Order.By != "size" || !listing.Order.Ascending || listing.Limit != 1 ||
+		!slices.Contains(listing.ExcludeDocumentTypes, collectionDocumentType) {
+		t.Errorf("Unexpected listing: %+v", listing)
+	}
+
+	// The next page starts after the last content, at its size
+	args["cursor"] = data["next_cursor"]
+	callTool(t, server.handleListContent, args)
+	after := lister.listing.After
+	if after == nil || after.ID != content.ID || after.Size == nil || *after.Size != 4096 {
+		t.Errorf("Expected the cursor at the last content, got %+v", after)
+	}
+
+	// list_by_status filters by status and size, counts and pages in the database too
+	data = callTool(t, server.handleListByStatus, map[string]interface{}{
+		"owner_id": ownerID.String(),
+		"status":   "uploaded",
+		"min_size": 1024,
+		"limit":    1,
+	})
+	if items := data["items"].([]interface{}); len(items) != 1 || data["next_cursor"] == nil {
+		t.Errorf("Expected the listed content and a cursor, got %v", data)
+	}
+	if listing := lister.listing; listing.Status != "uploaded" || listing.MinSize == nil || *listing.MinSize != 1024 || listing.Limit != 1 {
+		t.Errorf("Unexpected listing: %+v", listing)
+	}
+	data = callTool(t, server.handleListByStatus, map[string]interface{}{
+		"owner_id":   ownerID.String(),
+		"status":     "uploaded",
+		"min_size":   1024,
+		"count_only": true,
+	})
+	if data["total"] != float64(1) {
+		t.Errorf("Expected the count from the database, got %v", data["total"])
+	}
+
+	// Listings across owners need the admin service
+	config.RequireOwnerID = true
+	server, err = New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	argsJSON, _ := json.Marshal(map[string]interface{}{"limit": 5})
+	if _, err := server.handleListContent(context.Background(), &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: argsJSON}}); !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected a validation error without owner_id, got %v", err)
+	}
+}
+
 func TestListDerivedContentTool(t *testing.T) {
 	server := createTestServer(t)
 	ctx := context.Background()
@@ -506,7 +690,8 @@ func TestListByStatusTool(t *testing.T) {
 
 	// List by status
 	listArgs := map[string]interface{}{
-		"status": "uploaded",
+		"status":   "uploaded",
+		"owner_id": ownerID.String(),
 	}
 
 	listArgsJSON, _ := json.Marshal(listArgs)
@@ -892,3 +1077,2859 @@ func TestBatchGetDetails(t *testing.T) {
 		}
 	}
 }
+
+func TestDeleteContentCascade(t *testing.T) {
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	parentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": uuid.New().String(),
+		"name":     "photo.jpg",
+	})
+	thumbID := uploadTestDerived(t, server, parentID, "thumbnail_256")
+	nestedID := uploadTestDerived(t, server, thumbID, "preview_64")
+
+	// Dry run lists the whole tree without deleting anything
+	preview := callTool(t, server.handleDeleteContent, map[string]interface{}{
+		"content_id": parentID.String(),
+		"cascade":    true,
+		"dry_run":    true,
+	})
+	if preview["would_delete"].(float64) != 3 {
+		t.Errorf("Expected 3 items in dry run, got %v", preview["would_delete"])
+	}
+	if _, err := server.service.GetContent(ctx, thumbID); err != nil {
+		t.Fatalf("Dry run should not delete derived content: %v", err)
+	}
+
+	result := callTool(t, server.handleDeleteContent, map[string]interface{}{
+		"content_id": parentID.String(),
+		"cascade":    true,
+	})
+	deleted := result["deleted_ids"].([]interface{})
+	if len(deleted) != 3 {
+		t.Fatalf("Expected 3 deleted IDs, got %d", len(deleted))
+	}
+	if deleted[0] != nestedID.String() || deleted[2] != parentID.String() {
+		t.Errorf("Expected children to be deleted before parents, got %v", deleted)
+	}
+
+	for _, id := range []uuid.UUID{parentID, thumbID, nestedID} {
+		if _, err := server.service.GetContent(ctx, id); err == nil {
+			t.Errorf("Content %s should be deleted", id)
+		}
+	}
+}
+
+func TestSweepOrphans(t *testing.T) {
+	repo := memoryrepo.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(repo),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	config := DefaultConfig(service)
+	config.AdminService = admin.New(repo)
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+
+	ownerID := uuid.New().String()
+	orphanParentID := uploadTestContent(t, server, map[string]interface{}{"owner_id": ownerID, "name": "gone.jpg"})
+	orphanID := uploadTestDerived(t, server, orphanParentID, "thumbnail_256")
+	keptParentID := uploadTestContent(t, server, map[string]interface{}{"owner_id": ownerID, "name": "kept.jpg"})
+	keptID := uploadTestDerived(t, server, keptParentID, "thumbnail_256")
+
+	// Delete only the parent, leaving its thumbnail orphaned
+	if err := service.DeleteContent(ctx, orphanParentID); err != nil {
+		t.Fatalf("Failed to delete parent: %v", err)
+	}
+
+	removed, err := server.SweepOrphans(ctx)
+	if err != nil {
+		t.Fatalf("SweepOrphans failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 orphan removed, got %d", removed)
+	}
+	if _, err := service.GetContent(ctx, orphanID); err == nil {
+		t.Error("Orphaned thumbnail should be deleted")
+	}
+	if _, err := service.GetContent(ctx, keptID); err != nil {
+		t.Errorf("Thumbnail with live parent should be kept: %v", err)
+	}
+
+	// A failing repository is reported rather than taken for no orphans
+	server.service = &failingRelationshipService{Service: service}
+	if _, err := server.SweepOrphans(ctx); err == nil || !strings.Contains(err.Error(), "1 derived content") {
+		t.Errorf("Expected the failed lookup to be reported, got %v", err)
+	}
+	if _, err := service.GetContent(ctx, keptID); err != nil {
+		t.Errorf("Thumbnail whose lookup failed should be kept: %v", err)
+	}
+}
+
+// failingRelationshipService fails every derived relationship lookup
+type failingRelationshipService struct {
+	simplecontent.Service
+}
+
+func (s *failingRelationshipService) GetDerivedRelationship(ctx context.Context, contentID uuid.UUID) (*simplecontent.DerivedContent, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestCopyContent(t *testing.T) {
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	sourceOwner := uuid.New()
+	parentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": sourceOwner.String(),
+		"name":     "photo.jpg",
+		"tags":     []string{"vacation"},
+	})
+	uploadTestDerived(t, server, parentID, "thumbnail_256")
+
+	targetOwner := uuid.New()
+	result := callTool(t, server.handleCopyContent, map[string]interface{}{
+		"content_id":      parentID.String(),
+		"target_owner_id": targetOwner.String(),
+		"include_derived": true,
+	})
+
+	copyID, err := uuid.Parse(result["id"].(string))
+	if err != nil {
+		t.Fatalf("Invalid copy ID: %v", err)
+	}
+	if copyID == parentID {
+		t.Fatal("Copy should have a new ID")
+	}
+	if result["owner_id"] != targetOwner.String() {
+		t.Errorf("Expected owner %s, got %v", targetOwner, result["owner_id"])
+	}
+	if derived := result["derived"].([]interface{}); len(derived) != 1 {
+		t.Fatalf("Expected 1 derived copy, got %d", len(derived))
+	}
+
+	data, err := server.readContent(ctx, copyID)
+	if err != nil {
+		t.Fatalf("Failed to read copy: %v", err)
+	}
+	if string(data) != "test data" {
+		t.Errorf("Expected copied data, got %q", data)
+	}
+
+	metadata, err := server.service.GetContentMetadata(ctx, copyID)
+	if err != nil {
+		t.Fatalf("Failed to get copy metadata: %v", err)
+	}
+	if len(metadata.Tags) != 1 || metadata.Tags[0] != "vacation" {
+		t.Errorf("Expected tags to be copied, got %v", metadata.Tags)
+	}
+
+	// The source is left untouched
+	source, err := server.service.GetContent(ctx, parentID)
+	if err != nil {
+		t.Fatalf("Source should still exist: %v", err)
+	}
+	if source.OwnerID != sourceOwner {
+		t.Errorf("Source owner changed to %s", source.OwnerID)
+	}
+}
+
+func TestCopyContentBackend(t *testing.T) {
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(memoryrepo.New()),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+		simplecontent.WithBlobStore("archive", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	server, err := New(DefaultConfig(service))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+
+	parentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":        uuid.New().String(),
+		"name":            "scan.pdf",
+		"storage_backend": "archive",
+	})
+	thumbID := uploadTestDerived(t, server, parentID, "thumbnail_256")
+	callTool(t, server.handleUpdateContent, map[string]interface{}{
+		"content_id": thumbID.String(),
+		"metadata":   map[string]interface{}{"renderer": "v2"},
+	})
+	thumbBackend, err := server.contentBackend(ctx, thumbID)
+	if err != nil {
+		t.Fatalf("Failed to get thumbnail backend: %v", err)
+	}
+
+	result := callTool(t, server.handleCopyContent, map[string]interface{}{
+		"content_id":      parentID.String(),
+		"include_derived": true,
+	})
+
+	// Each copy stays on the backend of its source
+	copyID := uuid.MustParse(result["id"].(string))
+	if backend, err := server.contentBackend(ctx, copyID); err != nil || backend != "archive" {
+		t.Errorf("Expected the copy on the archive backend, got %q (%v)", backend, err)
+	}
+	derived := result["derived"].([]interface{})
+	if len(derived) != 1 {
+		t.Fatalf("Expected 1 derived copy, got %d", len(derived))
+	}
+	derivedID := uuid.MustParse(derived[0].(map[string]interface{})["id"].(string))
+	if backend, err := server.contentBackend(ctx, derivedID); err != nil || backend != thumbBackend {
+		t.Errorf("Expected the derived copy on the %s backend, got %q (%v)", thumbBackend, backend, err)
+	}
+
+	// Derived copies keep their custom metadata
+	metadata, err := server.loadContentMetadata(ctx, derivedID)
+	if err != nil {
+		t.Fatalf("Failed to get derived copy metadata: %v", err)
+	}
+	if metadata.Metadata["renderer"] != "v2" {
+		t.Errorf("Expected custom metadata to be copied, got %v", metadata.Metadata)
+	}
+}
+
+func TestTransferContent(t *testing.T) {
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	parentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": uuid.New().String(),
+		"name":     "photo.jpg",
+	})
+	thumbID := uploadTestDerived(t, server, parentID, "thumbnail_256")
+
+	targetOwner := uuid.New()
+	targetTenant := uuid.New()
+	result := callTool(t, server.handleTransferContent, map[string]interface{}{
+		"content_id":       parentID.String(),
+		"target_owner_id":  targetOwner.String(),
+		"target_tenant_id": targetTenant.String(),
+	})
+	if ids := result["transferred_ids"].([]interface{}); len(ids) != 2 {
+		t.Fatalf("Expected 2 transferred IDs, got %d", len(ids))
+	}
+
+	for _, id := range []uuid.UUID{parentID, thumbID} {
+		content, err := server.service.GetContent(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to get content: %v", err)
+		}
+		if content.OwnerID != targetOwner || content.TenantID != targetTenant {
+			t.Errorf("Content %s not transferred: owner %s tenant %s", id, content.OwnerID, content.TenantID)
+		}
+	}
+}
+
+// failingUpdateService fails UpdateContent for one content
+type failingUpdateService struct {
+	simplecontent.Service
+	failID uuid.UUID
+}
+
+func (s *failingUpdateService) UpdateContent(ctx context.Context, req simplecontent.UpdateContentRequest) error {
+	if req.Content.ID == s.failID {
+		return errors.New("update failed")
+	}
+	return s.Service.UpdateContent(ctx, req)
+}
+
+func TestTransferContentRollback(t *testing.T) {
+	service := &failingUpdateService{Service: createTestService(t)}
+	server, err := New(DefaultConfig(service))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+
+	ownerID := uuid.New()
+	parentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": ownerID.String(),
+		"name":     "photo.jpg",
+	})
+	thumbID := uploadTestDerived(t, server, parentID, "thumbnail_256")
+	service.failID = thumbID
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"content_id":      parentID.String(),
+		"target_owner_id": uuid.New().String(),
+	})
+	if _, err := server.handleTransferContent(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: args}}); err == nil {
+		t.Fatal("Expected the transfer to fail")
+	}
+
+	// The parent moved before the failure and must be moved back
+	for _, id := range []uuid.UUID{parentID, thumbID} {
+		content, err := server.service.GetContent(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to get content: %v", err)
+		}
+		if content.OwnerID != ownerID {
+			t.Errorf("Content %s left with owner %s after a failed transfer", id, content.OwnerID)
+		}
+	}
+}
+
+func TestTransferContentForbidden(t *testing.T) {
+	service := createTestService(t)
+	config := DefaultConfig(service)
+	config.AuthEnabled = true
+	config.Authenticator = auth.NewAPIKeyAuthenticator()
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	ownerID := uuid.New()
+	contentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": ownerID.String(),
+		"name":     "private.txt",
+	})
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"content_id":      contentID.String(),
+		"target_owner_id": uuid.New().String(),
+	})
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: args}}
+
+	// A key for another owner cannot take the content
+	ctx := auth.WithKeyInfo(context.Background(), &auth.KeyInfo{OwnerID: uuid.New()})
+	if _, err := server.handleTransferContent(ctx, req); err == nil {
+		t.Error("Expected transfer by another owner to be rejected")
+	}
+
+	// The owner cannot hand content to an owner they don't control
+	ctx = auth.WithKeyInfo(context.Background(), &auth.KeyInfo{OwnerID: ownerID})
+	if _, err := server.handleTransferContent(ctx, req); err == nil {
+		t.Error("Expected transfer to a foreign owner to be rejected")
+	}
+
+	// Admin keys may move content between owners
+	ctx = auth.WithKeyInfo(context.Background(), &auth.KeyInfo{OwnerID: ownerID, Scopes: []string{auth.ScopeAdmin}})
+	if _, err := server.handleTransferContent(ctx, req); err != nil {
+		t.Errorf("Expected admin transfer to succeed: %v", err)
+	}
+}
+
+func TestMigrateStorage(t *testing.T) {
+	defaultStore := memorystorage.New()
+	archiveStore := memorystorage.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(memoryrepo.New()),
+		simplecontent.WithBlobStore("default", defaultStore),
+		simplecontent.WithBlobStore("archive", archiveStore),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	server, err := New(DefaultConfig(service))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+
+	contentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": uuid.New().String(),
+		"name":     "report.pdf",
+	})
+	objects, err := service.GetObjectsByContentID(ctx, contentID)
+	if err != nil || len(objects) != 1 {
+		t.Fatalf("Expected 1 object, got %d (%v)", len(objects), err)
+	}
+	objectKey := objects[0].ObjectKey
+
+	// Dry run leaves the blob where it is
+	preview := callTool(t, server.handleMigrateStorage, map[string]interface{}{
+		"content_id":     contentID.String(),
+		"target_backend": "archive",
+		"dry_run":        true,
+	})
+	if preview["migrated"].(float64) != 0 {
+		t.Errorf("Dry run should not migrate, got %v", preview["migrated"])
+	}
+	if _, err := archiveStore.Download(ctx, objectKey); err == nil {
+		t.Error("Dry run should not write to the target backend")
+	}
+
+	result := callTool(t, server.handleMigrateStorage, map[string]interface{}{
+		"content_id":     contentID.String(),
+		"target_backend": "archive",
+	})
+	if result["migrated"].(float64) != 1 || result["failed"].(float64) != 0 {
+		t.Fatalf("Expected 1 migrated object, got %v", result)
+	}
+
+	objects, err = service.GetObjectsByContentID(ctx, contentID)
+	if err != nil {
+		t.Fatalf("Failed to get objects: %v", err)
+	}
+	if objects[0].StorageBackendName != "archive" {
+		t.Errorf("Expected object in archive backend, got %s", objects[0].StorageBackendName)
+	}
+	if _, err := defaultStore.Download(ctx, objectKey); err == nil {
+		t.Error("Source blob should be removed after migration")
+	}
+
+	data, err := server.readContent(ctx, contentID)
+	if err != nil {
+		t.Fatalf("Failed to download migrated content: %v", err)
+	}
+	if string(data) != "test data" {
+		t.Errorf("Expected migrated data, got %q", data)
+	}
+
+	// Running again is a no-op
+	again := callTool(t, server.handleMigrateStorage, map[string]interface{}{
+		"content_id":     contentID.String(),
+		"target_backend": "archive",
+	})
+	if again["migrated"].(float64) != 0 {
+		t.Errorf("Expected nothing left to migrate, got %v", again["migrated"])
+	}
+}
+
+func TestMigrateStoragePaging(t *testing.T) {
+	repo := memoryrepo.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(repo),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+		simplecontent.WithBlobStore("archive", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	server, err := New(DefaultConfig(service))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+
+	ownerID := uuid.New()
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		ids = append(ids, uploadTestContent(t, server, map[string]interface{}{
+			"owner_id": ownerID.String(),
+			"name":     fmt.Sprintf("file-%d.txt", i),
+		}))
+		time.Sleep(2 * time.Millisecond)
+	}
+	callTool(t, server.handleMigrateStorage, map[string]interface{}{
+		"content_id":     ids[0].String(),
+		"target_backend": "archive",
+	})
+
+	// The migrated content is skipped without filling the page
+	args := map[string]interface{}{
+		"owner_id":       ownerID.String(),
+		"source_backend": "default",
+		"target_backend": "archive",
+		"limit":          1,
+	}
+	first := callTool(t, server.handleMigrateStorage, args)
+	objects := first["objects"].([]interface{})
+	if first["migrated"].(float64) != 1 || objects[0].(map[string]interface{})["content_id"] != ids[1].String() {
+		t.Fatalf("Expected the second content to be migrated, got %v", first)
+	}
+	next, ok := first["next_cursor"].(string)
+	if !ok {
+		t.Fatal("Expected a next_cursor")
+	}
+
+	args["cursor"] = next
+	second := callTool(t, server.handleMigrateStorage, args)
+	objects = second["objects"].([]interface{})
+	if second["migrated"].(float64) != 1 || objects[0].(map[string]interface{})["content_id"] != ids[2].String() {
+		t.Fatalf("Expected the third content to be migrated, got %v", second)
+	}
+	if _, ok := second["next_cursor"]; ok {
+		t.Error("Expected no next_cursor on the last page")
+	}
+
+	// A source blob that no longer matches its stored checksum is not copied
+	corruptID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": ownerID.String(),
+		"name":     "corrupt.txt",
+	})
+	metadata, err := repo.GetContentMetadata(ctx, corruptID)
+	if err != nil {
+		t.Fatalf("Failed to get metadata: %v", err)
+	}
+	metadata.Checksum = strings.Repeat("0", 64)
+	metadata.ChecksumAlgorithm = "sha256"
+	if err := repo.SetContentMetadata(ctx, metadata); err != nil {
+		t.Fatalf("Failed to set metadata: %v", err)
+	}
+	result := callTool(t, server.handleMigrateStorage, map[string]interface{}{
+		"content_id":     corruptID.String(),
+		"target_backend": "archive",
+	})
+	if result["failed"].(float64) != 1 {
+		t.Errorf("Expected a checksum mismatch, got %v", result)
+	}
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	contentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": uuid.New().String(),
+		"name":     "notes.txt",
+	})
+
+	content := callTool(t, server.handleGetContent, map[string]interface{}{"content_id": contentID.String()})
+	staleETag, ok := content["etag"].(string)
+	if !ok || staleETag == "" {
+		t.Fatalf("Expected get_content to return an etag, got %v", content["etag"])
+	}
+
+	// First writer wins and receives the new version
+	updated := callTool(t, server.handleUpdateContent, map[string]interface{}{
+		"content_id": contentID.String(),
+		"tags":       []string{"first"},
+		"if_match":   staleETag,
+	})
+	newETag := updated["etag"].(string)
+	if newETag == staleETag {
+		t.Fatal("Expected the etag to change after an update")
+	}
+
+	current := callTool(t, server.handleGetContent, map[string]interface{}{"content_id": contentID.String()})
+	if current["etag"] != newETag {
+		t.Errorf("Expected get_content etag %s, got %v", newETag, current["etag"])
+	}
+
+	// A second writer holding the old version is rejected
+	args, _ := json.Marshal(map[string]interface{}{
+		"content_id": contentID.String(),
+		"tags":       []string{"second"},
+		"if_match":   staleETag,
+	})
+	_, err := server.handleUpdateContent(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: args}})
+	if !errors.Is(err, mcperrors.ErrConflict) {
+		t.Fatalf("Expected conflict error on stale update, got %v", err)
+	}
+
+	metadata, err := server.service.GetContentMetadata(ctx, contentID)
+	if err != nil {
+		t.Fatalf("Failed to get metadata: %v", err)
+	}
+	if len(metadata.Tags) != 1 || metadata.Tags[0] != "first" {
+		t.Errorf("Stale update should not change tags, got %v", metadata.Tags)
+	}
+
+	// Deletes are guarded the same way
+	args, _ = json.Marshal(map[string]interface{}{
+		"content_id": contentID.String(),
+		"if_match":   staleETag,
+	})
+	_, err = server.handleDeleteContent(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: args}})
+	if !errors.Is(err, mcperrors.ErrConflict) {
+		t.Fatalf("Expected conflict error on stale delete, got %v", err)
+	}
+
+	callTool(t, server.handleDeleteContent, map[string]interface{}{
+		"content_id": contentID.String(),
+		"if_match":   newETag,
+	})
+	if _, err := server.service.GetContent(ctx, contentID); err == nil {
+		t.Error("Content should be deleted with a matching etag")
+	}
+}
+
+func TestUpdateContentPatchModes(t *testing.T) {
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	contentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":  uuid.New().String(),
+		"name":      "photo.jpg",
+		"file_name": "photo.jpg",
+		"tags":      []string{"vacation", "beach"},
+		"metadata":  map[string]interface{}{"camera": "x100", "location": map[string]interface{}{"city": "Lisbon", "country": "PT"}},
+	})
+
+	// Merge is the default: only the named keys change
+	result := callTool(t, server.handleUpdateContent, map[string]interface{}{
+		"content_id": contentID.String(),
+		"metadata":   map[string]interface{}{"rating": 5, "camera": nil, "location": map[string]interface{}{"city": "Porto"}},
+		"add_tags":   []string{"2024"},
+	})
+	metadata, err := server.service.GetContentMetadata(ctx, contentID)
+	if err != nil {
+		t.Fatalf("Failed to get metadata: %v", err)
+	}
+	if _, ok := metadata.Metadata["camera"]; ok {
+		t.Error("Expected camera to be removed by a null merge value")
+	}
+	location := metadata.Metadata["location"].(map[string]interface{})
+	if location["city"] != "Porto" || location["country"] != "PT" {
+		t.Errorf("Expected nested merge, got %v", location)
+	}
+	if metadata.Metadata["rating"] == nil {
+		t.Error("Expected rating to be added")
+	}
+	if strings.Join(metadata.Tags, ",") != "vacation,beach,2024" {
+		t.Errorf("Expected tags to be appended, got %v", metadata.Tags)
+	}
+	if metadata.FileName != "photo.jpg" {
+		t.Errorf("Expected file name to be preserved, got %q", metadata.FileName)
+	}
+
+	changes := map[string]string{}
+	for _, c := range result["changes"].([]interface{}) {
+		change := c.(map[string]interface{})
+		changes[change["path"].(string)] = change["op"].(string)
+	}
+	for path, op := range map[string]string{"/metadata/camera": "remove", "/metadata/location/city": "replace", "/metadata/rating": "add", "/tags": "replace"} {
+		if changes[path] != op {
+			t.Errorf("Expected %s change at %s, got %v", op, path, changes)
+		}
+	}
+	if _, ok := changes["/metadata/location/country"]; ok {
+		t.Error("Unchanged keys should not appear in the diff")
+	}
+
+	// JSON Patch
+	callTool(t, server.handleUpdateContent, map[string]interface{}{
+		"content_id": contentID.String(),
+		"patch_mode": "json_patch",
+		"metadata": []interface{}{
+			map[string]interface{}{"op": "test", "path": "/location/city", "value": "Porto"},
+			map[string]interface{}{"op": "move", "from": "/rating", "path": "/stars"},
+		},
+		"remove_tags": []string{"beach"},
+	})
+	metadata, _ = server.service.GetContentMetadata(ctx, contentID)
+	if metadata.Metadata["stars"] == nil || metadata.Metadata["rating"] != nil {
+		t.Errorf("Expected rating moved to stars, got %v", metadata.Metadata)
+	}
+	if strings.Join(metadata.Tags, ",") != "vacation,2024" {
+		t.Errorf("Expected beach to be removed, got %v", metadata.Tags)
+	}
+
+	// A failing JSON Patch changes nothing
+	args, _ := json.Marshal(map[string]interface{}{
+		"content_id": contentID.String(),
+		"patch_mode": "json_patch",
+		"metadata":   []interface{}{map[string]interface{}{"op": "remove", "path": "/missing"}},
+		"name":       "renamed.jpg",
+	})
+	if _, err := server.handleUpdateContent(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: args}}); !errors.Is(err, mcperrors.ErrValidation) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	if content, _ := server.service.GetContent(ctx, contentID); content.Name != "photo.jpg" {
+		t.Errorf("Failed patch should not rename content, got %q", content.Name)
+	}
+
+	// Replace overwrites all custom metadata
+	callTool(t, server.handleUpdateContent, map[string]interface{}{
+		"content_id": contentID.String(),
+		"patch_mode": "replace",
+		"metadata":   map[string]interface{}{"only": "this"},
+	})
+	metadata, _ = server.service.GetContentMetadata(ctx, contentID)
+	if metadata.Metadata["only"] != "this" || metadata.Metadata["stars"] != nil || metadata.Metadata["location"] != nil {
+		t.Errorf("Expected metadata to be replaced, got %v", metadata.Metadata)
+	}
+	if len(metadata.Tags) != 2 {
+		t.Errorf("Tags should be untouched by a metadata replace, got %v", metadata.Tags)
+	}
+}
+
+func TestTransitionContentStatus(t *testing.T) {
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	contentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": uuid.New().String(),
+		"name":     "report.pdf",
+	})
+
+	call := func(args map[string]interface{}) error {
+		data, _ := json.Marshal(args)
+		_, err := server.handleTransitionContentStatus(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: data}})
+		return err
+	}
+
+	result := callTool(t, server.handleTransitionContentStatus, map[string]interface{}{
+		"content_id": contentID.String(),
+		"action":     "archive",
+		"actor":      "cleanup-agent",
+		"reason":     "quarterly retention",
+	})
+	if result["from"] != "uploaded" || result["to"] != "archived" {
+		t.Errorf("Expected uploaded -> archived, got %v -> %v", result["from"], result["to"])
+	}
+
+	// Archived content cannot be reprocessed directly
+	err := call(map[string]interface{}{"content_id": contentID.String(), "action": "reprocess"})
+	if !errors.Is(err, mcperrors.ErrInvalidTransition) {
+		t.Fatalf("Expected invalid transition error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "uploaded") {
+		t.Errorf("Expected the error to list the allowed statuses, got %v", err)
+	}
+
+	// Exactly one of action and to_status
+	err = call(map[string]interface{}{"content_id": contentID.String(), "action": "archive", "to_status": "archived"})
+	if !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected validation error for action and to_status, got %v", err)
+	}
+
+	// Unarchive restores the status held before archiving
+	result = callTool(t, server.handleTransitionContentStatus, map[string]interface{}{
+		"content_id": contentID.String(),
+		"action":     "unarchive",
+		"actor":      "cleanup-agent",
+	})
+	if result["to"] != "uploaded" {
+		t.Errorf("Expected unarchive to restore uploaded, got %v", result["to"])
+	}
+
+	content, err := server.service.GetContent(ctx, contentID)
+	if err != nil {
+		t.Fatalf("Failed to get content: %v", err)
+	}
+	if content.Status != "uploaded" {
+		t.Errorf("Expected status uploaded, got %s", content.Status)
+	}
+
+	history := result["history"].([]interface{})
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(history))
+	}
+	first := history[0].(map[string]interface{})
+	if first["actor"] != "cleanup-agent" || first["reason"] != "quarterly retention" || first["action"] != "archive" {
+		t.Errorf("Unexpected history entry: %v", first)
+	}
+
+	// The history is server-maintained and cannot be edited through update_content
+	args, _ := json.Marshal(map[string]interface{}{
+		"content_id": contentID.String(),
+		"metadata":   map[string]interface{}{statusHistoryKey: nil},
+	})
+	_, err = server.handleUpdateContent(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: args}})
+	if !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected validation error when editing the status history, got %v", err)
+	}
+
+	// Replacing metadata keeps the history
+	callTool(t, server.handleUpdateContent, map[string]interface{}{
+		"content_id": contentID.String(),
+		"patch_mode": "replace",
+		"metadata":   map[string]interface{}{"owner": "finance"},
+	})
+	metadata, err := server.service.GetContentMetadata(ctx, contentID)
+	if err != nil {
+		t.Fatalf("Failed to get metadata: %v", err)
+	}
+	if len(loadStatusHistory(metadata.Metadata)) != 2 {
+		t.Errorf("Expected the status history to survive a metadata replace, got %v", metadata.Metadata[statusHistoryKey])
+	}
+}
+
+func TestContentExpiry(t *testing.T) {
+	repo := memoryrepo.New()
+	blobStore := memorystorage.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(repo),
+		simplecontent.WithBlobStore("default", blobStore),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	config := DefaultConfig(service)
+	config.AdminService = admin.New(repo)
+	config.ExpiryAction = ExpiryPurge
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+	ownerID := uuid.New().String()
+
+	scratchID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":    ownerID,
+		"name":        "scratch.tmp",
+		"ttl_seconds": 60,
+	})
+	thumbID := uploadTestDerived(t, server, scratchID, "thumbnail_256")
+	keptID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":   ownerID,
+		"name":       "render.png",
+		"expires_at": time.Now().Add(time.Hour).Format(time.RFC3339),
+	})
+
+	content := callTool(t, server.handleGetContent, map[string]interface{}{"content_id": scratchID.String()})
+	if content["expires_at"] == nil {
+		t.Fatal("Expected get_content to report expires_at")
+	}
+
+	// Expiry is reserved metadata and can only be set through expires_at / ttl_seconds
+	args, _ := json.Marshal(map[string]interface{}{
+		"content_id": keptID.String(),
+		"metadata":   map[string]interface{}{expiresAtKey: nil},
+	})
+	_, err = server.handleUpdateContent(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: args}})
+	if !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected validation error when editing expiry metadata, got %v", err)
+	}
+
+	// Clearing the expiry keeps the content around
+	callTool(t, server.handleUpdateContent, map[string]interface{}{
+		"content_id": keptID.String(),
+		"expires_at": nil,
+	})
+	content = callTool(t, server.handleGetContent, map[string]interface{}{"content_id": keptID.String()})
+	if content["expires_at"] != nil {
+		t.Errorf("Expected expiry to be cleared, got %v", content["expires_at"])
+	}
+
+	// Move the scratch file's expiry into the past
+	metadata, err := server.loadContentMetadata(ctx, scratchID)
+	if err != nil {
+		t.Fatalf("Failed to load metadata: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	if err := server.saveContentMetadata(ctx, metadata, metadata.Tags, withExpiry(metadata.Metadata, &past)); err != nil {
+		t.Fatalf("Failed to save metadata: %v", err)
+	}
+	objects, err := service.GetObjectsByContentID(ctx, scratchID)
+	if err != nil || len(objects) == 0 {
+		t.Fatalf("Expected scratch content to have objects: %v", err)
+	}
+
+	// Expired content is unreadable before the sweeper gets to it
+	args, _ = json.Marshal(map[string]interface{}{"content_id": scratchID.String(), "format": "base64"})
+	readReq := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: args}}
+	if _, err := server.handleGetContent(ctx, readReq); !errors.Is(err, mcperrors.ErrNotFound) {
+		t.Errorf("Expected get_content of expired content to be not found, got %v", err)
+	}
+	if _, err := server.handleDownloadContent(ctx, readReq); !errors.Is(err, mcperrors.ErrNotFound) {
+		t.Errorf("Expected download_content of expired content to be not found, got %v", err)
+	}
+
+	// The sweeper runs in the background and stops when its context ends
+	sweepCtx, cancel := context.WithCancel(ctx)
+	server.goBackground(func() { server.runExpirySweeper(sweepCtx, 10*time.Millisecond) })
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := service.GetContent(ctx, scratchID); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expired content was not removed by the sweeper")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		server.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expiry sweeper did not stop after cancellation")
+	}
+
+	if _, err := service.GetContent(ctx, thumbID); err == nil {
+		t.Error("Derived content of expired content should be removed")
+	}
+	if _, err := service.GetContent(ctx, keptID); err != nil {
+		t.Errorf("Content without expiry should be kept: %v", err)
+	}
+	if _, err := blobStore.Download(ctx, objects[0].ObjectKey); err == nil {
+		t.Error("Expected purge to delete the stored blob")
+	}
+}
+
+func TestCollections(t *testing.T) {
+	server := createTestServer(t)
+	ctx := context.Background()
+	ownerID := uuid.New().String()
+
+	call := func(handler mcp.ToolHandler, args map[string]interface{}) error {
+		data, _ := json.Marshal(args)
+		_, err := handler(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: data}})
+		return err
+	}
+
+	project := callTool(t, server.handleCreateCollection, map[string]interface{}{"owner_id": ownerID, "name": "Project"})
+	projectID := project["id"].(string)
+	drafts := callTool(t, server.handleCreateCollection, map[string]interface{}{"owner_id": ownerID, "name": "Drafts", "parent_id": projectID})
+	draftsID := drafts["id"].(string)
+	if drafts["parent_id"] != projectID {
+		t.Errorf("Expected nested collection parent %s, got %v", projectID, drafts["parent_id"])
+	}
+
+	specID := uploadTestContent(t, server, map[string]interface{}{"owner_id": ownerID, "name": "spec.md"})
+	draftID := uploadTestContent(t, server, map[string]interface{}{"owner_id": ownerID, "name": "draft.md"})
+	uploadTestContent(t, server, map[string]interface{}{"owner_id": ownerID, "name": "loose.md"})
+
+	result := callTool(t, server.handleAddToCollection, map[string]interface{}{
+		"collection_id": projectID,
+		"content_ids":   []string{specID.String(), specID.String()},
+	})
+	if result["added"] != float64(1) {
+		t.Errorf("Expected 1 item added, got %v", result["added"])
+	}
+	callTool(t, server.handleAddToCollection, map[string]interface{}{"collection_id": draftsID, "content_ids": []string{draftID.String()}})
+
+	// A collection cannot be nested inside its own descendant
+	err := call(server.handleAddToCollection, map[string]interface{}{"collection_id": draftsID, "content_ids": []string{projectID}})
+	if !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected validation error for a nesting cycle, got %v", err)
+	}
+
+	listing := callTool(t, server.handleListCollection, map[string]interface{}{"collection_id": projectID})
+	if len(listing["items"].([]interface{})) != 1 || len(listing["collections"].([]interface{})) != 1 {
+		t.Errorf("Expected 1 item and 1 nested collection, got %v", listing)
+	}
+	listing = callTool(t, server.handleListCollection, map[string]interface{}{"collection_id": projectID, "recursive": true})
+	if listing["total"] != float64(2) {
+		t.Errorf("Expected 2 items with recursive listing, got %v", listing["total"])
+	}
+
+	roots := callTool(t, server.handleListCollection, map[string]interface{}{"owner_id": ownerID})
+	if roots["total"] != float64(1) {
+		t.Errorf("Expected only the top-level collection, got %v", roots["collections"])
+	}
+
+	// list_content and search_content filter by collection and hide collection records
+	listed := callTool(t, server.handleListContent, map[string]interface{}{"owner_id": ownerID, "collection_id": projectID})
+	items := listed["items"].([]interface{})
+	if len(items) != 1 || items[0].(map[string]interface{})["id"] != specID.String() {
+		t.Errorf("Expected only spec.md in the collection, got %v", items)
+	}
+	listed = callTool(t, server.handleListContent, map[string]interface{}{"owner_id": ownerID})
+	if listed["total"] != float64(3) {
+		t.Errorf("Expected collections to be excluded from list_content, got %v", listed["total"])
+	}
+	found := callTool(t, server.handleSearchContent, map[string]interface{}{"owner_id": ownerID, "query": "draft", "collection_id": draftsID})
+	if found["total"] != float64(1) {
+		t.Errorf("Expected search within collection to find draft.md, got %v", found["total"])
+	}
+
+	resource, err := server.handleCollectionResource(ctx, &mcp.ReadResourceRequest{Params: &mcp.ReadResourceParams{URI: "collection://" + draftsID}})
+	if err != nil {
+		t.Fatalf("Read collection resource failed: %v", err)
+	}
+	if !strings.Contains(resource.Contents[0].Text, draftID.String()) {
+		t.Errorf("Expected collection resource to list draft.md, got %s", resource.Contents[0].Text)
+	}
+
+	callTool(t, server.handleRemoveFromCollection, map[string]interface{}{"collection_id": projectID, "content_ids": []string{specID.String()}})
+	listing = callTool(t, server.handleListCollection, map[string]interface{}{"collection_id": projectID})
+	if listing["total"] != float64(0) {
+		t.Errorf("Expected spec.md to be removed, got %v", listing["items"])
+	}
+
+	// Deleting a collection with nested collections needs recursive
+	err = call(server.handleDeleteCollection, map[string]interface{}{"collection_id": projectID})
+	if !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected validation error deleting a non-empty collection, got %v", err)
+	}
+	deleted := callTool(t, server.handleDeleteCollection, map[string]interface{}{"collection_id": projectID, "recursive": true})
+	if len(deleted["deleted"].([]interface{})) != 2 {
+		t.Errorf("Expected both collections deleted, got %v", deleted["deleted"])
+	}
+	if _, err := server.service.GetContent(ctx, draftID); err != nil {
+		t.Errorf("Member content should survive collection deletion: %v", err)
+	}
+}
+
+func TestCollectionAccess(t *testing.T) {
+	config := DefaultConfig(createTestService(t))
+	config.AuthEnabled = true
+	config.Authenticator = auth.NewAPIKeyAuthenticator()
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	ownerID, otherID := uuid.New(), uuid.New()
+	ownerCtx := auth.WithKeyInfo(context.Background(), &auth.KeyInfo{OwnerID: ownerID})
+	otherCtx := auth.WithKeyInfo(context.Background(), &auth.KeyInfo{OwnerID: otherID})
+	adminCtx := auth.WithKeyInfo(context.Background(), &auth.KeyInfo{OwnerID: ownerID, Scopes: []string{auth.ScopeAdmin}})
+	call := func(ctx context.Context, handler mcp.ToolHandler, args map[string]interface{}) (map[string]interface{}, error) {
+		data, _ := json.Marshal(args)
+		result, err := handler(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: data}})
+		if err != nil {
+			return nil, err
+		}
+		var decoded map[string]interface{}
+		json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &decoded)
+		return decoded, nil
+	}
+
+	collection, err := call(ownerCtx, server.handleCreateCollection, map[string]interface{}{"owner_id": ownerID.String(), "name": "Shared"})
+	if err != nil {
+		t.Fatalf("Failed to create collection: %v", err)
+	}
+	collectionID := collection["id"].(string)
+	mineID := uploadTestContent(t, server, map[string]interface{}{"owner_id": ownerID.String(), "name": "mine.txt"})
+	theirsID := uploadTestContent(t, server, map[string]interface{}{"owner_id": ownerID.String(), "name": "theirs.txt"})
+	if _, err := call(ownerCtx, server.handleAddToCollection, map[string]interface{}{
+		"collection_id": collectionID,
+		"content_ids":   []string{mineID.String(), theirsID.String()},
+	}); err != nil {
+		t.Fatalf("Failed to add to collection: %v", err)
+	}
+	// A member transferred away stays in the collection
+	if _, err := call(adminCtx, server.handleTransferContent, map[string]interface{}{
+		"content_id":      theirsID.String(),
+		"target_owner_id": otherID.String(),
+	}); err != nil {
+		t.Fatalf("Failed to transfer content: %v", err)
+	}
+
+	// Knowing the collection ID is not enough to list it
+	if _, err := call(otherCtx, server.handleListContent, map[string]interface{}{"collection_id": collectionID}); !errors.Is(err, mcperrors.ErrForbidden) {
+		t.Errorf("Expected listing another owner's collection to be forbidden, got %v", err)
+	}
+
+	// Members the caller cannot access are hidden, and owner_id still filters
+	listed, err := call(ownerCtx, server.handleListContent, map[string]interface{}{"collection_id": collectionID})
+	if err != nil {
+		t.Fatalf("Failed to list collection: %v", err)
+	}
+	if items := listed["items"].([]interface{}); len(items) != 1 || items[0].(map[string]interface{})["id"] != mineID.String() {
+		t.Errorf("Expected only the owner's member, got %v", items)
+	}
+	listed, err = call(adminCtx, server.handleListContent, map[string]interface{}{"collection_id": collectionID, "owner_id": otherID.String()})
+	if err != nil {
+		t.Fatalf("Failed to list collection: %v", err)
+	}
+	if items := listed["items"].([]interface{}); len(items) != 1 || items[0].(map[string]interface{})["id"] != theirsID.String() {
+		t.Errorf("Expected owner_id to filter members, got %v", items)
+	}
+}
+
+func TestCreateDerivedContent(t *testing.T) {
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	parentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": uuid.New().String(),
+		"name":     "report.pdf",
+	})
+
+	result := callTool(t, server.handleCreateDerivedContent, map[string]interface{}{
+		"parent_id":     parentID.String(),
+		"variant":       "summary_en",
+		"data":          base64.StdEncoding.EncodeToString([]byte("A short summary.")),
+		"document_type": "text/plain",
+		"metadata":      map[string]interface{}{"model": "summarizer-v2"},
+	})
+	derivedID, err := uuid.Parse(result["id"].(string))
+	if err != nil {
+		t.Fatalf("Invalid derived ID: %v", err)
+	}
+	if result["derivation_type"] != "summary" {
+		t.Errorf("Expected derivation type inferred from variant, got %v", result["derivation_type"])
+	}
+
+	listed := callTool(t, server.handleListDerivedContent, map[string]interface{}{"parent_id": parentID.String()})
+	items := listed["items"].([]interface{})
+	if len(items) != 1 || items[0].(map[string]interface{})["content_id"] != derivedID.String() {
+		t.Fatalf("Expected derived content in listing, got %v", items)
+	}
+
+	status := callTool(t, server.handleGetContentStatus, map[string]interface{}{"content_id": parentID.String()})
+	if status["derived_count"] != float64(1) {
+		t.Errorf("Expected derived_count 1, got %v", status["derived_count"])
+	}
+
+	derived, err := server.service.GetContent(ctx, derivedID)
+	if err != nil {
+		t.Fatalf("Failed to get derived content: %v", err)
+	}
+	if derived.Name != "report.pdf (summary_en)" || derived.DocumentType != "text/plain" {
+		t.Errorf("Unexpected derived content: name %q, type %q", derived.Name, derived.DocumentType)
+	}
+
+	metadata, err := server.service.GetContentMetadata(ctx, derivedID)
+	if err != nil {
+		t.Fatalf("Failed to get metadata: %v", err)
+	}
+	if metadata.Metadata["model"] != "summarizer-v2" || metadata.FileSize != int64(len("A short summary.")) {
+		t.Errorf("Unexpected derived metadata: %+v", metadata)
+	}
+
+	data, err := server.readContent(ctx, derivedID)
+	if err != nil || string(data) != "A short summary." {
+		t.Errorf("Expected derived data to round-trip, got %q (%v)", data, err)
+	}
+
+	// Derived content needs a ready parent
+	pending, err := server.service.CreateContent(ctx, simplecontent.CreateContentRequest{OwnerID: uuid.New(), Name: "pending"})
+	if err != nil {
+		t.Fatalf("Failed to create content: %v", err)
+	}
+	args, _ := json.Marshal(map[string]interface{}{
+		"parent_id": pending.ID.String(),
+		"variant":   "summary_en",
+		"data":      base64.StdEncoding.EncodeToString([]byte("x")),
+	})
+	if _, err := server.handleCreateDerivedContent(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: args}}); err == nil {
+		t.Error("Expected an error for a parent that has not been uploaded")
+	}
+}
+
+func TestThumbnailGeneration(t *testing.T) {
+	service := createTestService(t)
+	config := DefaultConfig(service)
+	config.ThumbnailSizes = []int{256, 64}
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 400, 200))); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	parentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      uuid.New().String(),
+		"name":          "photo.png",
+		"document_type": "image/png",
+		"data":          base64.StdEncoding.EncodeToString(buf.Bytes()),
+	})
+	processJobs(t, server)
+
+	parent, err := server.service.GetContent(ctx, parentID)
+	if err != nil {
+		t.Fatalf("Failed to get parent: %v", err)
+	}
+	if parent.Status != string(simplecontent.ContentStatusProcessed) {
+		t.Errorf("Expected parent to be processed, got %s", parent.Status)
+	}
+
+	result := callTool(t, server.handleGetThumbnails, map[string]interface{}{
+		"parent_id": parentID.String(),
+		"sizes":     []string{"256", "64"},
+	})
+	thumbnails := result["thumbnails"].(map[string]interface{})
+	if len(thumbnails) != 2 {
+		t.Fatalf("Expected 2 thumbnails, got %v", thumbnails)
+	}
+
+	thumb := thumbnails["64"].(map[string]interface{})
+	if thumb["status"] != string(simplecontent.ContentStatusProcessed) || thumb["document_type"] != "image/png" {
+		t.Errorf("Unexpected thumbnail: %v", thumb)
+	}
+	thumbID, _ := uuid.Parse(thumb["content_id"].(string))
+	metadata, err := server.service.GetContentMetadata(ctx, thumbID)
+	if err != nil {
+		t.Fatalf("Failed to get thumbnail metadata: %v", err)
+	}
+	if fmt.Sprint(metadata.Metadata["width"], "x", metadata.Metadata["height"]) != "64x32" {
+		t.Errorf("Expected a 64x32 thumbnail, got %v", metadata.Metadata)
+	}
+
+	parentMetadata, err := server.service.GetContentMetadata(ctx, parentID)
+	if err != nil {
+		t.Fatalf("Failed to get parent metadata: %v", err)
+	}
+	history := loadStatusHistory(parentMetadata.Metadata)
+	if len(history) != 2 || history[0].To != "processing" || history[1].To != "processed" || history[1].Actor != "processor:thumbnail" {
+		t.Errorf("Unexpected status history: %+v", history)
+	}
+
+	// Non-image uploads are left alone
+	textID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      uuid.New().String(),
+		"name":          "notes.txt",
+		"document_type": "text/plain",
+	})
+	processJobs(t, server)
+	text, err := server.service.GetContent(ctx, textID)
+	if err != nil {
+		t.Fatalf("Failed to get content: %v", err)
+	}
+	if text.Status != string(simplecontent.ContentStatusUploaded) {
+		t.Errorf("Expected text content to stay uploaded, got %s", text.Status)
+	}
+
+	// Undecodable images return to uploaded and the job fails without retrying
+	brokenID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      uuid.New().String(),
+		"name":          "broken.png",
+		"document_type": "image/png",
+	})
+	processJobs(t, server)
+	broken, err := server.service.GetContent(ctx, brokenID)
+	if err != nil {
+		t.Fatalf("Failed to get content: %v", err)
+	}
+	if broken.Status != string(simplecontent.ContentStatusUploaded) {
+		t.Errorf("Expected broken image to return to uploaded, got %s", broken.Status)
+	}
+	nodes, err := server.collectDerivedTree(ctx, brokenID)
+	if err != nil || len(nodes) != 0 {
+		t.Errorf("Expected placeholders to be removed, got %v (%v)", nodes, err)
+	}
+	listed := callTool(t, server.handleListJobs, map[string]interface{}{"content_id": brokenID.String()})
+	job := listed["items"].([]interface{})[0].(map[string]interface{})
+	if job["status"] != "failed" || job["attempts"] != float64(1) || job["last_error"] == "" {
+		t.Errorf("Unexpected job: %v", job)
+	}
+}
+
+// flakyProcessor derives upper-cased text and fails its first attempts
+type flakyProcessor struct {
+	failures int
+}
+
+func (p *flakyProcessor) Name() string { return "upper" }
+
+func (p *flakyProcessor) Supports(mimeType, derivationType string) bool {
+	return mimeType == "text/plain" && derivationType == "upper"
+}
+
+func (p *flakyProcessor) Variants(job *jobs.Job) ([]string, error) {
+	return []string{"upper"}, nil
+}
+
+func (p *flakyProcessor) Process(ctx context.Context, input *jobs.Input) ([]*jobs.Output, error) {
+	if p.failures > 0 {
+		p.failures--
+		return nil, fmt.Errorf("temporarily unavailable")
+	}
+	return []*jobs.Output{{
+		Variant:  "upper",
+		MimeType: "text/plain",
+		Data:     bytes.ToUpper(input.Data),
+	}}, nil
+}
+
+func TestDerivationJobs(t *testing.T) {
+	service := createTestService(t)
+	config := DefaultConfig(service)
+	config.Processors = []jobs.Processor{&flakyProcessor{failures: 1}}
+	config.JobRetryBackoff = time.Millisecond
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+	ownerID := uuid.New().String()
+
+	call := func(handler mcp.ToolHandler, args map[string]interface{}) error {
+		argsJSON, _ := json.Marshal(args)
+		_, err := handler(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: argsJSON}})
+		return err
+	}
+
+	contentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID,
+		"name":          "notes.txt",
+		"document_type": "text/plain",
+	})
+
+	// No processor derives thumbnails from text
+	err = call(server.handleRequestDerivation, map[string]interface{}{
+		"content_id":      contentID.String(),
+		"derivation_type": "thumbnail",
+	})
+	if !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+
+	// max_attempts is capped by the server
+	err = call(server.handleRequestDerivation, map[string]interface{}{
+		"content_id":      contentID.String(),
+		"derivation_type": "upper",
+		"max_attempts":    1000000,
+	})
+	if !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected validation error for max_attempts above the limit, got %v", err)
+	}
+
+	requested := callTool(t, server.handleRequestDerivation, map[string]interface{}{
+		"content_id":      contentID.String(),
+		"derivation_type": "upper",
+	})
+	if requested["status"] != "queued" || requested["processor"] != "upper" {
+		t.Fatalf("Unexpected job: %v", requested)
+	}
+	jobID := requested["id"].(string)
+
+	status := callTool(t, server.handleGetContentStatus, map[string]interface{}{"content_id": contentID.String()})
+	if status["pending_jobs"] != float64(1) || status["processing_complete"] != false {
+		t.Errorf("Expected a pending job, got %v", status)
+	}
+
+	// The first attempt fails and is retried after the backoff
+	processJobs(t, server)
+	job := callTool(t, server.handleGetJob, map[string]interface{}{"job_id": jobID})
+	if job["status"] != "queued" || job["attempts"] != float64(1) || job["last_error"] != "temporarily unavailable" {
+		t.Fatalf("Expected job to be queued for retry, got %v", job)
+	}
+	content, err := server.service.GetContent(ctx, contentID)
+	if err != nil || content.Status != string(simplecontent.ContentStatusUploaded) {
+		t.Errorf("Expected content back in uploaded after a failed attempt, got %v (%v)", content, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	processJobs(t, server)
+	job = callTool(t, server.handleGetJob, map[string]interface{}{"job_id": jobID})
+	if job["status"] != "succeeded" || job["attempts"] != float64(2) {
+		t.Fatalf("Expected job to succeed on retry, got %v", job)
+	}
+	resultIDs := job["result_ids"].([]interface{})
+	derivedID, _ := uuid.Parse(resultIDs[0].(string))
+	data, err := server.readContent(ctx, derivedID)
+	if err != nil || string(data) != "TEST DATA" {
+		t.Errorf("Expected derived output, got %q (%v)", data, err)
+	}
+
+	status = callTool(t, server.handleGetContentStatus, map[string]interface{}{"content_id": contentID.String()})
+	if status["status"] != "processed" || status["pending_jobs"] != float64(0) || status["processing_complete"] != true {
+		t.Errorf("Expected processing to be complete, got %v", status)
+	}
+
+	// Deriving again replaces the earlier output
+	callTool(t, server.handleRequestDerivation, map[string]interface{}{
+		"content_id":      contentID.String(),
+		"derivation_type": "upper",
+	})
+	processJobs(t, server)
+	nodes, err := server.collectDerivedTree(ctx, contentID)
+	if err != nil || len(nodes) != 1 || nodes[0].ContentID == derivedID {
+		t.Errorf("Expected the output to be replaced, got %v (%v)", nodes, err)
+	}
+
+	listed := callTool(t, server.handleListJobs, map[string]interface{}{"owner_id": ownerID, "status": "succeeded"})
+	if listed["count"] != float64(2) {
+		t.Errorf("Expected 2 succeeded jobs, got %v", listed["count"])
+	}
+
+	if err := call(server.handleListJobs, map[string]interface{}{}); !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected owner_id to be required, got %v", err)
+	}
+}
+
+// hookedProcessor calls before at the start of each run of the embedded processor
+type hookedProcessor struct {
+	flakyProcessor
+	before func(input *jobs.Input)
+}
+
+func (p *hookedProcessor) Process(ctx context.Context, input *jobs.Input) ([]*jobs.Output, error) {
+	p.before(input)
+	return p.flakyProcessor.Process(ctx, input)
+}
+
+func TestDerivationJobWhileProcessing(t *testing.T) {
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(memoryrepo.New()),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+		simplecontent.WithBlobStore("archive", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	var server *Server
+	processor := &hookedProcessor{before: func(input *jobs.Input) {
+		// The content lock is free while the processor runs
+		acquired := make(chan struct{})
+		go func() {
+			server.locks.lock(input.Job.ContentID)()
+			close(acquired)
+		}()
+		select {
+		case <-acquired:
+		case <-time.After(time.Second):
+			t.Error("Expected the content lock to be released while the processor runs")
+		}
+	}}
+	config := DefaultConfig(service)
+	config.Processors = []jobs.Processor{processor}
+	server, err = New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+
+	contentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":        uuid.New().String(),
+		"name":            "notes.txt",
+		"document_type":   "text/plain",
+		"storage_backend": "archive",
+	})
+	requested := callTool(t, server.handleRequestDerivation, map[string]interface{}{
+		"content_id":      contentID.String(),
+		"derivation_type": "upper",
+	})
+	processJobs(t, server)
+
+	job := callTool(t, server.handleGetJob, map[string]interface{}{"job_id": requested["id"]})
+	if job["status"] != "succeeded" {
+		t.Fatalf("Expected the job to succeed, got %v", job)
+	}
+
+	// Outputs are stored on the backend of their source
+	derivedID := uuid.MustParse(job["result_ids"].([]interface{})[0].(string))
+	if backend, err := server.contentBackend(ctx, derivedID); err != nil || backend != "archive" {
+		t.Errorf("Expected the output on the archive backend, got %q (%v)", backend, err)
+	}
+}
+
+func TestReprocessContent(t *testing.T) {
+	config := DefaultConfig(createTestService(t))
+	config.Processors = []jobs.Processor{&flakyProcessor{}}
+	config.DerivationPolicy = DerivationPolicy{"text/plain": {"upper"}}
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+
+	contentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      uuid.New().String(),
+		"name":          "notes.txt",
+		"document_type": "text/plain",
+	})
+
+	// Only failed or processed content is reprocessed
+	args, _ := json.Marshal(map[string]interface{}{"content_id": contentID.String(), "action": "reprocess"})
+	if _, err := server.handleTransitionContentStatus(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: args}}); !errors.Is(err, mcperrors.ErrInvalidTransition) {
+		t.Errorf("Expected uploaded content to be rejected, got %v", err)
+	}
+
+	callTool(t, server.handleTransitionContentStatus, map[string]interface{}{
+		"content_id": contentID.String(),
+		"action":     "mark_failed",
+	})
+	result := callTool(t, server.handleTransitionContentStatus, map[string]interface{}{
+		"content_id": contentID.String(),
+		"action":     "reprocess",
+	})
+	if result["from"] != "failed" || result["to"] != "uploaded" {
+		t.Errorf("Expected failed -> uploaded, got %v -> %v", result["from"], result["to"])
+	}
+	jobIDs := result["job_ids"].([]interface{})
+	if len(jobIDs) != 1 {
+		t.Fatalf("Expected the policy's derivation to be queued, got %v", jobIDs)
+	}
+
+	// The queued job takes the content through processing to processed
+	processJobs(t, server)
+	content, err := server.service.GetContent(ctx, contentID)
+	if err != nil {
+		t.Fatalf("Failed to get content: %v", err)
+	}
+	if content.Status != string(simplecontent.ContentStatusProcessed) {
+		t.Errorf("Expected processed content, got %s", content.Status)
+	}
+	job, err := server.jobQueue.Get(ctx, uuid.MustParse(jobIDs[0].(string)))
+	if err != nil || job.Status != jobs.StatusSucceeded || len(job.ResultIDs) != 1 {
+		t.Errorf("Expected the job to succeed with one output, got %+v (%v)", job, err)
+	}
+
+	// Processed content can be reprocessed again, with the same derivations
+	result = callTool(t, server.handleTransitionContentStatus, map[string]interface{}{
+		"content_id": contentID.String(),
+		"action":     "reprocess",
+	})
+	if len(result["job_ids"].([]interface{})) != 1 {
+		t.Errorf("Expected one job for processed content, got %v", result["job_ids"])
+	}
+	processJobs(t, server)
+	if content, _ := server.service.GetContent(ctx, contentID); content.Status != string(simplecontent.ContentStatusProcessed) {
+		t.Errorf("Expected processed content after reprocessing again, got %s", content.Status)
+	}
+}
+
+func TestExtractedText(t *testing.T) {
+	service := createTestService(t)
+	config := DefaultConfig(service)
+	config.ExtractText = true
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+	ownerID := uuid.New().String()
+
+	page := `<html><head><title>Release notes</title></head><body><p>The new version is faster and it is smaller.</p><script>track()</script></body></html>`
+	pageID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID,
+		"name":          "notes.html",
+		"document_type": "text/html",
+		"data":          base64.StdEncoding.EncodeToString([]byte(page)),
+	})
+	processJobs(t, server)
+
+	// The extraction job stores a text variant
+	derived, err := server.service.ListDerivedContent(ctx, simplecontent.WithParentID(pageID))
+	if err != nil {
+		t.Fatalf("Failed to list derived content: %v", err)
+	}
+	if len(derived) != 1 || derived[0].Variant != "text" || derived[0].Status != string(simplecontent.ContentStatusProcessed) {
+		t.Fatalf("Expected a processed text variant, got %+v", derived)
+	}
+	metadata, err := server.service.GetContentMetadata(ctx, derived[0].ContentID)
+	if err != nil {
+		t.Fatalf("Failed to get derived metadata: %v", err)
+	}
+	if metadata.Metadata["language"] != "en" || metadata.Metadata["format"] != "html" {
+		t.Errorf("Unexpected extraction metadata: %v", metadata.Metadata)
+	}
+
+	result := callTool(t, server.handleDownloadContent, map[string]interface{}{
+		"content_id": pageID.String(),
+		"format":     "extracted_text",
+	})
+	if result["text"] != "Release notes\n\nThe new version is faster and it is smaller." {
+		t.Errorf("Unexpected text: %q", result["text"])
+	}
+	if result["source"] != "derived" || result["derived_id"] != derived[0].ContentID.String() || result["format"] != "html" {
+		t.Errorf("Unexpected result: %v", result)
+	}
+
+	// Documents without a text variant are extracted on the fly
+	notesID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      uuid.New().String(),
+		"name":          "todo.md",
+		"document_type": "text/markdown",
+		"data":          base64.StdEncoding.EncodeToString([]byte("# Todo\r\n\r\n- ship it\r\n")),
+	})
+	result = callTool(t, server.handleDownloadContent, map[string]interface{}{
+		"content_id": notesID.String(),
+		"format":     "extracted_text",
+	})
+	if result["text"] != "# Todo\n\n- ship it" || result["source"] != "extracted" || result["word_count"] != float64(3) {
+		t.Errorf("Unexpected result: %v", result)
+	}
+
+	// Other types are rejected
+	blobID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      uuid.New().String(),
+		"name":          "blob.bin",
+		"document_type": "application/octet-stream",
+		"data":          base64.StdEncoding.EncodeToString([]byte{1, 2, 3}),
+	})
+	args, _ := json.Marshal(map[string]interface{}{"content_id": blobID.String(), "format": "extracted_text"})
+	if _, err := server.handleDownloadContent(ctx, &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Arguments: args},
+	}); err == nil || !strings.Contains(err.Error(), "cannot be extracted") {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+}
+
+func TestWaitForContent(t *testing.T) {
+	service := createTestService(t)
+	config := DefaultConfig(service)
+	config.ThumbnailSizes = []int{64}
+	config.JobWorkers = 0
+	config.WaitPollInterval = 10 * time.Millisecond
+	config.MaxWaitTimeout = time.Minute
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 100, 100))); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	contentID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      uuid.New().String(),
+		"name":          "photo.png",
+		"document_type": "image/png",
+		"data":          base64.StdEncoding.EncodeToString(buf.Bytes()),
+	})
+
+	// Connect a client that records progress notifications
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.mcpServer.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("Failed to connect server: %v", err)
+	}
+	progress := make(chan *mcp.ProgressNotificationParams, 100)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "1.0"}, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			progress <- req.Params
+		},
+	})
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect client: %v", err)
+	}
+	defer session.Close()
+
+	// The thumbnail job only runs once the waiting call has reported progress
+	params := &mcp.CallToolParams{
+		// SetProgressToken drops the token when Meta is nil, so set it directly
+		Meta: mcp.Meta{"progressToken": "wait-1"},
+		Name: "wait_for_content",
+		Arguments: map[string]interface{}{
+			"content_id":      contentID.String(),
+			"variants":        []string{"thumbnail_64"},
+			"timeout_seconds": 10,
+		},
+	}
+	done := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		res, err := session.CallTool(ctx, params)
+		if err != nil {
+			t.Errorf("CallTool failed: %v", err)
+		}
+		done <- res
+	}()
+
+	select {
+	case p := <-progress:
+		if p.ProgressToken != "wait-1" || !strings.Contains(p.Message, "waiting for thumbnail_64") || p.Total != 10 {
+			t.Errorf("Unexpected progress notification: %+v", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No progress notification received")
+	}
+	processJobs(t, server)
+
+	res := <-done
+	if res == nil {
+		t.FailNow()
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &result); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if result["satisfied"] != true || result["timed_out"] != false || len(result["missing_variants"].([]interface{})) != 0 {
+		t.Errorf("Unexpected result: %v", result)
+	}
+	// The variant can be seen before the pool records the job as succeeded,
+	// so pending_jobs may still count it
+	if result["status"] != string(simplecontent.ContentStatusProcessed) || result["ready"] != true {
+		t.Errorf("Expected the processed status payload, got %v", result)
+	}
+
+	// Without status or variants the call returns once the content is ready
+	result = callTool(t, server.handleWaitForContent, map[string]interface{}{
+		"content_id": contentID.String(),
+	})
+	if result["satisfied"] != true || result["ready"] != true {
+		t.Errorf("Unexpected result: %v", result)
+	}
+
+	// A status that is never reached times out
+	result = callTool(t, server.handleWaitForContent, map[string]interface{}{
+		"content_id":      contentID.String(),
+		"status":          "archived",
+		"timeout_seconds": 0.05,
+	})
+	if result["satisfied"] != false || result["timed_out"] != true {
+		t.Errorf("Expected a timeout, got %v", result)
+	}
+
+	// Failed content ends the wait early
+	if err := server.service.UpdateContentStatus(ctx, contentID, simplecontent.ContentStatusFailed); err != nil {
+		t.Fatalf("Failed to mark content failed: %v", err)
+	}
+	result = callTool(t, server.handleWaitForContent, map[string]interface{}{
+		"content_id":      contentID.String(),
+		"status":          "processed",
+		"timeout_seconds": 10,
+	})
+	if result["satisfied"] != false || result["timed_out"] != false || result["status"] != "failed" {
+		t.Errorf("Expected the wait to stop at failed content, got %v", result)
+	}
+
+	args, _ := json.Marshal(map[string]interface{}{"content_id": contentID.String(), "timeout_seconds": 600})
+	if _, err := server.handleWaitForContent(ctx, &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Arguments: args},
+	}); err == nil {
+		t.Error("Expected an error for a timeout above MaxWaitTimeout")
+	}
+
+	// Content deleted during the wait ends it with the deleted status
+	notesID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": uuid.New().String(),
+		"name":     "notes.txt",
+	})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		args, _ := json.Marshal(map[string]interface{}{"content_id": notesID.String()})
+		if _, err := server.handleDeleteContent(ctx, &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{Arguments: args},
+		}); err != nil {
+			t.Errorf("Failed to delete content: %v", err)
+		}
+	}()
+	result = callTool(t, server.handleWaitForContent, map[string]interface{}{
+		"content_id":      notesID.String(),
+		"status":          "archived",
+		"timeout_seconds": 10,
+	})
+	if result["satisfied"] != false || result["timed_out"] != false || result["status"] != "deleted" {
+		t.Errorf("Expected the wait to stop at deleted content, got %v", result)
+	}
+}
+
+func TestDerivationPolicy(t *testing.T) {
+	policy, err := ParseDerivationPolicy("image/*=thumbnail_256, thumbnail_1024; application/pdf=preview,text;*=text")
+	if err != nil {
+		t.Fatalf("ParseDerivationPolicy failed: %v", err)
+	}
+	tests := map[string]string{
+		"image/png":                  "thumbnail_256,thumbnail_1024",
+		"application/pdf; version=2": "preview,text",
+		"text/plain":                 "text",
+	}
+	for mimeType, expected := range tests {
+		if got := strings.Join(policy.Expected(mimeType), ","); got != expected {
+			t.Errorf("Expected(%q) = %q, expected %q", mimeType, got, expected)
+		}
+	}
+
+	for _, invalid := range []string{"image/*", "png=thumbnail_256"} {
+		if _, err := ParseDerivationPolicy(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestContentStatusDerivations(t *testing.T) {
+	service := createTestService(t)
+	config := DefaultConfig(service)
+	config.ThumbnailSizes = []int{64}
+	config.Processors = []jobs.Processor{&flakyProcessor{failures: 1}}
+	config.JobMaxAttempts = 1
+	config.DerivationPolicy = DerivationPolicy{
+		"image/*":    {"thumbnail_64", "thumbnail_32", "preview"},
+		"text/plain": {"upper"},
+	}
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ownerID := uuid.New().String()
+
+	derivations := func(contentID uuid.UUID) map[string]interface{} {
+		t.Helper()
+		result := callTool(t, server.handleGetContentStatus, map[string]interface{}{"content_id": contentID.String()})
+		return result["derivations"].(map[string]interface{})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 100, 100))); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	imageID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID,
+		"name":          "photo.png",
+		"document_type": "image/png",
+		"data":          base64.StdEncoding.EncodeToString(buf.Bytes()),
+	})
+
+	// The queued thumbnail job makes thumbnail_64 pending
+	report := derivations(imageID)
+	if fmt.Sprint(report["expected"], report["pending"], report["missing"]) != "[thumbnail_64 thumbnail_32 preview] [thumbnail_64] [thumbnail_32 preview]" {
+		t.Errorf("Unexpected report before processing: %v", report)
+	}
+	if report["completeness"] != float64(0) || report["complete"] != false {
+		t.Errorf("Unexpected completeness: %v", report)
+	}
+
+	processJobs(t, server)
+	uploadTestDerived(t, server, imageID, "preview")
+
+	report = derivations(imageID)
+	if fmt.Sprint(report["present"], report["pending"], report["missing"]) != "[thumbnail_64 preview] [] [thumbnail_32]" {
+		t.Errorf("Unexpected report after processing: %v", report)
+	}
+	if report["completeness"] != 66.7 {
+		t.Errorf("Expected 66.7%% completeness, got %v", report["completeness"])
+	}
+
+	// A job that fails its only attempt reports the derivation as failed
+	textID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID,
+		"name":          "notes.txt",
+		"document_type": "text/plain",
+	})
+	callTool(t, server.handleRequestDerivation, map[string]interface{}{
+		"content_id":      textID.String(),
+		"derivation_type": "upper",
+	})
+	processJobs(t, server)
+
+	report = derivations(textID)
+	if fmt.Sprint(report["failed"], report["present"]) != "[upper] []" {
+		t.Errorf("Expected upper to have failed, got %v", report)
+	}
+	status := callTool(t, server.handleGetContentStatus, map[string]interface{}{"content_id": textID.String()})
+	if status["ready"] != false {
+		t.Errorf("Expected content missing an expected derivation not to be ready, got %v", status["ready"])
+	}
+
+	// A queued retry after the failed job makes the derivation pending again
+	callTool(t, server.handleRequestDerivation, map[string]interface{}{
+		"content_id":      textID.String(),
+		"derivation_type": "upper",
+	})
+	report = derivations(textID)
+	if fmt.Sprint(report["failed"], report["pending"]) != "[] [upper]" {
+		t.Errorf("Expected the retry to make upper pending, got %v", report)
+	}
+
+	// Content without expectations is complete
+	server.config.DerivationPolicy = nil
+	report = derivations(textID)
+	if report["completeness"] != float64(100) || report["complete"] != true {
+		t.Errorf("Expected a complete report without a policy, got %v", report)
+	}
+}
+
+func TestSearchContent(t *testing.T) {
+	server := createTestServer(t)
+	ownerID := uuid.New()
+
+	reportID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":    ownerID.String(),
+		"name":        "Quarterly report",
+		"description": "Revenue figures",
+		"tags":        []string{"finance"},
+	})
+	notesID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":    ownerID.String(),
+		"name":        "Meeting notes",
+		"description": "Discussed the reporting schedule",
+	})
+	uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": uuid.New().String(),
+		"name":     "Someone else's report",
+	})
+
+	search := func(args map[string]interface{}) []interface{} {
+		t.Helper()
+		args["owner_id"] = ownerID.String()
+		return callTool(t, server.handleSearchContent, args)["items"].([]interface{})
+	}
+
+	// Ranked by relevance, with scores and highlights
+	items := search(map[string]interface{}{"query": "reports"})
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 results, got %v", items)
+	}
+	first := items[0].(map[string]interface{})
+	if first["id"] != reportID.String() || first["score"].(float64) <= items[1].(map[string]interface{})["score"].(float64) {
+		t.Errorf("Expected the name match first, got %v", items)
+	}
+	if highlights := first["highlights"].(map[string]interface{}); highlights["name"] != "Quarterly <mark>report</mark>" {
+		t.Errorf("Unexpected highlights: %v", highlights)
+	}
+
+	if items := search(map[string]interface{}{"tags": []string{"finance"}}); len(items) != 1 {
+		t.Errorf("Expected the tagged content, got %v", items)
+	}
+
+	// Updates and deletes are reflected in the index
+	callTool(t, server.handleUpdateContent, map[string]interface{}{
+		"content_id": notesID.String(),
+		"name":       "Board minutes",
+		"add_tags":   []string{"finance"},
+	})
+	if items := search(map[string]interface{}{"query": "minutes", "tags": []string{"finance"}}); len(items) != 1 {
+		t.Errorf("Expected the renamed content, got %v", items)
+	}
+
+	callTool(t, server.handleDeleteContent, map[string]interface{}{"content_id": reportID.String()})
+	items = search(map[string]interface{}{"query": "report"})
+	if len(items) != 1 || items[0].(map[string]interface{})["id"] != notesID.String() {
+		t.Errorf("Expected only the notes after the delete, got %v", items)
+	}
+}
+
+func TestRebuildSearchIndex(t *testing.T) {
+	repo := memoryrepo.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(repo),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	ctx := context.Background()
+
+	// Content stored before the server started is not in its index yet
+	ownerID := uuid.New()
+	content, err := service.UploadContent(ctx, simplecontent.UploadContentRequest{
+		OwnerID:            ownerID,
+		Name:               "Existing invoice",
+		DocumentType:       "text/plain",
+		StorageBackendName: "default",
+		Reader:             strings.NewReader("data"),
+		Tags:               []string{"billing"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to upload content: %v", err)
+	}
+
+	config := DefaultConfig(service)
+	config.AdminService = admin.New(repo)
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	// Until the index is rebuilt, searches list the content from the service
+	args := map[string]interface{}{"owner_id": ownerID.String(), "query": "invoice billing"}
+	items := callTool(t, server.handleSearchContent, args)["items"].([]interface{})
+	if len(items) != 1 || items[0].(map[string]interface{})["id"] != content.ID.String() {
+		t.Fatalf("Expected the existing content before the rebuild, got %v", items)
+	}
+	if server.indexPopulated.Load() {
+		t.Error("Expected the index to be reported unpopulated before the rebuild")
+	}
+
+	indexed, err := server.RebuildSearchIndex(ctx)
+	if err != nil || indexed != 1 {
+		t.Fatalf("Expected 1 content indexed, got %d (%v)", indexed, err)
+	}
+	if !server.indexPopulated.Load() {
+		t.Error("Expected the index to be populated after the rebuild")
+	}
+	items = callTool(t, server.handleSearchContent, args)["items"].([]interface{})
+	if len(items) != 1 || items[0].(map[string]interface{})["id"] != content.ID.String() {
+		t.Errorf("Expected the existing content, got %v", items)
+	}
+
+	// Without an admin service the index cannot be rebuilt, so searches need an owner to list
+	noAdmin, err := New(DefaultConfig(service))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	items = callTool(t, noAdmin.handleSearchContent, args)["items"].([]interface{})
+	if len(items) != 1 {
+		t.Errorf("Expected the existing content without an admin service, got %v", items)
+	}
+	argsJSON, _ := json.Marshal(map[string]interface{}{"query": "invoice"})
+	_, err = noAdmin.handleSearchContent(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: argsJSON}})
+	if !errors.Is(err, mcperrors.ErrValidation) || !strings.Contains(err.Error(), "admin service") {
+		t.Errorf("Expected a validation error naming the missing rebuild, got %v", err)
+	}
+}
+
+func TestSearchContentQueryLanguage(t *testing.T) {
+	server := createTestServer(t)
+	ownerID := uuid.New()
+
+	invoiceID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID.String(),
+		"name":          "Quarterly report March",
+		"document_type": "application/pdf",
+		"tags":          []string{"invoice"},
+	})
+	uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID.String(),
+		"name":          "Quarterly report draft",
+		"document_type": "application/pdf",
+		"tags":          []string{"invoice", "draft"},
+	})
+	uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID.String(),
+		"name":          "Report quarterly photo",
+		"document_type": "image/png",
+		"tags":          []string{"invoice"},
+	})
+
+	result := callTool(t, server.handleSearchContent, map[string]interface{}{
+		"owner_id": ownerID.String(),
+		"query":    `tag:invoice AND mime:application/pdf AND created:>2025-01-01 AND size:<5MB AND "quarterly report" -draft`,
+	})
+	items := result["items"].([]interface{})
+	if len(items) != 1 || items[0].(map[string]interface{})["id"] != invoiceID.String() {
+		t.Errorf("Expected only the final invoice, got %v", items)
+	}
+
+	// Syntax errors are validation errors pointing at the problem
+	args, _ := json.Marshal(map[string]interface{}{"owner_id": ownerID.String(), "query": "tag:invoice AND (mime:image/*"})
+	_, err := server.handleSearchContent(context.Background(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Arguments: args},
+	})
+	if !errors.Is(err, mcperrors.ErrValidation) || !strings.Contains(err.Error(), "position 17: missing ')'") {
+		t.Errorf("Expected a syntax error, got %v", err)
+	}
+
+	syntax, err := server.handleSearchSyntaxResource(context.Background(), &mcp.ReadResourceRequest{
+		Params: &mcp.ReadResourceParams{URI: searchSyntaxURI},
+	})
+	if err != nil || !strings.Contains(syntax.Contents[0].Text, "size:<5MB") {
+		t.Errorf("Expected the query grammar, got %v (%v)", syntax, err)
+	}
+}
+
+func TestMetadataFilters(t *testing.T) {
+	repo := memoryrepo.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(repo),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	config := DefaultConfig(service)
+	config.AdminService = admin.New(repo)
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ownerID := uuid.New()
+
+	apolloID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": ownerID.String(),
+		"name":     "Apollo contract",
+		"metadata": map[string]interface{}{
+			"project":  "apollo",
+			"customer": map[string]interface{}{"id": "c-42"},
+			"amount":   1200,
+			"signed":   "2025-03-14",
+		},
+	})
+	uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": ownerID.String(),
+		"name":     "Gemini contract",
+		"metadata": map[string]interface{}{"project": "gemini", "amount": 300},
+	})
+	uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": ownerID.String(),
+		"name":     "Unrelated notes",
+	})
+
+	ids := func(result map[string]interface{}) []string {
+		var ids []string
+		for _, item := range result["items"].([]interface{}) {
+			ids = append(ids, item.(map[string]interface{})["id"].(string))
+		}
+		return ids
+	}
+
+	filters := []map[string]interface{}{
+		{"path": "$.customer.id", "op": "eq", "value": "c-42"},
+		{"path": "$.amount", "op": "gt", "value": 1000},
+		{"path": "$.signed", "op": "gte", "value": "2025-01-01"},
+	}
+	result := callTool(t, server.handleListContent, map[string]interface{}{
+		"owner_id":         ownerID.String(),
+		"metadata_filters": filters,
+	})
+	if got := ids(result); len(got) != 1 || got[0] != apolloID.String() || result["total"].(float64) != 1 {
+		t.Errorf("Expected only the apollo contract, got %v", result)
+	}
+
+	// Filters apply before pagination
+	result = callTool(t, server.handleListContent, map[string]interface{}{
+		"owner_id":         ownerID.String(),
+		"metadata_filters": []map[string]interface{}{{"path": "$.project", "op": "exists"}},
+		"limit":            1,
+		"offset":           1,
+	})
+	if len(ids(result)) != 1 || result["total"].(float64) != 2 {
+		t.Errorf("Expected the second of 2 contracts, got %v", result)
+	}
+
+	// Admin listing without an owner pages through all content
+	server.config.RequireOwnerID = false
+	result = callTool(t, server.handleListContent, map[string]interface{}{
+		"metadata_filters": []map[string]interface{}{{"path": "$.project", "op": "in", "value": []string{"apollo", "mercury"}}},
+	})
+	if got := ids(result); len(got) != 1 || got[0] != apolloID.String() {
+		t.Errorf("Expected the apollo contract from the admin listing, got %v", result)
+	}
+
+	result = callTool(t, server.handleSearchContent, map[string]interface{}{
+		"owner_id":         ownerID.String(),
+		"query":            "contract",
+		"metadata_filters": []map[string]interface{}{{"path": "$.project", "op": "ne", "value": "gemini"}},
+	})
+	if got := ids(result); len(got) != 1 || got[0] != apolloID.String() {
+		t.Errorf("Expected the apollo contract from search, got %v", result)
+	}
+
+	// Metadata changes are picked up by the search index
+	callTool(t, server.handleUpdateContent, map[string]interface{}{
+		"content_id": apolloID.String(),
+		"metadata":   map[string]interface{}{"project": "gemini"},
+	})
+	result = callTool(t, server.handleSearchContent, map[string]interface{}{
+		"owner_id":         ownerID.String(),
+		"metadata_filters": []map[string]interface{}{{"path": "$.project", "op": "eq", "value": "gemini"}},
+	})
+	if len(ids(result)) != 2 {
+		t.Errorf("Expected both contracts after the update, got %v", result)
+	}
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"owner_id":         ownerID.String(),
+		"metadata_filters": []map[string]interface{}{{"path": "$.amount", "op": "gt", "value": "lots"}},
+	})
+	_, err = server.handleListContent(context.Background(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Arguments: args},
+	})
+	if !errors.Is(err, mcperrors.ErrValidation) || !strings.Contains(err.Error(), "metadata_filters") {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+}
+
+func TestSearchContentBody(t *testing.T) {
+	service := createTestService(t)
+	config := DefaultConfig(service)
+	config.IndexBodies = true
+	config.ExtractText = true
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ownerID := uuid.New()
+
+	policy := "1. Scope\n\nThis policy covers travel.\n\n" + strings.Repeat("Expenses are reimbursed monthly. ", 40) +
+		"\n\n9. Liability\n\nThe employee shall indemnify the company for personal losses."
+	policyID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID.String(),
+		"name":          "Travel policy",
+		"document_type": "text/markdown",
+		"data":          base64.StdEncoding.EncodeToString([]byte(policy)),
+	})
+	uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID.String(),
+		"name":          "Indemnify clause drafts",
+		"document_type": "text/plain",
+		"data":          base64.StdEncoding.EncodeToString([]byte("Nothing relevant here.")),
+	})
+
+	// DOCX bodies are searched through their extracted text
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("word/document.xml")
+	w.Write([]byte(`<w:document><w:body><w:p><w:r><w:t>The contractor shall indemnify the client.</w:t></w:r></w:p></w:body></w:document>`))
+	zw.Close()
+	contractID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID.String(),
+		"name":          "Contract",
+		"document_type": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"data":          base64.StdEncoding.EncodeToString(buf.Bytes()),
+	})
+	processJobs(t, server)
+
+	result := callTool(t, server.handleSearchContent, map[string]interface{}{
+		"owner_id": ownerID.String(),
+		"query":    `"shall indemnify"`,
+		"scope":    "body",
+	})
+	items := result["items"].([]interface{})
+	if len(items) != 2 || result["total"].(float64) != 2 {
+		t.Fatalf("Expected passages of the policy and the contract, got %v", result)
+	}
+	found := map[string]map[string]interface{}{}
+	for _, item := range items {
+		passage := item.(map[string]interface{})
+		found[passage["content_id"].(string)] = passage
+		if !strings.Contains(passage["highlight"].(string), "<mark>indemnify</mark>") {
+			t.Errorf("Unexpected highlight: %v", passage)
+		}
+	}
+
+	passage := found[policyID.String()]
+	if passage == nil {
+		t.Fatalf("Expected a policy passage, got %v", items)
+	}
+	start, end := int(passage["start"].(float64)), int(passage["end"].(float64))
+	if !strings.Contains(policy[start:end], "shall indemnify the company") || passage["source_id"] != nil {
+		t.Errorf("Unexpected policy passage: %v", passage)
+	}
+	if passage := found[contractID.String()]; passage == nil || passage["source_id"] == nil {
+		t.Errorf("Expected the contract passage from its extracted text, got %v", passage)
+	}
+
+	// Content filters still apply
+	result = callTool(t, server.handleSearchContent, map[string]interface{}{
+		"owner_id": ownerID.String(),
+		"query":    "indemnify mime:text/*",
+		"scope":    "body",
+	})
+	if result["total"].(float64) != 1 {
+		t.Errorf("Expected only the policy passage, got %v", result)
+	}
+
+	// Deleted content leaves the body index
+	callTool(t, server.handleDeleteContent, map[string]interface{}{"content_id": policyID.String()})
+	result = callTool(t, server.handleSearchContent, map[string]interface{}{
+		"owner_id": ownerID.String(),
+		"query":    "indemnify",
+		"scope":    "body",
+	})
+	if result["total"].(float64) != 1 {
+		t.Errorf("Expected only the contract passage, got %v", result)
+	}
+
+	// Body search requires words and the option
+	args, _ := json.Marshal(map[string]interface{}{"owner_id": ownerID.String(), "query": "tag:x", "scope": "body"})
+	if _, err := server.handleSearchContent(context.Background(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Arguments: args},
+	}); !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+	plain := createTestServer(t)
+	args, _ = json.Marshal(map[string]interface{}{"query": "indemnify", "scope": "body"})
+	if _, err := plain.handleSearchContent(context.Background(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Arguments: args},
+	}); err == nil || !strings.Contains(err.Error(), "not enabled") {
+		t.Errorf("Expected body search to be disabled, got %v", err)
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	repo := memoryrepo.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(repo),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	config := DefaultConfig(service)
+	config.AdminService = admin.New(repo)
+	config.CursorSecret = []byte("test secret")
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ctx := context.Background()
+	ownerID := uuid.New().String()
+
+	for i := 0; i < 5; i++ {
+		uploadTestContent(t, server, map[string]interface{}{"owner_id": ownerID, "name": fmt.Sprintf("report %d", i)})
+	}
+
+	// pageThrough follows next_cursor, optionally uploading content after the first page
+	pageThrough := func(handler mcp.ToolHandler, args map[string]interface{}, insert bool) []string {
+		t.Helper()
+		var seen []string
+		for page := 0; ; page++ {
+			result := callTool(t, handler, args)
+			for _, item := range result["items"].([]interface{}) {
+				seen = append(seen, item.(map[string]interface{})["id"].(string))
+			}
+			next, ok := result["next_cursor"].(string)
+			if !ok {
+				return seen
+			}
+			if page == 0 && insert {
+				uploadTestContent(t, server, map[string]interface{}{"owner_id": ownerID, "name": "report late"})
+			}
+			args["cursor"] = next
+		}
+	}
+	distinct := func(ids []string) bool {
+		set := make(map[string]bool)
+		for _, id := range ids {
+			set[id] = true
+		}
+		return len(set) == len(ids)
+	}
+
+	// Owner mode pages in memory
+	seen := pageThrough(server.handleListContent, map[string]interface{}{"owner_id": ownerID, "limit": 2}, true)
+	if len(seen) != 5 || !distinct(seen) {
+		t.Errorf("Expected the 5 original contents once each, got %v", seen)
+	}
+
+	// Admin mode pages through the repository
+	server.config.RequireOwnerID = false
+	seen = pageThrough(server.handleListContent, map[string]interface{}{"owner_id": ownerID, "limit": 2}, true)
+	if len(seen) != 6 || !distinct(seen) {
+		t.Errorf("Expected 6 contents once each in admin mode, got %v", seen)
+	}
+
+	// Search pages follow the ranking
+	seen = pageThrough(server.handleSearchContent, map[string]interface{}{"owner_id": ownerID, "query": "report", "limit": 3}, false)
+	if len(seen) != 7 || !distinct(seen) {
+		t.Errorf("Expected 7 search hits once each, got %v", seen)
+	}
+
+	// Derived content pages report the total
+	parentID := uploadTestContent(t, server, map[string]interface{}{"owner_id": ownerID, "name": "photo.png"})
+	for _, variant := range []string{"thumbnail_64", "thumbnail_128", "thumbnail_256"} {
+		uploadTestDerived(t, server, parentID, variant)
+	}
+	derived := callTool(t, server.handleListDerivedContent, map[string]interface{}{"parent_id": parentID.String(), "limit": 2})
+	if derived["total"] != float64(3) || derived["count"] != float64(2) || derived["next_cursor"] == nil {
+		t.Fatalf("Unexpected first derived page: %v", derived)
+	}
+	derived = callTool(t, server.handleListDerivedContent, map[string]interface{}{"parent_id": parentID.String(), "limit": 2, "cursor": derived["next_cursor"]})
+	if derived["count"] != float64(1) || derived["next_cursor"] != nil {
+		t.Errorf("Unexpected last derived page: %v", derived)
+	}
+
+	// Cursors are bound to their listing and can't be combined with offset
+	first := callTool(t, server.handleListContent, map[string]interface{}{"owner_id": ownerID, "limit": 1})
+	token := first["next_cursor"].(string)
+	for _, args := range []map[string]interface{}{
+		{"owner_id": ownerID, "limit": 1, "cursor": token + "x"},
+		{"owner_id": ownerID, "status": "uploaded", "cursor": token},
+		{"owner_id": ownerID, "offset": 1, "cursor": token},
+	} {
+		argsJSON, _ := json.Marshal(args)
+		_, err := server.handleListContent(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: argsJSON}})
+		if !errors.Is(err, mcperrors.ErrValidation) {
+			t.Errorf("Expected a validation error for %v, got %v", args, err)
+		}
+	}
+	if _, err := server.handleSearchContent(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: json.RawMessage(`{"query":"report","cursor":"` + token + `"}`)}}); !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected a list_content cursor to be rejected by search_content, got %v", err)
+	}
+}
+
+func TestSortOrder(t *testing.T) {
+	repo := memoryrepo.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(repo),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	config := DefaultConfig(service)
+	config.AdminService = admin.New(repo)
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ownerID := uuid.New().String()
+
+	for _, upload := range []struct{ name, data string }{
+		{"beta notes", "medium size"},
+		{"alpha notes", "the largest of the three"},
+		{"gamma notes", "tiny"},
+	} {
+		uploadTestContent(t, server, map[string]interface{}{
+			"owner_id": ownerID,
+			"name":     upload.name,
+			"data":     base64.StdEncoding.EncodeToString([]byte(upload.data)),
+		})
+	}
+
+	// names follows next_cursor and returns the names in listing order
+	names := func(handler mcp.ToolHandler, args map[string]interface{}) []string {
+		t.Helper()
+		var names []string
+		for {
+			result := callTool(t, handler, args)
+			for _, item := range result["items"].([]interface{}) {
+				names = append(names, item.(map[string]interface{})["name"].(string))
+			}
+			next, ok := result["next_cursor"].(string)
+			if !ok {
+				return names
+			}
+			args["cursor"] = next
+		}
+	}
+
+	tests := []struct {
+		handler mcp.ToolHandler
+		args    map[string]interface{}
+		want    string
+	}{
+		{server.handleListContent, map[string]interface{}{"sort_by": "size"}, "alpha notes,beta notes,gamma notes"},
+		{server.handleListContent, map[string]interface{}{"sort_by": "name", "order": "asc"}, "alpha notes,beta notes,gamma notes"},
+		{server.handleListContent, map[string]interface{}{"order": "asc"}, "beta notes,alpha notes,gamma notes"},
+		{server.handleSearchContent, map[string]interface{}{"query": "notes", "sort_by": "size", "order": "asc"}, "gamma notes,beta notes,alpha notes"},
+		{server.handleSearchContent, map[string]interface{}{"query": "notes", "sort_by": "name", "order": "desc"}, "gamma notes,beta notes,alpha notes"},
+	}
+	for _, requireOwner := range []bool{true, false} {
+		// Admin mode sorts by creation time in the repository
+		server.config.RequireOwnerID = requireOwner
+		for _, test := range tests {
+			args := map[string]interface{}{"owner_id": ownerID, "limit": 1}
+			for k, v := range test.args {
+				args[k] = v
+			}
+			if got := strings.Join(names(test.handler, args), ","); got != test.want {
+				t.Errorf("%v (owner required: %v): got %s, want %s", test.args, requireOwner, got, test.want)
+			}
+		}
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"owner_id": ownerID, "sort_by": "relevance"},
+		{"owner_id": ownerID, "order": "up"},
+	} {
+		argsJSON, _ := json.Marshal(args)
+		_, err := server.handleListContent(context.Background(), &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: argsJSON}})
+		if !errors.Is(err, mcperrors.ErrValidation) {
+			t.Errorf("Expected a validation error for %v, got %v", args, err)
+		}
+	}
+}
+
+func TestContentFacets(t *testing.T) {
+	repo := memoryrepo.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(repo),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	config := DefaultConfig(service)
+	config.AdminService = admin.New(repo)
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ownerID := uuid.New().String()
+
+	invoiceID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID,
+		"name":          "March invoice",
+		"document_type": "application/pdf",
+		"tags":          []string{"invoice", "2025"},
+		"data":          base64.StdEncoding.EncodeToString([]byte("0123456789")),
+	})
+	uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID,
+		"name":          "Team photo",
+		"document_type": "image/png",
+		"tags":          []string{"people"},
+		"data":          base64.StdEncoding.EncodeToString([]byte("12345")),
+	})
+	uploadTestDerived(t, server, invoiceID, "thumbnail_256")
+	callTool(t, server.handleCreateCollection, map[string]interface{}{"owner_id": ownerID, "name": "Projects"})
+	uploadTestContent(t, server, map[string]interface{}{"owner_id": uuid.New().String(), "name": "Someone else's"})
+
+	result := callTool(t, server.handleContentFacets, map[string]interface{}{"owner_id": ownerID, "interval": "day"})
+	if result["total"] != float64(3) || result["total_bytes"] != float64(15) {
+		t.Errorf("Expected 3 contents and 15 bytes, got %v and %v", result["total"], result["total_bytes"])
+	}
+	tags := result["tags"].(map[string]interface{})
+	mimeTypes := result["mime_types"].(map[string]interface{})
+	derivationTypes := result["derivation_types"].(map[string]interface{})
+	if tags["invoice"] != float64(1) || mimeTypes["image/png"] != float64(1) || derivationTypes["original"] != float64(2) || derivationTypes["thumbnail"] != float64(1) {
+		t.Errorf("Unexpected facets: %v", result)
+	}
+	if backends := result["storage_backends"].(map[string]interface{}); backends["default"] != float64(3) {
+		t.Errorf("Expected every content in the default backend, got %v", backends)
+	}
+	created := result["created"].([]interface{})
+	if len(created) != 1 || created[0].(map[string]interface{})["count"] != float64(3) {
+		t.Errorf("Expected a single day bucket, got %v", created)
+	}
+
+	// Filters narrow the summary down
+	result = callTool(t, server.handleContentFacets, map[string]interface{}{"owner_id": ownerID, "query": "invoice"})
+	if result["total"] != float64(1) || result["total_bytes"] != float64(10) {
+		t.Errorf("Expected only the invoice, got %v", result)
+	}
+	result = callTool(t, server.handleContentFacets, map[string]interface{}{"owner_id": ownerID, "tags": []string{"people"}})
+	if result["total"] != float64(1) || result["mime_types"].(map[string]interface{})["image/png"] != float64(1) {
+		t.Errorf("Expected only the photo, got %v", result)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"interval": "day"},
+		{"owner_id": ownerID, "interval": "quarter"},
+	} {
+		argsJSON, _ := json.Marshal(args)
+		_, err := server.handleContentFacets(context.Background(), &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: argsJSON}})
+		if !errors.Is(err, mcperrors.ErrValidation) {
+			t.Errorf("Expected a validation error for %v, got %v", args, err)
+		}
+	}
+}
+
+func TestSemanticSearch(t *testing.T) {
+	service := createTestService(t)
+
+	// Disabled without an embedder
+	server, err := New(DefaultConfig(service))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	argsJSON, _ := json.Marshal(map[string]interface{}{"query": "lease", "owner_id": uuid.New().String()})
+	if _, err := server.handleSemanticSearch(context.Background(), &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: argsJSON}}); !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected a validation error without an embedder, got %v", err)
+	}
+
+	config := DefaultConfig(service)
+	config.Embedder = semantic.NewHashEmbedder(0)
+	server, err = New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ownerID := uuid.New().String()
+
+	leaseID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":      ownerID,
+		"name":          "Agreement 2025-07",
+		"document_type": "text/plain",
+		"data": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("General terms and definitions apply. ", 40) +
+			"The tenant leases office space on the third floor for five years.")),
+	})
+	reportID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id":    ownerID,
+		"name":        "Quarterly sales report",
+		"description": "Revenue by region",
+	})
+	uploadTestContent(t, server, map[string]interface{}{"owner_id": uuid.New().String(), "name": "Office lease"})
+
+	result := callTool(t, server.handleSemanticSearch, map[string]interface{}{
+		"owner_id": ownerID,
+		"query":    "contract about office leases",
+		"k":        1,
+	})
+	items := result["items"].([]interface{})
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 result, got %v", result)
+	}
+	item := items[0].(map[string]interface{})
+	if item["id"] != leaseID.String() || item["matched"] != "text" || item["start"].(float64) == 0 {
+		t.Errorf("Expected the passage about the lease, got %v", item)
+	}
+	if score := item["score"].(float64); score <= 0 || score > 1 {
+		t.Errorf("Unexpected score %v", score)
+	}
+
+	result = callTool(t, server.handleSemanticSearch, map[string]interface{}{"owner_id": ownerID, "query": "sales revenue"})
+	items = result["items"].([]interface{})
+	if len(items) == 0 || items[0].(map[string]interface{})["id"] != reportID.String() || items[0].(map[string]interface{})["matched"] != "metadata" {
+		t.Errorf("Expected the report first, got %v", items)
+	}
+
+	// Deleted content leaves the vector index
+	callTool(t, server.handleDeleteContent, map[string]interface{}{"content_id": reportID})
+	result = callTool(t, server.handleSemanticSearch, map[string]interface{}{"owner_id": ownerID, "query": "sales revenue", "min_score": 0.5})
+	if items := result["items"].([]interface{}); len(items) != 0 {
+		t.Errorf("Expected no results after delete, got %v", items)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"query": "lease"},
+		{"owner_id": ownerID, "query": " "},
+		{"owner_id": ownerID, "query": "lease", "k": 0},
+	} {
+		argsJSON, _ := json.Marshal(args)
+		_, err := server.handleSemanticSearch(context.Background(), &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: argsJSON}})
+		if !errors.Is(err, mcperrors.ErrValidation) {
+			t.Errorf("Expected a validation error for %v, got %v", args, err)
+		}
+	}
+}
+
+func TestListingScopeAccess(t *testing.T) {
+	repo := memoryrepo.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(repo),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	config := DefaultConfig(service)
+	config.AdminService = admin.New(repo)
+	config.AuthEnabled = true
+	config.Authenticator = auth.NewAPIKeyAuthenticator()
+	config.Embedder = semantic.NewHashEmbedder(0)
+	config.RequireOwnerID = false
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	ownerID := uuid.New()
+	ownerCtx := auth.WithKeyInfo(context.Background(), &auth.KeyInfo{OwnerID: ownerID})
+	adminCtx := auth.WithKeyInfo(context.Background(), &auth.KeyInfo{OwnerID: ownerID, Scopes: []string{auth.ScopeAdmin}})
+	call := func(ctx context.Context, handler mcp.ToolHandler, args map[string]interface{}) error {
+		// Arguments each tool requires, ignored by the others
+		args["status"] = "uploaded"
+		data, _ := json.Marshal(args)
+		_, err := handler(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: data}})
+		return err
+	}
+
+	for name, handler := range map[string]mcp.ToolHandler{
+		"content_facets":  server.handleContentFacets,
+		"semantic_search": server.handleSemanticSearch,
+		"list_by_status":  server.handleListByStatus,
+	} {
+		// A malformed scope is rejected rather than dropped
+		if err := call(ownerCtx, handler, map[string]interface{}{"query": "report", "owner_id": "not-a-uuid"}); !errors.Is(err, mcperrors.ErrValidation) {
+			t.Errorf("%s: expected a validation error for a malformed owner_id, got %v", name, err)
+		}
+		if err := call(ownerCtx, handler, map[string]interface{}{"query": "report", "owner_id": ownerID.String(), "tenant_id": 7}); !errors.Is(err, mcperrors.ErrValidation) {
+			t.Errorf("%s: expected a validation error for a malformed tenant_id, got %v", name, err)
+		}
+
+		// Keys only see their own owner; listing every owner takes an admin key
+		if err := call(ownerCtx, handler, map[string]interface{}{"query": "report", "owner_id": uuid.New().String()}); !errors.Is(err, mcperrors.ErrForbidden) {
+			t.Errorf("%s: expected another owner's scope to be forbidden, got %v", name, err)
+		}
+		if err := call(ownerCtx, handler, map[string]interface{}{"query": "report"}); !errors.Is(err, mcperrors.ErrForbidden) {
+			t.Errorf("%s: expected an unscoped listing to be forbidden, got %v", name, err)
+		}
+		if err := call(ownerCtx, handler, map[string]interface{}{"query": "report", "owner_id": ownerID.String()}); err != nil {
+			t.Errorf("%s: expected the owner's scope to be allowed, got %v", name, err)
+		}
+		if err := call(adminCtx, handler, map[string]interface{}{"query": "report"}); err != nil {
+			t.Errorf("%s: expected an admin key to list every owner, got %v", name, err)
+		}
+	}
+}
+
+func TestParseRangeFilter(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	ranges, err := parseRangeFilter(map[string]interface{}{
+		"created_after":  "-7d",
+		"created_before": "2025-03-14T11:00:00+01:00",
+		"updated_after":  "2025-03-01",
+		"updated_before": "+12h",
+		"min_size":       float64(10),
+		"max_size":       "1KB",
+	}, now)
+	if err != nil {
+		t.Fatalf("Failed to parse ranges: %v", err)
+	}
+	for name, got := range map[string]*time.Time{
+		"2025-03-07T12:00:00Z": ranges.createdAfter,
+		"2025-03-14T10:00:00Z": ranges.createdBefore,
+		"2025-03-01T00:00:00Z": ranges.updatedAfter,
+		"2025-03-15T00:00:00Z": ranges.updatedBefore,
+	} {
+		if want, _ := time.Parse(time.RFC3339, name); got == nil || !got.Equal(want) {
+			t.Errorf("Expected %s, got %v", name, got)
+		}
+	}
+	if *ranges.minSize != 10 || *ranges.maxSize != 1024 {
+		t.Errorf("Unexpected sizes %d and %d", *ranges.minSize, *ranges.maxSize)
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"created_after": "last week"},
+		{"updated_before": "7d"},
+		{"min_size": float64(-1)},
+		{"max_size": "big"},
+		{"created_after": "2025-03-02", "created_before": "2025-03-01"},
+		{"min_size": float64(10), "max_size": float64(5)},
+	} {
+		if _, err := parseRangeFilter(params, now); !errors.Is(err, mcperrors.ErrValidation) {
+			t.Errorf("Expected a validation error for %v, got %v", params, err)
+		}
+	}
+}
+
+func TestRangeFilters(t *testing.T) {
+	repo := memoryrepo.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(repo),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	config := DefaultConfig(service)
+	config.AdminService = admin.New(repo)
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ownerID := uuid.New().String()
+
+	smallID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": ownerID,
+		"name":     "Small report",
+		"data":     base64.StdEncoding.EncodeToString([]byte("tiny")),
+	})
+	time.Sleep(2 * time.Millisecond)
+	mark := time.Now().UTC()
+	time.Sleep(2 * time.Millisecond)
+	largeID := uploadTestContent(t, server, map[string]interface{}{
+		"owner_id": ownerID,
+		"name":     "Large report",
+		"data":     base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("x"), 2048)),
+	})
+
+	ids := func(result map[string]interface{}, key string) []string {
+		var ids []string
+		for _, item := range result["items"].([]interface{}) {
+			ids = append(ids, item.(map[string]interface{})[key].(string))
+		}
+		return ids
+	}
+	markArg := mark.Format(time.RFC3339Nano)
+
+	for name, tc := range map[string]struct {
+		args map[string]interface{}
+		want []string
+	}{
+		"created after":  {map[string]interface{}{"created_after": markArg}, []string{largeID.String()}},
+		"created before": {map[string]interface{}{"created_before": markArg}, []string{smallID.String()}},
+		"relative":       {map[string]interface{}{"created_after": "-1h", "updated_before": "+1h"}, []string{largeID.String(), smallID.String()}},
+		"future":         {map[string]interface{}{"created_after": "+1d"}, nil},
+		"min size":       {map[string]interface{}{"min_size": "1KB"}, []string{largeID.String()}},
+		"max size":       {map[string]interface{}{"max_size": float64(4)}, []string{smallID.String()}},
+	} {
+		tc.args["owner_id"] = ownerID
+		result := callTool(t, server.handleListContent, tc.args)
+		if got := ids(result, "id"); !slices.Equal(got, tc.want) {
+			t.Errorf("list_content %s: got %v, want %v", name, got, tc.want)
+		}
+		tc.args["query"] = "report"
+		result = callTool(t, server.handleSearchContent, tc.args)
+		if got := ids(result, "id"); !slices.Equal(got, tc.want) {
+			t.Errorf("search_content %s: got %v, want %v", name, got, tc.want)
+		}
+		delete(tc.args, "query")
+		tc.args["status"] = "uploaded"
+		result = callTool(t, server.handleListByStatus, tc.args)
+		if got := ids(result, "id"); len(got) != len(tc.want) {
+			t.Errorf("list_by_status %s: got %v, want %v", name, got, tc.want)
+		}
+	}
+
+	// Updates move content into an updated_after window
+	time.Sleep(2 * time.Millisecond)
+	updateMark := time.Now().UTC().Format(time.RFC3339Nano)
+	callTool(t, server.handleUpdateContent, map[string]interface{}{"content_id": smallID.String(), "description": "Revised"})
+	result := callTool(t, server.handleListContent, map[string]interface{}{"owner_id": ownerID, "updated_after": updateMark})
+	if got := ids(result, "id"); !slices.Equal(got, []string{smallID.String()}) {
+		t.Errorf("Expected the updated content, got %v", got)
+	}
+
+	// The admin repository applies time bounds, also when paging by cursor
+	server.config.RequireOwnerID = false
+	result = callTool(t, server.handleListContent, map[string]interface{}{"created_before": markArg, "limit": 1})
+	if got := ids(result, "id"); !slices.Equal(got, []string{smallID.String()}) || result["next_cursor"] != nil {
+		t.Errorf("Expected only the small report in admin mode, got %v", result)
+	}
+
+	uploadTestDerived(t, server, smallID, "thumbnail_256")
+	result = callTool(t, server.handleListDerivedContent, map[string]interface{}{"parent_id": smallID.String(), "created_after": markArg})
+	if got := ids(result, "content_id"); len(got) != 1 {
+		t.Errorf("Expected the thumbnail created after the mark, got %v", got)
+	}
+	result = callTool(t, server.handleListDerivedContent, map[string]interface{}{"parent_id": smallID.String(), "created_before": markArg})
+	if got := ids(result, "content_id"); len(got) != 0 {
+		t.Errorf("Expected no thumbnail created before the mark, got %v", got)
+	}
+	result = callTool(t, server.handleListDerivedContent, map[string]interface{}{"parent_id": smallID.String(), "min_size": float64(100)})
+	if got := ids(result, "content_id"); len(got) != 0 {
+		t.Errorf("Expected no thumbnail of 100 bytes or more, got %v", got)
+	}
+}
+
+func TestListByStatusScoped(t *testing.T) {
+	repo := memoryrepo.New()
+	service, err := simplecontent.New(
+		simplecontent.WithRepository(repo),
+		simplecontent.WithBlobStore("default", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	config := DefaultConfig(service)
+	config.AdminService = admin.New(repo)
+	server, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ownerID, tenantID := uuid.New().String(), uuid.New().String()
+
+	var uploaded []string
+	for i := 0; i < 3; i++ {
+		id := uploadTestContent(t, server, map[string]interface{}{"owner_id": ownerID, "tenant_id": tenantID, "name": fmt.Sprintf("file %d", i)})
+		uploaded = append(uploaded, id.String())
+	}
+	uploadTestContent(t, server, map[string]interface{}{"owner_id": uuid.New().String(), "name": "Someone else's"})
+	callTool(t, server.handleCreateCollection, map[string]interface{}{"owner_id": ownerID, "name": "Projects"})
+
+	// Pages follow each other newest first, within the owner's content
+	var listed []string
+	args := map[string]interface{}{"status": "uploaded", "owner_id": ownerID, "limit": 2}
+	for {
+		result := callTool(t, server.handleListByStatus, args)
+		for _, item := range result["items"].([]interface{}) {
+			listed = append(listed, item.(map[string]interface{})["id"].(string))
+		}
+		next, ok := result["next_cursor"].(string)
+		if !ok {
+			break
+		}
+		args["cursor"] = next
+	}
+	slices.Reverse(uploaded)
+	if !slices.Equal(listed, uploaded) {
+		t.Errorf("Expected %v, got %v", uploaded, listed)
+	}
+
+	for name, tc := range map[string]struct {
+		args map[string]interface{}
+		want float64
+	}{
+		"owner":       {map[string]interface{}{"status": "uploaded", "owner_id": ownerID}, 3},
+		"tenant":      {map[string]interface{}{"status": "uploaded", "tenant_id": tenantID}, 3},
+		"collections": {map[string]interface{}{"status": "created", "owner_id": ownerID}, 0},
+		"size":        {map[string]interface{}{"status": "uploaded", "owner_id": ownerID, "min_size": float64(1)}, 3},
+	} {
+		tc.args["count_only"] = true
+		result := callTool(t, server.handleListByStatus, tc.args)
+		if result["total"] != tc.want || result["items"] != nil {
+			t.Errorf("%s: expected a total of %v, got %v", name, tc.want, result)
+		}
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"status": "uploaded"},
+		{"status": "uploaded", "owner_id": ownerID, "limit": 0},
+		{"status": "uploaded", "owner_id": ownerID, "cursor": "bogus"},
+	} {
+		argsJSON, _ := json.Marshal(args)
+		_, err := server.handleListByStatus(context.Background(), &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: argsJSON}})
+		if !errors.Is(err, mcperrors.ErrValidation) {
+			t.Errorf("Expected a validation error for %v, got %v", args, err)
+		}
+	}
+
+	// Without an admin service the owner's content is listed through the service
+	config = DefaultConfig(service)
+	config.RequireOwnerID = false
+	server, err = New(config)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	result := callTool(t, server.handleListByStatus, map[string]interface{}{"status": "uploaded", "owner_id": ownerID, "tenant_id": tenantID, "limit": 2})
+	if result["total"] != float64(3) || len(result["items"].([]interface{})) != 2 || result["next_cursor"] == nil {
+		t.Errorf("Expected a first page of 2 out of 3, got %v", result)
+	}
+	argsJSON, _ := json.Marshal(map[string]interface{}{"status": "uploaded"})
+	if _, err := server.handleListByStatus(context.Background(), &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: argsJSON}}); !errors.Is(err, mcperrors.ErrValidation) {
+		t.Errorf("Expected owner_id to be required without an admin service, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package thumbnail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/jobs"
+)
+
+// DerivationType is the derivation type of thumbnails
+const DerivationType = "thumbnail"
+
+// DefaultSizes are the sizes generated when neither the processor nor the
+// job specify any; they match the sizes get_thumbnails looks up by default
+var DefaultSizes = []int{256, 512, 720, 1024}
+
+// maxJobSizes is the number of sizes a job may request at most
+const maxJobSizes = 16
+
+// Processor generates thumbnail_<size> variants for image content
+type Processor struct {
+	sizes     []int
+	maxPixels int
+
+	// MaxSize is the largest size a job may request (default 4096)
+	MaxSize int
+}
+
+// NewProcessor creates a thumbnail processor generating the given sizes by
+// default. Jobs may override them with a "sizes" parameter. Images with more
+// than maxPixels pixels (DefaultMaxPixels if not positive) are not decoded.
+func NewProcessor(sizes []int, maxPixels int) *Processor {
+	if len(sizes) == 0 {
+		sizes = DefaultSizes
+	}
+	return &Processor{sizes: sizes, maxPixels: maxPixels, MaxSize: 4096}
+}
+
+// Name implements jobs.Processor
+func (p *Processor) Name() string {
+	return "thumbnail"
+}
+
+// Supports implements jobs.Processor
+func (p *Processor) Supports(mimeType, derivationType string) bool {
+	return derivationType == DerivationType && Supported(mimeType)
+}
+
+// Variants implements jobs.Processor
+func (p *Processor) Variants(job *jobs.Job) ([]string, error) {
+	sizes, err := p.jobSizes(job)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make([]string, len(sizes))
+	for i, size := range sizes {
+		variants[i] = variant(size)
+	}
+	return variants, nil
+}
+
+// Process implements jobs.Processor
+func (p *Processor) Process(ctx context.Context, input *jobs.Input) ([]*jobs.Output, error) {
+	sizes, err := p.jobSizes(input.Job)
+	if err != nil {
+		return nil, jobs.Permanent(err)
+	}
+
+	thumbnails, err := Generate(input.Data, sizes, p.maxPixels)
+	if errors.Is(err, ErrUnsupported) || errors.Is(err, ErrTooLarge) {
+		// The data will not decode any better on the next attempt
+		return nil, jobs.Permanent(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]*jobs.Output, 0, len(sizes))
+	for _, size := range sizes {
+		thumb := thumbnails[size]
+		outputs = append(outputs, &jobs.Output{
+			Variant:  variant(size),
+			MimeType: thumb.MimeType,
+			Data:     thumb.Data,
+			Metadata: map[string]interface{}{
+				"size":   size,
+				"width":  thumb.Width,
+				"height": thumb.Height,
+			},
+		})
+	}
+	return outputs, nil
+}
+
+// jobSizes returns the sizes requested by the job's "sizes" parameter, or the
+// processor's sizes, without duplicates
+func (p *Processor) jobSizes(job *jobs.Job) ([]int, error) {
+	sizes := p.sizes
+	if raw, ok := job.Params["sizes"]; ok {
+		values, ok := raw.([]interface{})
+		if !ok || len(values) == 0 {
+			return nil, fmt.Errorf("sizes must be a non-empty array of integers")
+		}
+		if len(values) > maxJobSizes {
+			return nil, fmt.Errorf("at most %d sizes can be requested", maxJobSizes)
+		}
+		sizes = make([]int, 0, len(values))
+		for _, value := range values {
+			size, ok := value.(float64)
+			if !ok || size <= 0 || size != float64(int(size)) {
+				return nil, fmt.Errorf("sizes must be positive integers")
+			}
+			if p.MaxSize > 0 && int(size) > p.MaxSize {
+				return nil, fmt.Errorf("sizes cannot be greater than %d", p.MaxSize)
+			}
+			sizes = append(sizes, int(size))
+		}
+	}
+
+	seen := make(map[int]bool, len(sizes))
+	unique := make([]int, 0, len(sizes))
+	for _, size := range sizes {
+		if !seen[size] {
+			seen[size] = true
+			unique = append(unique, size)
+		}
+	}
+	return unique, nil
+}
+
+// variant returns the variant name of a thumbnail size
+func variant(size int) string {
+	return fmt.Sprintf("thumbnail_%d", size)
+}
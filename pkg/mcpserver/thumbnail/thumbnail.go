@@ -0,0 +1,152 @@
+// Package thumbnail generates image thumbnails in pure Go.
+//
+// PNG, JPEG and GIF images are decoded with the standard library and WebP
+// with golang.org/x/image. Thumbnails keep the aspect ratio of the source and
+// are never enlarged. Sources that may carry transparency (PNG, GIF) are
+// encoded as PNG, everything else as JPEG. Images declaring more than a
+// maximum number of pixels are rejected before their pixels are decoded.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Register the WebP decoder
+)
+
+// ErrUnsupported is returned for data that is not a supported image
+var ErrUnsupported = errors.New("unsupported image format")
+
+// ErrTooLarge is returned for images with more pixels than allowed
+var ErrTooLarge = errors.New("image too large")
+
+// DefaultMaxPixels is the largest number of pixels decoded by default (40 MP)
+const DefaultMaxPixels = 40_000_000
+
+// jpegQuality is the quality used for JPEG thumbnails
+const jpegQuality = 85
+
+// supportedTypes lists the MIME types that can be decoded
+var supportedTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/jpg":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Thumbnail is an encoded thumbnail image
+type Thumbnail struct {
+	Data     []byte
+	MimeType string
+	Width    int
+	Height   int
+}
+
+// Supported reports whether images of the given MIME type can be thumbnailed
+func Supported(mimeType string) bool {
+	mimeType = strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+	return supportedTypes[mimeType]
+}
+
+// Decode decodes an image and returns it with its format name (png, jpeg,
+// gif, webp). Images with more than maxPixels pixels (DefaultMaxPixels if not
+// positive) are rejected with ErrTooLarge from their header, before the
+// pixels are allocated.
+func Decode(data []byte, maxPixels int) (image.Image, string, error) {
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, "", ErrUnsupported
+		}
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > int64(maxPixels) {
+		return nil, "", fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrTooLarge, config.Width, config.Height, maxPixels)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, format, nil
+}
+
+// Resize scales img so that its longest side is at most size pixels,
+// keeping the aspect ratio. Images that already fit are returned unchanged.
+func Resize(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if size <= 0 || (width <= size && height <= size) {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// Encode encodes a thumbnail, choosing PNG for formats that may carry
+// transparency and JPEG otherwise
+func Encode(img image.Image, sourceFormat string) (*Thumbnail, error) {
+	var buf bytes.Buffer
+	mimeType := "image/jpeg"
+
+	switch sourceFormat {
+	case "png", "gif":
+		mimeType = "image/png"
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+	default:
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+	}
+
+	bounds := img.Bounds()
+	return &Thumbnail{
+		Data:     buf.Bytes(),
+		MimeType: mimeType,
+		Width:    bounds.Dx(),
+		Height:   bounds.Dy(),
+	}, nil
+}
+
+// Generate decodes data once, if it has at most maxPixels pixels, and returns
+// a thumbnail for each size
+func Generate(data []byte, sizes []int, maxPixels int) (map[int]*Thumbnail, error) {
+	img, format, err := Decode(data, maxPixels)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnails := make(map[int]*Thumbnail, len(sizes))
+	for _, size := range sizes {
+		thumb, err := Encode(Resize(img, size), format)
+		if err != nil {
+			return nil, err
+		}
+		thumbnails[size] = thumb
+	}
+	return thumbnails, nil
+}
@@ -0,0 +1,107 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/tendant/simple-content-mcp/pkg/mcpserver/jobs"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerate(t *testing.T) {
+	thumbnails, err := Generate(encodePNG(t, 300, 600), []int{100, 1000}, 0)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	// Longest side is scaled to the size, keeping the aspect ratio
+	small := thumbnails[100]
+	if small.Width != 50 || small.Height != 100 || small.MimeType != "image/png" {
+		t.Errorf("Unexpected thumbnail: %dx%d %s", small.Width, small.Height, small.MimeType)
+	}
+
+	// Images are never enlarged
+	large := thumbnails[1000]
+	if large.Width != 300 || large.Height != 600 {
+		t.Errorf("Expected original size, got %dx%d", large.Width, large.Height)
+	}
+
+	if _, format, err := Decode(small.Data, 0); err != nil || format != "png" {
+		t.Errorf("Expected a decodable PNG, got %q (%v)", format, err)
+	}
+}
+
+func TestGenerateJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 640, 480)), nil); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+
+	thumbnails, err := Generate(buf.Bytes(), []int{320}, 0)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	thumb := thumbnails[320]
+	if thumb.Width != 320 || thumb.Height != 240 || thumb.MimeType != "image/jpeg" {
+		t.Errorf("Unexpected thumbnail: %dx%d %s", thumb.Width, thumb.Height, thumb.MimeType)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	if _, err := Generate([]byte("not an image"), []int{100}, 0); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+
+	if !Supported("image/JPEG") || !Supported("image/webp; charset=binary") || Supported("application/pdf") {
+		t.Error("Unexpected Supported result")
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	if _, err := Generate(encodePNG(t, 100, 100), []int{50}, 5000); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+
+	// A header declaring 50000x50000 pixels is rejected without decoding them
+	data := encodePNG(t, 1, 1)
+	binary.BigEndian.PutUint32(data[16:], 50000)
+	binary.BigEndian.PutUint32(data[20:], 50000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	if _, _, err := Decode(data, 0); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge for a forged header, got %v", err)
+	}
+}
+
+func TestProcessorSizes(t *testing.T) {
+	p := NewProcessor(nil, 0)
+	variants, err := p.Variants(&jobs.Job{Params: map[string]interface{}{"sizes": []interface{}{256.0, 64.0, 256.0}}})
+	if err != nil || len(variants) != 2 || variants[0] != "thumbnail_256" {
+		t.Errorf("Unexpected variants %v (%v)", variants, err)
+	}
+
+	if _, err := p.Variants(&jobs.Job{Params: map[string]interface{}{"sizes": []interface{}{100000.0}}}); err == nil {
+		t.Error("Expected sizes above MaxSize to be rejected")
+	}
+	many := make([]interface{}, maxJobSizes+1)
+	for i := range many {
+		many[i] = float64(i + 1)
+	}
+	if _, err := p.Variants(&jobs.Job{Params: map[string]interface{}{"sizes": many}}); err == nil {
+		t.Error("Expected too many sizes to be rejected")
+	}
+}
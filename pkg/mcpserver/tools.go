@@ -17,6 +17,60 @@ func (s *Server) registerTools() error {
 		ownerIDDesc = "Filter by owner ID (required)"
 	}
 
+	// Shared by list_content, search_content and content_facets
+	metadataFiltersSchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "JSON path into the custom metadata, e.g. $.customer.id, $.items[0].sku or $[\"key.with.dots\"]",
+				},
+				"op": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"eq", "ne", "in", "exists", "gt", "gte", "lt", "lte"},
+					"description": "eq/ne compare JSON values; in takes an array of values; exists takes true (default) or false; gt/gte/lt/lte compare numbers or dates (YYYY-MM-DD or RFC 3339)",
+				},
+				"value": map[string]interface{}{
+					"description": "Value to compare with",
+				},
+			},
+			"required": []string{"path", "op"},
+		},
+		"description": "Only include content whose custom metadata matches all of these conditions",
+	}
+
+	// Shared by list_content, search_content, list_by_status, list_derived_content
+	// and migrate_storage
+	cursorSchema := map[string]interface{}{
+		"type":        "string",
+		"description": "Opaque next_cursor of the previous page, with the same filters (replaces offset)",
+	}
+
+	// Shared by list_content and search_content
+	orderSchema := map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{"asc", "desc"},
+		"description": "Sort order; ties are broken by created_at in the same order",
+		"default":     "desc",
+	}
+
+	// Range filters, shared by list_content, search_content, list_by_status
+	// and list_derived_content. Bounds are inclusive.
+	timeBoundSchema := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":        "string",
+			"description": description + ": RFC 3339 (2025-01-01T12:00:00Z), a date (midnight UTC) or relative to now (-7d, -12h, -2w)",
+		}
+	}
+	sizeBoundSchema := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":        []string{"integer", "string"},
+			"description": description + ", in bytes or with a unit (100KB, 5MB, 1GB)",
+		}
+	}
+
 	// Define all tools with their schemas
 	tools := []*mcp.Tool{
 		{
@@ -71,13 +125,23 @@ func (s *Server) registerTools() error {
 						"type":        "object",
 						"description": "Custom metadata",
 					},
+					"expires_at": map[string]interface{}{
+						"type":        "string",
+						"format":      "date-time",
+						"description": "Time after which the content is removed (RFC 3339)",
+					},
+					"ttl_seconds": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     1,
+						"description": "Seconds from now until the content is removed (alternative to expires_at)",
+					},
 				},
 				"required": []string{"owner_id", "name", "data"},
 			},
 		},
 		{
 			Name:        "get_content",
-			Description: "Retrieve content metadata by ID",
+			Description: "Retrieve content metadata by ID, including an etag for use with if_match",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -126,6 +190,11 @@ func (s *Server) registerTools() error {
 						"format":      "uuid",
 						"description": "Filter by tenant ID",
 					},
+					"collection_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Only include content that is a direct member of this collection",
+					},
 					"status": map[string]interface{}{
 						"type":        "string",
 						"description": "Filter by status",
@@ -137,6 +206,13 @@ func (s *Server) registerTools() error {
 						},
 						"description": "Filter by tags",
 					},
+					"metadata_filters": metadataFiltersSchema,
+					"created_after":    timeBoundSchema("Only include content created at or after this time"),
+					"created_before":   timeBoundSchema("Only include content created at or before this time"),
+					"updated_after":    timeBoundSchema("Only include content updated at or after this time"),
+					"updated_before":   timeBoundSchema("Only include content updated at or before this time"),
+					"min_size":         sizeBoundSchema("Only include content of at least this size"),
+					"max_size":         sizeBoundSchema("Only include content of at most this size"),
 					"limit": map[string]interface{}{
 						"type":        "integer",
 						"description": "Maximum number of results",
@@ -148,13 +224,21 @@ func (s *Server) registerTools() error {
 						"description": "Offset for pagination",
 						"default":     0,
 					},
+					"cursor": cursorSchema,
+					"sort_by": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"created_at", "updated_at", "name", "size"},
+						"description": "Sort key",
+						"default":     "created_at",
+					},
+					"order": orderSchema,
 				},
 				"required": listContentRequired,
 			},
 		},
 		{
 			Name:        "download_content",
-			Description: "Download content data (returns download URL or base64)",
+			Description: "Download content data (returns download URL, base64, or the extracted plain text of documents)",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -165,8 +249,8 @@ func (s *Server) registerTools() error {
 					},
 					"format": map[string]interface{}{
 						"type":        "string",
-						"enum":        []string{"url", "base64"},
-						"description": "Return format",
+						"enum":        []string{"url", "base64", "extracted_text"},
+						"description": "Return format; extracted_text returns the UTF-8 text of text, Markdown, HTML, JSON, CSV, DOCX and ODT documents",
 						"default":     "url",
 					},
 				},
@@ -175,7 +259,7 @@ func (s *Server) registerTools() error {
 		},
 		{
 			Name:        "update_content",
-			Description: "Update content metadata",
+			Description: "Update content metadata and return a before/after diff",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -197,11 +281,45 @@ func (s *Server) registerTools() error {
 						"items": map[string]interface{}{
 							"type": "string",
 						},
-						"description": "New tags",
+						"description": "Replace the whole tag list",
+					},
+					"add_tags": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+						"description": "Tags to add, keeping existing tags",
+					},
+					"remove_tags": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+						"description": "Tags to remove",
 					},
 					"metadata": map[string]interface{}{
-						"type":        "object",
-						"description": "New custom metadata",
+						"type":        []string{"object", "array"},
+						"description": "Custom metadata changes, interpreted according to patch_mode (an array of operations for json_patch). Merged into the existing metadata by default: keys left out are kept, so remove a key by setting it to null or use patch_mode replace",
+					},
+					"patch_mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"merge", "replace", "json_patch"},
+						"description": "merge (default): RFC 7396 merge patch (null removes a key); replace: overwrite all custom metadata, the behavior before patch_mode existed; json_patch: RFC 6902 operations",
+						"default":     "merge",
+					},
+					"expires_at": map[string]interface{}{
+						"type":        "string",
+						"format":      "date-time",
+						"description": "Time after which the content is removed (RFC 3339); null clears the expiry",
+					},
+					"ttl_seconds": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     1,
+						"description": "Seconds from now until the content is removed (alternative to expires_at)",
+					},
+					"if_match": map[string]interface{}{
+						"type":        "string",
+						"description": "Only update if the content's etag (from get_content) still matches",
 					},
 				},
 				"required": []string{"content_id"},
@@ -209,7 +327,157 @@ func (s *Server) registerTools() error {
 		},
 		{
 			Name:        "delete_content",
-			Description: "Soft delete content",
+			Description: "Soft delete content, optionally cascading to derived content",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"content_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Content ID",
+					},
+					"cascade": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also delete derived content (thumbnails, previews) recursively",
+						"default":     false,
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Preview what would be deleted without deleting anything",
+						"default":     false,
+					},
+					"if_match": map[string]interface{}{
+						"type":        "string",
+						"description": "Only delete if the content's etag (from get_content) still matches",
+					},
+				},
+				"required": []string{"content_id"},
+			},
+		},
+		{
+			Name:        "copy_content",
+			Description: "Duplicate content, optionally into another owner or tenant, with its derived content",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"content_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Content ID to copy",
+					},
+					"target_owner_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Owner of the copy (defaults to the source owner)",
+					},
+					"target_tenant_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Tenant of the copy (defaults to the source tenant)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the copy (defaults to the source name)",
+					},
+					"include_derived": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also copy derived content (thumbnails, previews) recursively",
+						"default":     false,
+					},
+					"storage_backend": map[string]interface{}{
+						"type":        "string",
+						"description": "Storage backend for the copy and its derived content (default: the backend of each source)",
+					},
+				},
+				"required": []string{"content_id"},
+			},
+		},
+		{
+			Name:        "transfer_content",
+			Description: "Transfer ownership of content to another owner or tenant",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"content_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Content ID to transfer",
+					},
+					"target_owner_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "New owner ID",
+					},
+					"target_tenant_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "New tenant ID (defaults to the current tenant)",
+					},
+					"include_derived": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also transfer derived content",
+						"default":     true,
+					},
+				},
+				"required": []string{"content_id", "target_owner_id"},
+			},
+		},
+		{
+			Name:        "migrate_storage",
+			Description: "Move content blobs to another storage backend, verifying checksums before removing the source",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"target_backend": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the storage backend to move blobs to",
+					},
+					"source_backend": map[string]interface{}{
+						"type":        "string",
+						"description": "Only move blobs currently stored in this backend",
+					},
+					"content_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Migrate a single content item (otherwise the filters below are used)",
+					},
+					"include_derived": map[string]interface{}{
+						"type":        "boolean",
+						"description": "With content_id, also migrate its derived content",
+						"default":     false,
+					},
+					"owner_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Filter by owner ID",
+					},
+					"tenant_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Filter by tenant ID",
+					},
+					"status": map[string]interface{}{
+						"type":        "string",
+						"description": "Filter by content status",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of content items to migrate; contents with nothing to move are skipped and don't count",
+						"minimum":     1,
+					},
+					"cursor": cursorSchema,
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Report what would be migrated without moving anything",
+						"default":     false,
+					},
+				},
+				"required": []string{"target_backend"},
+			},
+		},
+		{
+			Name:        "transition_content_status",
+			Description: "Move content through its lifecycle (archive, unarchive, mark failed, reprocess) with validated transitions and an audit trail",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -218,19 +486,185 @@ func (s *Server) registerTools() error {
 						"format":      "uuid",
 						"description": "Content ID",
 					},
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"archive", "unarchive", "mark_failed", "reprocess"},
+						"description": "Named lifecycle action (use either action or to_status). reprocess returns failed or processed content to uploaded and queues its derivations again, returning their job_ids",
+					},
+					"to_status": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"created", "uploading", "uploaded", "processing", "processed", "failed", "archived"},
+						"description": "Target status (use either action or to_status)",
+					},
+					"reason": map[string]interface{}{
+						"type":        "string",
+						"description": "Why the transition is made (recorded in the status history)",
+					},
+					"actor": map[string]interface{}{
+						"type":        "string",
+						"description": "Who is making the transition, e.g. an agent or user name",
+					},
+					"if_match": map[string]interface{}{
+						"type":        "string",
+						"description": "Only transition if the content's etag (from get_content) still matches",
+					},
 				},
 				"required": []string{"content_id"},
 			},
 		},
+		{
+			Name:        "create_collection",
+			Description: "Create a collection (folder) for grouping content, optionally nested in another collection",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"owner_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Owner UUID",
+					},
+					"tenant_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Tenant UUID (optional)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Collection name",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "Collection description",
+					},
+					"parent_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Collection to nest the new collection in",
+					},
+				},
+				"required": []string{"owner_id", "name"},
+			},
+		},
+		{
+			Name:        "add_to_collection",
+			Description: "Add content or collections to a collection (a collection can be nested in only one parent)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"collection_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Collection ID",
+					},
+					"content_ids": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type":   "string",
+							"format": "uuid",
+						},
+						"description": "Content or collection IDs",
+					},
+				},
+				"required": []string{"collection_id", "content_ids"},
+			},
+		},
+		{
+			Name:        "remove_from_collection",
+			Description: "Remove content or nested collections from a collection without deleting them",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"collection_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Collection ID",
+					},
+					"content_ids": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type":   "string",
+							"format": "uuid",
+						},
+						"description": "Content or collection IDs",
+					},
+				},
+				"required": []string{"collection_id", "content_ids"},
+			},
+		},
+		{
+			Name:        "list_collection",
+			Description: "List the content and nested collections of a collection, or an owner's top-level collections when collection_id is omitted",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"collection_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Collection ID",
+					},
+					"owner_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Owner UUID (lists top-level collections when collection_id is omitted)",
+					},
+					"tenant_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Tenant UUID (optional)",
+					},
+					"recursive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include content of nested collections",
+						"default":     false,
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of items",
+						"default":     50,
+					},
+					"offset": map[string]interface{}{
+						"type":        "integer",
+						"description": "Offset for pagination",
+						"default":     0,
+					},
+				},
+			},
+		},
+		{
+			Name:        "delete_collection",
+			Description: "Delete a collection; member content is kept",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"collection_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Collection ID",
+					},
+					"recursive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also delete nested collections (required when there are any)",
+						"default":     false,
+					},
+				},
+				"required": []string{"collection_id"},
+			},
+		},
 		{
 			Name:        "search_content",
-			Description: "Search content by metadata, tags, or full-text",
+			Description: "Search content by name, tags and description, or by the text of the content with scope=body. Results are ranked by relevance and include a score and highlighted snippets; body results are passages with their byte offsets in the text",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"query": map[string]interface{}{
 						"type":        "string",
-						"description": "Search query",
+						"description": "Query, e.g. tag:invoice AND mime:application/pdf AND created:>2025-01-01 AND size:<5MB AND \"quarterly report\" -draft. Plain words must all match the name, tags or description (prefixes and word forms match). Full syntax: resource schema://search-query",
+					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"metadata", "body"},
+						"description": "metadata (default) matches name, tags and description and returns contents; body matches the text of text-like content and extracted text and returns passages with content_id, start and end byte offsets (source_id is set when the text is a derived text variant)",
+						"default":     "metadata",
 					},
 					"owner_id": map[string]interface{}{
 						"type":        "string",
@@ -242,12 +676,17 @@ func (s *Server) registerTools() error {
 						"format":      "uuid",
 						"description": "Filter by tenant ID",
 					},
+					"collection_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Only include content that is a direct member of this collection",
+					},
 					"tags": map[string]interface{}{
 						"type": "array",
 						"items": map[string]interface{}{
 							"type": "string",
 						},
-						"description": "Filter by tags",
+						"description": "Only include content having all of these tags",
 					},
 					"status": map[string]interface{}{
 						"type": "array",
@@ -256,6 +695,13 @@ func (s *Server) registerTools() error {
 						},
 						"description": "Filter by status values",
 					},
+					"metadata_filters": metadataFiltersSchema,
+					"created_after":    timeBoundSchema("Only include content created at or after this time"),
+					"created_before":   timeBoundSchema("Only include content created at or before this time"),
+					"updated_after":    timeBoundSchema("Only include content updated at or after this time"),
+					"updated_before":   timeBoundSchema("Only include content updated at or before this time"),
+					"min_size":         sizeBoundSchema("Only include content of at least this size"),
+					"max_size":         sizeBoundSchema("Only include content of at most this size"),
 					"limit": map[string]interface{}{
 						"type":        "integer",
 						"description": "Maximum number of results",
@@ -266,6 +712,14 @@ func (s *Server) registerTools() error {
 						"description": "Offset for pagination",
 						"default":     0,
 					},
+					"cursor": cursorSchema,
+					"sort_by": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"relevance", "created_at", "updated_at", "name", "size"},
+						"description": "Sort key (default: relevance; results without query words are newest first); scope body is always sorted by relevance",
+						"default":     "relevance",
+					},
+					"order": orderSchema,
 				},
 			},
 		},
@@ -295,6 +749,12 @@ func (s *Server) registerTools() error {
 						},
 						"description": "Filter by multiple variants",
 					},
+					"created_after":  timeBoundSchema("Only include content created at or after this time"),
+					"created_before": timeBoundSchema("Only include content created at or before this time"),
+					"updated_after":  timeBoundSchema("Only include content updated at or after this time"),
+					"updated_before": timeBoundSchema("Only include content updated at or before this time"),
+					"min_size":       sizeBoundSchema("Only include content of at least this size"),
+					"max_size":       sizeBoundSchema("Only include content of at most this size"),
 					"limit": map[string]interface{}{
 						"type":        "integer",
 						"description": "Maximum number of results",
@@ -305,10 +765,70 @@ func (s *Server) registerTools() error {
 						"description": "Offset for pagination",
 						"default":     0,
 					},
+					"cursor": cursorSchema,
 				},
 				"required": []string{"parent_id"},
 			},
 		},
+		{
+			Name:        "create_derived_content",
+			Description: "Register agent-produced content (summary, translation, thumbnail, ...) as derived content of a parent",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"parent_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Parent content ID",
+					},
+					"derivation_type": map[string]interface{}{
+						"type":        "string",
+						"description": "Derivation type (thumbnail, preview, summary, ...); inferred from the variant prefix if empty",
+					},
+					"variant": map[string]interface{}{
+						"type":        "string",
+						"description": "Specific variant, e.g. thumbnail_256 or summary_en",
+					},
+					"data": map[string]interface{}{
+						"type":        "string",
+						"description": "Base64 encoded data or URL to download from",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Content name (defaults to the parent name with the variant)",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "Content description",
+					},
+					"document_type": map[string]interface{}{
+						"type":        "string",
+						"description": "MIME type of the derived content",
+					},
+					"file_name": map[string]interface{}{
+						"type":        "string",
+						"description": "File name",
+					},
+					"storage_backend": map[string]interface{}{
+						"type":        "string",
+						"description": "Storage backend name (default if empty)",
+						"default":     "default",
+					},
+					"tags": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+						"description": "Tags for categorization",
+					},
+					"metadata": map[string]interface{}{
+						"type":        "object",
+						"description": "Derivation metadata (e.g. model, language, source pages)",
+					},
+				},
+				"required": []string{"parent_id", "variant", "data"},
+			},
+		},
 		{
 			Name:        "get_thumbnails",
 			Description: "Get thumbnails by size for an image (convenience wrapper)",
@@ -331,6 +851,88 @@ func (s *Server) registerTools() error {
 				"required": []string{"parent_id"},
 			},
 		},
+		{
+			Name:        "request_derivation",
+			Description: "Queue a background job that derives content (e.g. thumbnails) from an uploaded content with a registered processor",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"content_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Source content ID",
+					},
+					"derivation_type": map[string]interface{}{
+						"type":        "string",
+						"description": "Derivation type, e.g. thumbnail",
+					},
+					"params": map[string]interface{}{
+						"type":        "object",
+						"description": "Processor parameters, e.g. {\"sizes\": [256, 512]} for thumbnails (at most 16 sizes, each up to the server's thumbnail size limit)",
+					},
+					"max_attempts": map[string]interface{}{
+						"type":        "integer",
+						"description": "Attempts before the job fails (defaults to the server setting, capped by the server's attempts limit)",
+						"minimum":     1,
+					},
+				},
+				"required": []string{"content_id", "derivation_type"},
+			},
+		},
+		{
+			Name:        "get_job",
+			Description: "Get the status, attempts, last error and results of a derivation job",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"job_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Job ID",
+					},
+				},
+				"required": []string{"job_id"},
+			},
+		},
+		{
+			Name:        "list_jobs",
+			Description: "List derivation jobs of a content or an owner, newest first. count is the number of jobs on the page.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"content_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Filter by source content ID",
+					},
+					"owner_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Filter by owner ID",
+					},
+					"tenant_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Filter by tenant ID",
+					},
+					"status": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"queued", "running", "succeeded", "failed"},
+						"description": "Filter by job status",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of results",
+						"default":     50,
+					},
+					"offset": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of results to skip",
+						"default":     0,
+					},
+				},
+			},
+		},
 		{
 			Name:        "get_content_status",
 			Description: "Get content processing status and derived content availability",
@@ -346,9 +948,39 @@ func (s *Server) registerTools() error {
 				"required": []string{"content_id"},
 			},
 		},
+		{
+			Name:        "wait_for_content",
+			Description: "Wait until content reaches a status or its derived variants are processed, instead of polling get_content_status. Sends progress notifications while waiting and returns the final status",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"content_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Content ID",
+					},
+					"status": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"created", "uploading", "uploaded", "processing", "processed", "failed", "archived", "deleted"},
+						"description": "Status to wait for",
+					},
+					"variants": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Derived variants (e.g. thumbnail_256, text) that must be processed",
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum time to wait",
+						"default":     30,
+					},
+				},
+				"required": []string{"content_id"},
+			},
+		},
 		{
 			Name:        "list_by_status",
-			Description: "List content by lifecycle status (for monitoring/workers)",
+			Description: "List an owner's or tenant's content by lifecycle status (for monitoring/workers), newest first, or count it with count_only",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -368,17 +1000,117 @@ func (s *Server) registerTools() error {
 					"owner_id": map[string]interface{}{
 						"type":        "string",
 						"format":      "uuid",
-						"description": "Optional: filter by owner ID",
+						"description": "Filter by owner ID (owner_id or tenant_id is required when the server requires owner_id)",
 					},
+					"tenant_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Filter by tenant ID",
+					},
+					"created_after":  timeBoundSchema("Only include content created at or after this time"),
+					"created_before": timeBoundSchema("Only include content created at or before this time"),
+					"updated_after":  timeBoundSchema("Only include content updated at or after this time"),
+					"updated_before": timeBoundSchema("Only include content updated at or before this time"),
+					"min_size":       sizeBoundSchema("Only include content of at least this size"),
+					"max_size":       sizeBoundSchema("Only include content of at most this size"),
 					"limit": map[string]interface{}{
 						"type":        "integer",
 						"description": "Maximum number of results",
 						"default":     100,
 					},
+					"cursor": cursorSchema,
+					"count_only": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only return the total number of matching content, without items",
+						"default":     false,
+					},
 				},
 				"required": []string{"status"},
 			},
 		},
+		{
+			Name:        "content_facets",
+			Description: "Summarize content in one call: counts by tag, MIME type, status, derivation type, storage backend and creation date, plus total bytes",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"owner_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Filter by owner ID (owner_id or tenant_id is required when the server requires owner_id)",
+					},
+					"tenant_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Filter by tenant ID",
+					},
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Only count content matching this search query (see schema://search-query)",
+					},
+					"status": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+						"description": "Only count content having one of these statuses",
+					},
+					"tags": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+						"description": "Only count content having all of these tags",
+					},
+					"metadata_filters": metadataFiltersSchema,
+					"collection_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Only count the members of this collection",
+					},
+					"interval": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"day", "week", "month", "year"},
+						"description": "Width of the creation date buckets (UTC; weeks start on Monday)",
+						"default":     "month",
+					},
+				},
+			},
+		},
+		{
+			Name:        "semantic_search",
+			Description: "Find content by meaning rather than exact words, e.g. \"the contract about office leases\". Returns the k contents whose name, description or text is nearest to the query, with a similarity score and the field that matched (text matches include start and end byte offsets in the text)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Natural language description of the content to find",
+					},
+					"owner_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Filter by owner ID (owner_id or tenant_id is required when the server requires owner_id)",
+					},
+					"tenant_id": map[string]interface{}{
+						"type":        "string",
+						"format":      "uuid",
+						"description": "Filter by tenant ID",
+					},
+					"k": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of results",
+						"default":     10,
+					},
+					"min_score": map[string]interface{}{
+						"type":        "number",
+						"description": "Least cosine similarity of a result, between -1 and 1",
+						"default":     0,
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
 		{
 			Name:        "batch_upload",
 			Description: "Upload multiple content items in one operation",
@@ -435,11 +1167,31 @@ func (s *Server) registerTools() error {
 									"type":        "string",
 									"description": "Storage backend name",
 								},
+								"expires_at": map[string]interface{}{
+									"type":        "string",
+									"format":      "date-time",
+									"description": "Time after which the content is removed (RFC 3339)",
+								},
+								"ttl_seconds": map[string]interface{}{
+									"type":        "integer",
+									"minimum":     1,
+									"description": "Seconds from now until the content is removed (alternative to expires_at)",
+								},
 							},
 							"required": []string{"name", "data"},
 						},
 						"description": "Array of content items to upload",
 					},
+					"expires_at": map[string]interface{}{
+						"type":        "string",
+						"format":      "date-time",
+						"description": "Default expiry for items without their own (RFC 3339)",
+					},
+					"ttl_seconds": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     1,
+						"description": "Default TTL in seconds for items without their own expiry",
+					},
 				},
 				"required": []string{"owner_id", "items"},
 			},
@@ -499,16 +1251,48 @@ func (s *Server) getToolHandler(name string) mcp.ToolHandler {
 		return s.handleUpdateContent
 	case "delete_content":
 		return s.handleDeleteContent
+	case "copy_content":
+		return s.handleCopyContent
+	case "transfer_content":
+		return s.handleTransferContent
+	case "migrate_storage":
+		return s.handleMigrateStorage
+	case "transition_content_status":
+		return s.handleTransitionContentStatus
+	case "create_collection":
+		return s.handleCreateCollection
+	case "add_to_collection":
+		return s.handleAddToCollection
+	case "remove_from_collection":
+		return s.handleRemoveFromCollection
+	case "list_collection":
+		return s.handleListCollection
+	case "delete_collection":
+		return s.handleDeleteCollection
 	case "search_content":
 		return s.handleSearchContent
+	case "semantic_search":
+		return s.handleSemanticSearch
 	case "list_derived_content":
 		return s.handleListDerivedContent
+	case "create_derived_content":
+		return s.handleCreateDerivedContent
 	case "get_thumbnails":
 		return s.handleGetThumbnails
+	case "request_derivation":
+		return s.handleRequestDerivation
+	case "get_job":
+		return s.handleGetJob
+	case "list_jobs":
+		return s.handleListJobs
 	case "get_content_status":
 		return s.handleGetContentStatus
+	case "wait_for_content":
+		return s.handleWaitForContent
 	case "list_by_status":
 		return s.handleListByStatus
+	case "content_facets":
+		return s.handleContentFacets
 	case "batch_upload":
 		return s.handleBatchUpload
 	case "batch_get_details":